@@ -5,8 +5,10 @@ import (
 	"log"
 	"os"
 
+	"github.com/3lvia/cli/pkg/auth"
 	"github.com/3lvia/cli/pkg/build"
 	"github.com/3lvia/cli/pkg/deploy"
+	"github.com/3lvia/cli/pkg/promote"
 	"github.com/3lvia/cli/pkg/scan"
 	"github.com/urfave/cli/v2"
 )
@@ -28,8 +30,10 @@ func main() {
 		EnableBashCompletion: true,
 		Version:              string(versionFile),
 		Commands: []*cli.Command{
+			auth.Command,
 			build.Command,
 			deploy.Command,
+			promote.Command,
 			scan.Command,
 		},
 	}
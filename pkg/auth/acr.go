@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+const ElviaContainerRegistry = "containerregistryelvia.azurecr.io"
+
+// Username used by `az acr login`, the password being an Entra ID refresh token.
+const acrTokenUsername = "00000000-0000-0000-0000-000000000000"
+
+func acrRegistryName(registry string) (string, error) {
+	registryName, _, _ := strings.Cut(registry, ".")
+	if registryName == "" {
+		return "", fmt.Errorf("Invalid registry name: %s", registry)
+	}
+
+	return registryName, nil
+}
+
+// AuthenticateACR logs Docker in to the Azure Container Registry. With a client ID, and a federated token
+// or a GitHub Actions OIDC token, this is done without the Azure CLI. Otherwise, the Azure CLI is used.
+func AuthenticateACR(
+	registry string,
+	tenantID string,
+	subscriptionID string,
+	options *AzLoginCommandOptions,
+) error {
+	if options == nil {
+		options = &AzLoginCommandOptions{}
+	}
+
+	registryName, err := acrRegistryName(registry)
+	if err != nil {
+		return err
+	}
+
+	if options.ClientID != "" {
+		federatedToken, err := resolveFederatedToken(options.FederatedToken)
+		if err != nil {
+			return err
+		}
+
+		if federatedToken != "" {
+			log.Printf("Authenticating to %s using federated credentials\n", registry)
+
+			return authenticateACRNative(
+				registry,
+				"https://"+registry,
+				azureAuthorityHost(),
+				tenantID,
+				options.ClientID,
+				federatedToken,
+			)
+		}
+	}
+
+	if err := AuthenticateAzure(tenantID, subscriptionID, options); err != nil {
+		return err
+	}
+
+	azAcrLoginCommandOutput := azAcrLoginCommand(
+		registryName,
+		nil,
+	)
+	if command.IsError(azAcrLoginCommandOutput) {
+		return fmt.Errorf(
+			"Failed to authenticate to Azure Container Registry: %w",
+			azAcrLoginCommandOutput.Error,
+		)
+	}
+
+	return nil
+}
+
+// resolveFederatedToken returns the federated token if given, or else requests an OIDC token from GitHub Actions
+// if running in a workflow with the id-token: write permission. Returns an empty string if neither is available.
+func resolveFederatedToken(federatedToken string) (string, error) {
+	if federatedToken != "" {
+		return federatedToken, nil
+	}
+
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	if requestURL == "" {
+		return "", nil
+	}
+
+	token, err := getGitHubActionsOIDCToken(
+		requestURL,
+		os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"),
+		azureFederatedTokenAudience,
+	)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get OIDC token for Azure: %w", err)
+	}
+
+	return token, nil
+}
+
+func acrStatus(registry string) ProviderStatus {
+	status := dockerRegistryStatus("ACR", registry)
+	if status.Identity == acrTokenUsername {
+		status.Identity = "Entra ID token"
+	}
+
+	return status
+}
+
+func azAcrLoginCommand(
+	registryName string,
+	options *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"az",
+			"acr",
+			"login",
+			"--name",
+			registryName,
+		),
+		options,
+	)
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestACRRegistryName(t *testing.T) {
+	registryName, err := acrRegistryName(ElviaContainerRegistry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if registryName != "containerregistryelvia" {
+		t.Errorf("Expected %s, got %s", "containerregistryelvia", registryName)
+	}
+
+	if _, err := acrRegistryName(""); err == nil {
+		t.Errorf("Expected error for empty registry, got nil")
+	}
+}
+
+func TestAzAcrLoginCommand(t *testing.T) {
+	expectedCommandString := "az acr login --name containerregistryelvia"
+
+	actualCommand := azAcrLoginCommand("containerregistryelvia", &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
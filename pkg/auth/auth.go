@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+	"github.com/3lvia/cli/pkg/utils"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+)
+
+const commandName = "auth"
+
+const (
+	providerAzure = "azure"
+	providerACR   = "acr"
+	providerGHCR  = "ghcr"
+	providerGKE   = "gke"
+	providerKube  = "kube"
+)
+
+var allProviders = []string{providerAzure, providerACR, providerGHCR, providerGKE, providerKube}
+
+// Providers logged in to when none are given, i.e. what is needed to push to Elvia's default registry.
+var defaultLoginProviders = []string{providerAzure, providerACR}
+
+var registryFlag = &cli.StringFlag{
+	Name:    "acr-registry",
+	Usage:   "The Azure Container Registry to use.",
+	Value:   ElviaContainerRegistry,
+	EnvVars: []string{"3LV_ACR_REGISTRY"},
+}
+
+var Command *cli.Command = &cli.Command{
+	Name:  commandName,
+	Usage: "Manage authentication with Azure, container registries, Google Cloud and Kubernetes",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "login",
+			Usage:     "Log in to the given providers: azure, acr, ghcr, gke or kube. Defaults to azure and acr.",
+			ArgsUsage: "[provider...]",
+			Flags: append(append([]cli.Flag{
+				registryFlag,
+				&cli.StringFlag{
+					Name:    "azure-tenant-id",
+					Usage:   "The tenant ID to use when authenticating with Azure.",
+					Value:   ElviaTenantID,
+					EnvVars: []string{"3LV_AZURE_TENANT_ID"},
+				},
+				&cli.StringFlag{
+					Name:    "azure-subscription-id",
+					Usage:   "The subscription ID to use when authenticating with Azure.",
+					Value:   ElviaDefaultRuntimeSubscriptionID,
+					EnvVars: []string{"3LV_AZURE_SUBSCRIPTION_ID"},
+				},
+				&cli.StringFlag{
+					Name:    "kube-context",
+					Usage:   "The kube context to switch to when logging in to kube.",
+					EnvVars: []string{"3LV_KUBE_CONTEXT"},
+				},
+			}, AzureFederatedFlags()...), GoogleAuthFlags()...),
+			Action: Login,
+		},
+		{
+			Name:      "status",
+			Usage:     "Show which providers are authenticated, as whom and until when. Defaults to all providers.",
+			ArgsUsage: "[provider...]",
+			Flags:     []cli.Flag{registryFlag},
+			Action:    Status,
+		},
+		{
+			Name:      "logout",
+			Usage:     "Log out of the given providers: azure, acr, ghcr, gke or kube.",
+			ArgsUsage: "provider...",
+			Flags:     []cli.Flag{registryFlag},
+			Action:    Logout,
+		},
+	},
+}
+
+func Login(c *cli.Context) error {
+	providers, err := parseProviders(c.Args().Slice(), defaultLoginProviders)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var errs []error
+	for _, provider := range providers {
+		if err := login(c, provider); err != nil {
+			errs = append(errs, fmt.Errorf("Failed to log in to %s: %w", provider, err))
+		}
+	}
+
+	fmt.Print(formatStatusTable(providerStatuses(providers, c.String("acr-registry")), time.Now()))
+
+	if len(errs) > 0 {
+		return cli.Exit(errors.Join(errs...), 1)
+	}
+
+	return nil
+}
+
+func login(c *cli.Context, provider string) error {
+	azLoginOptions := AzLoginOptionsFromContext(c)
+
+	switch provider {
+	case providerAzure:
+		return AuthenticateAzure(
+			c.String("azure-tenant-id"),
+			c.String("azure-subscription-id"),
+			azLoginOptions,
+		)
+
+	case providerACR:
+		return AuthenticateACR(
+			c.String("acr-registry"),
+			c.String("azure-tenant-id"),
+			c.String("azure-subscription-id"),
+			azLoginOptions,
+		)
+
+	case providerGHCR:
+		_, err := AuthenticateGHCR()
+		return err
+
+	case providerGKE:
+		return AuthenticateGoogle(GoogleAuthOptionsFromContext(c))
+
+	case providerKube:
+		kubeContext := c.String("kube-context")
+		if kubeContext == "" {
+			return fmt.Errorf("--kube-context is required, use `3lv deploy` to get cluster credentials")
+		}
+
+		kubectlUseContextOutput := kubectlUseContextCommand(kubeContext, nil)
+		if command.IsError(kubectlUseContextOutput) {
+			return kubectlUseContextOutput.Error
+		}
+	}
+
+	return nil
+}
+
+func Status(c *cli.Context) error {
+	providers, err := parseProviders(c.Args().Slice(), allProviders)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Print(formatStatusTable(providerStatuses(providers, c.String("acr-registry")), time.Now()))
+
+	return nil
+}
+
+func Logout(c *cli.Context) error {
+	if c.NArg() <= 0 {
+		return cli.ShowSubcommandHelp(c)
+	}
+
+	providers, err := parseProviders(c.Args().Slice(), nil)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var errs []error
+	for _, provider := range providers {
+		var output command.Output
+
+		switch provider {
+		case providerAzure:
+			output = azLogoutCommand(nil)
+		case providerACR:
+			output = dockerLogoutCommand(c.String("acr-registry"), nil)
+		case providerGHCR:
+			output = dockerLogoutCommand(GitHubContainerRegistry, nil)
+		case providerGKE:
+			output = gcloudAuthRevokeCommand(nil)
+		case providerKube:
+			output = kubectlUnsetCurrentContextCommand(nil)
+		}
+
+		if command.IsError(output) {
+			errs = append(errs, fmt.Errorf("Failed to log out of %s: %w", provider, output.Error))
+			continue
+		}
+
+		log.Printf("Logged out of %s\n", provider)
+	}
+
+	if len(errs) > 0 {
+		return cli.Exit(errors.Join(errs...), 1)
+	}
+
+	return nil
+}
+
+// parseProviders validates the providers given as arguments, returning the defaults if none are given.
+func parseProviders(args []string, defaultProviders []string) ([]string, error) {
+	providers := utils.RemoveZeroValues(args)
+	if len(providers) == 0 {
+		return defaultProviders, nil
+	}
+
+	for _, provider := range providers {
+		if !lo.Contains(allProviders, provider) {
+			return nil, fmt.Errorf("Unknown provider %s, must be one of azure, acr, ghcr, gke or kube", provider)
+		}
+	}
+
+	return providers, nil
+}
+
+func providerStatuses(providers []string, acrRegistry string) []ProviderStatus {
+	var statuses []ProviderStatus
+	for _, provider := range providers {
+		switch provider {
+		case providerAzure:
+			statuses = append(statuses, azureStatus())
+		case providerACR:
+			statuses = append(statuses, acrStatus(acrRegistry))
+		case providerGHCR:
+			statuses = append(statuses, ghcrStatus())
+		case providerGKE:
+			statuses = append(statuses, gkeStatus())
+		case providerKube:
+			statuses = append(statuses, kubeStatus())
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseProviders1(t *testing.T) {
+	providers, err := parseProviders(nil, defaultLoginProviders)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if !slices.Equal(providers, defaultLoginProviders) {
+		t.Errorf("Expected %v, got %v", defaultLoginProviders, providers)
+	}
+}
+
+func TestParseProviders2(t *testing.T) {
+	providers, err := parseProviders([]string{"ghcr", "kube"}, defaultLoginProviders)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := []string{"ghcr", "kube"}
+	if !slices.Equal(providers, expected) {
+		t.Errorf("Expected %v, got %v", expected, providers)
+	}
+}
+
+func TestParseProviders3(t *testing.T) {
+	if _, err := parseProviders([]string{"aws"}, nil); err == nil {
+		t.Errorf("Expected error for unknown provider, got nil")
+	}
+}
@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/3lvia/cli/pkg/command"
 )
@@ -35,8 +38,14 @@ func AuthenticateAzure(
 	subscriptionID string,
 	options *AzLoginCommandOptions,
 ) error {
-	azAccountShowCommandOutput := azAccountShowCommand(nil)
-	if command.IsError(azAccountShowCommandOutput) {
+	account, err := getAzureAccount(nil)
+	if err != nil {
+		log.Printf("Could not get current Azure account: %v\n", err)
+	}
+
+	if reason := azureLoginReason(account, tenantID, time.Now()); reason != "" {
+		log.Printf("Logging in to Azure tenant %s: %s\n", tenantID, reason)
+
 		azLoginCommandOutput := azLoginCommand(
 			tenantID,
 			options,
@@ -44,20 +53,13 @@ func AuthenticateAzure(
 		if command.IsError(azLoginCommandOutput) {
 			return fmt.Errorf("Failed to authenticate to Azure: %w", azLoginCommandOutput.Error)
 		}
-		return nil
+
+		// Logging in changes the active subscription, so it must always be set afterwards.
+		account = nil
 	}
 
-	if tenantID := azAccountShowCommandOutput.Output; tenantID != "" {
-		azAccountShowCmdOutputString := strings.TrimSpace(string(tenantID))
-		if azAccountShowCmdOutputString != tenantID {
-			azLoginTenantCommandOutput := azLoginCommand(
-				tenantID,
-				options,
-			)
-			if command.IsError(azLoginTenantCommandOutput) {
-				return fmt.Errorf("Failed to authenticate to Azure: %w", azLoginTenantCommandOutput.Error)
-			}
-		}
+	if account != nil && account.SubscriptionID == subscriptionID {
+		return nil
 	}
 
 	azSetSubscriptionCommandOutput := azSetSubscriptionCommand(
@@ -71,6 +73,109 @@ func AuthenticateAzure(
 	return nil
 }
 
+type AzureAccount struct {
+	TenantID       string
+	SubscriptionID string
+	UserName       string
+	// Either user or servicePrincipal.
+	UserType string
+	// Zero if the expiry of the access token is unknown.
+	TokenExpiresOn time.Time
+}
+
+// getAzureAccount returns the account the Azure CLI is logged in with, or nil if not logged in.
+func getAzureAccount(runOptions *command.RunOptions) (*AzureAccount, error) {
+	azAccountShowCommandOutput := azAccountShowCommand(runOptions)
+	if command.IsError(azAccountShowCommandOutput) {
+		return nil, nil
+	}
+
+	account, err := parseAzureAccount(azAccountShowCommandOutput.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	azGetAccessTokenCommandOutput := azGetAccessTokenCommand(runOptions)
+	if command.IsError(azGetAccessTokenCommandOutput) {
+		// The Azure CLI refreshes the access token if it can, so failing to get one means the login has expired.
+		account.TokenExpiresOn = time.Unix(0, 0)
+		return account, nil
+	}
+
+	expiresOn, err := parseAzureAccessTokenExpiry(azGetAccessTokenCommandOutput.Output)
+	if err != nil {
+		return account, err
+	}
+	account.TokenExpiresOn = expiresOn
+
+	return account, nil
+}
+
+func parseAzureAccount(accountJSON string) (*AzureAccount, error) {
+	var azAccount struct {
+		ID       string `json:"id"`
+		TenantID string `json:"tenantId"`
+		User     struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal([]byte(accountJSON), &azAccount); err != nil {
+		return nil, fmt.Errorf("Failed to parse Azure account: %w", err)
+	}
+
+	return &AzureAccount{
+		TenantID:       azAccount.TenantID,
+		SubscriptionID: azAccount.ID,
+		UserName:       azAccount.User.Name,
+		UserType:       azAccount.User.Type,
+	}, nil
+}
+
+// parseAzureAccessTokenExpiry returns the expiry of the access token from `az account get-access-token`.
+// Newer versions of the Azure CLI include expires_on as a Unix timestamp, older versions only expiresOn in local time.
+func parseAzureAccessTokenExpiry(accessTokenJSON string) (time.Time, error) {
+	var azAccessToken struct {
+		ExpiresOn      string `json:"expiresOn"`
+		ExpiresOnEpoch int64  `json:"expires_on"`
+	}
+	if err := json.Unmarshal([]byte(accessTokenJSON), &azAccessToken); err != nil {
+		return time.Time{}, fmt.Errorf("Failed to parse Azure access token: %w", err)
+	}
+
+	if azAccessToken.ExpiresOnEpoch != 0 {
+		return time.Unix(azAccessToken.ExpiresOnEpoch, 0), nil
+	}
+
+	if azAccessToken.ExpiresOn == "" {
+		return time.Time{}, nil
+	}
+
+	expiresOn, err := time.ParseInLocation("2006-01-02 15:04:05.999999", azAccessToken.ExpiresOn, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to parse Azure access token expiry: %w", err)
+	}
+
+	return expiresOn, nil
+}
+
+// azureLoginReason returns why logging in to the tenant is required, or an empty string if the account can be used as is.
+func azureLoginReason(account *AzureAccount, tenantID string, now time.Time) string {
+	if account == nil {
+		return "not logged in"
+	}
+
+	if !strings.EqualFold(account.TenantID, tenantID) {
+		return fmt.Sprintf("logged in to tenant %s", account.TenantID)
+	}
+
+	if !account.TokenExpiresOn.IsZero() && !now.Before(account.TokenExpiresOn) {
+		return fmt.Sprintf("access token for %s %s has expired", account.UserType, account.UserName)
+	}
+
+	return ""
+}
+
 func azSetSubscriptionCommand(
 	subscriptionID string,
 	runOptions *command.RunOptions,
@@ -93,10 +198,24 @@ func azAccountShowCommand(runOptions *command.RunOptions) command.Output {
 			"az",
 			"account",
 			"show",
+			"--output",
+			"json",
+		),
+		runOptions,
+	)
+}
+
+// azGetAccessTokenCommand only queries the expiry of the access token, so that the token itself is not logged.
+func azGetAccessTokenCommand(runOptions *command.RunOptions) command.Output {
+	return command.Run(
+		*exec.Command(
+			"az",
+			"account",
+			"get-access-token",
 			"--query",
-			"tenantId",
+			"{expiresOn: expiresOn, expires_on: expires_on}",
 			"--output",
-			"tsv",
+			"json",
 		),
 		runOptions,
 	)
@@ -154,3 +273,35 @@ func azLoginCommand(
 
 	return result
 }
+
+func azureStatus() ProviderStatus {
+	status := ProviderStatus{Name: "Azure"}
+
+	account, err := getAzureAccount(&command.RunOptions{Quiet: true})
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	if account == nil {
+		status.Error = fmt.Errorf("Not logged in")
+		return status
+	}
+
+	status.Authenticated = true
+	status.Identity = fmt.Sprintf("%s (%s)", account.UserName, account.UserType)
+	status.Details = fmt.Sprintf("tenant %s, subscription %s", account.TenantID, account.SubscriptionID)
+	status.ExpiresOn = account.TokenExpiresOn
+
+	return status
+}
+
+func azLogoutCommand(runOptions *command.RunOptions) command.Output {
+	return command.Run(
+		*exec.Command(
+			"az",
+			"logout",
+		),
+		runOptions,
+	)
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/3lvia/cli/pkg/command"
 )
@@ -76,10 +77,8 @@ func TestAzAccountShowCommand(t *testing.T) {
 			"az",
 			"account",
 			"show",
-			"--query",
-			"tenantId",
 			"--output",
-			"tsv",
+			"json",
 		},
 		" ",
 	)
@@ -92,3 +91,129 @@ func TestAzAccountShowCommand(t *testing.T) {
 		actualCommand,
 	)
 }
+
+func TestAzGetAccessTokenCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"az",
+			"account",
+			"get-access-token",
+			"--query",
+			"{expiresOn: expiresOn, expires_on: expires_on}",
+			"--output",
+			"json",
+		},
+		" ",
+	)
+
+	actualCommand := azGetAccessTokenCommand(&command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestParseAzureAccount(t *testing.T) {
+	const accountJSON = `{
+  "environmentName": "AzureCloud",
+  "id": "` + ElviaDefaultRuntimeSubscriptionID + `",
+  "isDefault": true,
+  "name": "Elvia Runtime",
+  "state": "Enabled",
+  "tenantId": "` + ElviaTenantID + `",
+  "user": {
+    "name": "00000000-0000-0000-0000-000000000000",
+    "type": "servicePrincipal"
+  }
+}`
+
+	account, err := parseAzureAccount(accountJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := AzureAccount{
+		TenantID:       ElviaTenantID,
+		SubscriptionID: ElviaDefaultRuntimeSubscriptionID,
+		UserName:       "00000000-0000-0000-0000-000000000000",
+		UserType:       "servicePrincipal",
+	}
+	if *account != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *account)
+	}
+}
+
+func TestParseAzureAccessTokenExpiry1(t *testing.T) {
+	expiresOn, err := parseAzureAccessTokenExpiry(`{"expiresOn": "2024-07-01 14:00:00.000000", "expires_on": 1719835200}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if expiresOn.Unix() != 1719835200 {
+		t.Errorf("Expected %d, got %d", 1719835200, expiresOn.Unix())
+	}
+}
+
+func TestParseAzureAccessTokenExpiry2(t *testing.T) {
+	expiresOn, err := parseAzureAccessTokenExpiry(`{"expiresOn": "2024-07-01 14:00:00.123456"}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := time.Date(2024, 7, 1, 14, 0, 0, 123456000, time.Local)
+	if !expiresOn.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, expiresOn)
+	}
+}
+
+func TestAzureLoginReason1(t *testing.T) {
+	if reason := azureLoginReason(nil, ElviaTenantID, time.Now()); reason != "not logged in" {
+		t.Errorf("Expected %s, got %s", "not logged in", reason)
+	}
+}
+
+func TestAzureLoginReason2(t *testing.T) {
+	account := &AzureAccount{TenantID: "other-tenant-id"}
+
+	reason := azureLoginReason(account, ElviaTenantID, time.Now())
+
+	const expected = "logged in to tenant other-tenant-id"
+	if reason != expected {
+		t.Errorf("Expected %s, got %s", expected, reason)
+	}
+}
+
+func TestAzureLoginReason3(t *testing.T) {
+	now := time.Now()
+	account := &AzureAccount{
+		TenantID:       ElviaTenantID,
+		UserName:       "user@elvia.no",
+		UserType:       "user",
+		TokenExpiresOn: now.Add(-time.Minute),
+	}
+
+	reason := azureLoginReason(account, ElviaTenantID, now)
+
+	const expected = "access token for user user@elvia.no has expired"
+	if reason != expected {
+		t.Errorf("Expected %s, got %s", expected, reason)
+	}
+}
+
+func TestAzureLoginReason4(t *testing.T) {
+	now := time.Now()
+
+	accounts := []*AzureAccount{
+		{TenantID: ElviaTenantID, TokenExpiresOn: now.Add(time.Hour)},
+		{TenantID: strings.ToUpper(ElviaTenantID), TokenExpiresOn: now.Add(time.Hour)},
+		{TenantID: ElviaTenantID},
+	}
+
+	for _, account := range accounts {
+		if reason := azureLoginReason(account, ElviaTenantID, now); reason != "" {
+			t.Errorf("Expected no login for %+v, got %s", account, reason)
+		}
+	}
+}
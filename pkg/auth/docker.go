@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+type DockerCredentials struct {
+	Username string
+	Secret   string
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// dockerConfigFile returns the path to the Docker client configuration, respecting DOCKER_CONFIG.
+func dockerConfigFile() (string, error) {
+	if dockerConfigDirectory := os.Getenv("DOCKER_CONFIG"); dockerConfigDirectory != "" {
+		return filepath.Join(dockerConfigDirectory, "config.json"), nil
+	}
+
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDirectory, ".docker", "config.json"), nil
+}
+
+func readDockerConfig() (dockerConfig, error) {
+	var config dockerConfig
+
+	configFile, err := dockerConfigFile()
+	if err != nil {
+		return config, err
+	}
+
+	content, err := os.ReadFile(configFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("Failed to read Docker config: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("Failed to parse Docker config %s: %w", configFile, err)
+	}
+
+	return config, nil
+}
+
+// getDockerCredentials returns the credentials Docker uses for the registry, or nil if Docker is not logged in to it.
+// Credentials are read from the Docker config, or from the configured credential helper.
+func getDockerCredentials(registry string) (*DockerCredentials, error) {
+	config, err := readDockerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, serverAddress := range []string{registry, "https://" + registry} {
+		if entry, ok := config.Auths[serverAddress]; ok && entry.Auth != "" {
+			return decodeDockerAuth(entry.Auth)
+		}
+	}
+
+	credentialHelper := config.CredHelpers[registry]
+	if credentialHelper == "" {
+		credentialHelper = config.CredsStore
+	}
+	if credentialHelper == "" {
+		return nil, nil
+	}
+
+	return getCredentialHelperCredentials(credentialHelper, registry)
+}
+
+func decodeDockerAuth(auth string) (*DockerCredentials, error) {
+	decoded, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode Docker credentials: %w", err)
+	}
+
+	username, secret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return nil, fmt.Errorf("Invalid Docker credentials, expected username:password")
+	}
+
+	return &DockerCredentials{Username: username, Secret: secret}, nil
+}
+
+// getCredentialHelperCredentials runs the Docker credential helper directly instead of using command.Run,
+// since the output contains the secret.
+func getCredentialHelperCredentials(credentialHelper string, registry string) (*DockerCredentials, error) {
+	cmd := exec.Command("docker-credential-"+credentialHelper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+
+	output, err := cmd.Output()
+	if err != nil {
+		// Credential helpers exit with an error when they have no credentials for the registry.
+		return nil, nil
+	}
+
+	var credentials struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(output, &credentials); err != nil {
+		return nil, fmt.Errorf("Failed to parse output of docker-credential-%s: %w", credentialHelper, err)
+	}
+
+	if credentials.Secret == "" {
+		return nil, nil
+	}
+
+	return &DockerCredentials{Username: credentials.Username, Secret: credentials.Secret}, nil
+}
+
+// storeDockerCredentials stores the credentials for the registry like `docker login` would,
+// using the configured credential helper or else the Docker config.
+func storeDockerCredentials(registry string, credentials DockerCredentials) error {
+	config, err := readDockerConfig()
+	if err != nil {
+		return err
+	}
+
+	credentialHelper := config.CredHelpers[registry]
+	if credentialHelper == "" {
+		credentialHelper = config.CredsStore
+	}
+	if credentialHelper != "" {
+		return storeCredentialHelperCredentials(credentialHelper, registry, credentials)
+	}
+
+	configFile, err := dockerConfigFile()
+	if err != nil {
+		return err
+	}
+
+	// Keep the fields of the Docker config not known to this CLI.
+	rawConfig := map[string]json.RawMessage{}
+	content, err := os.ReadFile(configFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Failed to read Docker config: %w", err)
+	}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &rawConfig); err != nil {
+			return fmt.Errorf("Failed to parse Docker config %s: %w", configFile, err)
+		}
+	}
+
+	auths := map[string]json.RawMessage{}
+	if rawAuths, ok := rawConfig["auths"]; ok {
+		if err := json.Unmarshal(rawAuths, &auths); err != nil {
+			return fmt.Errorf("Failed to parse Docker config %s: %w", configFile, err)
+		}
+	}
+
+	auth, err := json.Marshal(
+		map[string]string{
+			"auth": base64.StdEncoding.EncodeToString([]byte(credentials.Username + ":" + credentials.Secret)),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	auths[registry] = auth
+
+	if rawConfig["auths"], err = json.Marshal(auths); err != nil {
+		return err
+	}
+
+	newContent, err := json.MarshalIndent(rawConfig, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0700); err != nil {
+		return err
+	}
+
+	temporaryFile := configFile + ".tmp"
+	if err := os.WriteFile(temporaryFile, newContent, 0600); err != nil {
+		return fmt.Errorf("Failed to write Docker config: %w", err)
+	}
+
+	return os.Rename(temporaryFile, configFile)
+}
+
+// storeCredentialHelperCredentials runs the Docker credential helper directly instead of using command.Run,
+// since the input contains the secret.
+func storeCredentialHelperCredentials(credentialHelper string, registry string, credentials DockerCredentials) error {
+	input, err := json.Marshal(
+		map[string]string{
+			"ServerURL": registry,
+			"Username":  credentials.Username,
+			"Secret":    credentials.Secret,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("docker-credential-"+credentialHelper, "store")
+	cmd.Stdin = strings.NewReader(string(input))
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("Failed to store credentials using docker-credential-%s: %w: %s", credentialHelper, err, output)
+	}
+
+	return nil
+}
+
+// jwtExpiry returns the expiry of the token if it is a JWT, or the zero time if not.
+// The signature is not verified, the expiry is only used for display.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Expiry int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Expiry == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Expiry, 0)
+}
+
+// dockerRegistryStatus returns the status of the Docker login to the registry.
+func dockerRegistryStatus(name string, registry string) ProviderStatus {
+	status := ProviderStatus{Name: name, Details: registry}
+
+	credentials, err := getDockerCredentials(registry)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	if credentials == nil {
+		status.Error = fmt.Errorf("Docker is not logged in")
+		return status
+	}
+
+	status.Authenticated = true
+	status.Identity = credentials.Username
+	status.ExpiresOn = jwtExpiry(credentials.Secret)
+
+	return status
+}
+
+func dockerLogoutCommand(
+	registry string,
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"docker",
+			"logout",
+			registry,
+		),
+		runOptions,
+	)
+}
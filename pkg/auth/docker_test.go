@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func testJWT(claims string) string {
+	return strings.Join(
+		[]string{
+			base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)),
+			base64.RawURLEncoding.EncodeToString([]byte(claims)),
+			"signature",
+		},
+		".",
+	)
+}
+
+func writeTestDockerConfig(t *testing.T, content string) {
+	dockerConfigDirectory := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dockerConfigDirectory)
+
+	if err := os.WriteFile(filepath.Join(dockerConfigDirectory, "config.json"), []byte(content), 0600); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+}
+
+func TestGetDockerCredentials1(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("octocat:ghp_token"))
+	writeTestDockerConfig(t, `{"auths": {"https://ghcr.io": {"auth": "`+auth+`"}}}`)
+
+	credentials, err := getDockerCredentials("ghcr.io")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if credentials == nil || credentials.Username != "octocat" || credentials.Secret != "ghp_token" {
+		t.Errorf("Expected credentials for octocat, got %+v", credentials)
+	}
+}
+
+func TestGetDockerCredentials2(t *testing.T) {
+	writeTestDockerConfig(t, `{"auths": {"ghcr.io": {}}}`)
+
+	credentials, err := getDockerCredentials(ElviaContainerRegistry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if credentials != nil {
+		t.Errorf("Expected no credentials, got %+v", credentials)
+	}
+}
+
+func TestGetDockerCredentials3(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	credentials, err := getDockerCredentials("ghcr.io")
+	if err != nil {
+		t.Fatalf("Expected no error without Docker config, got %s", err)
+	}
+
+	if credentials != nil {
+		t.Errorf("Expected no credentials, got %+v", credentials)
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	expected := time.Unix(1719835200, 0)
+
+	if actual := jwtExpiry(testJWT(`{"exp":1719835200}`)); !actual.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+
+	if actual := jwtExpiry("ghp_token"); !actual.IsZero() {
+		t.Errorf("Expected zero time for token which is not a JWT, got %s", actual)
+	}
+}
+
+func TestACRStatus(t *testing.T) {
+	token := testJWT(`{"exp":1719835200}`)
+	auth := base64.StdEncoding.EncodeToString([]byte(acrTokenUsername + ":" + token))
+	writeTestDockerConfig(t, `{"auths": {"`+ElviaContainerRegistry+`": {"auth": "`+auth+`"}}}`)
+
+	status := acrStatus(ElviaContainerRegistry)
+
+	if !status.Authenticated {
+		t.Fatalf("Expected ACR to be authenticated, got %s", status.Error)
+	}
+
+	if status.Identity != "Entra ID token" {
+		t.Errorf("Expected %s, got %s", "Entra ID token", status.Identity)
+	}
+
+	if status.ExpiresOn.Unix() != 1719835200 {
+		t.Errorf("Expected %d, got %d", 1719835200, status.ExpiresOn.Unix())
+	}
+}
+
+func TestDockerLogoutCommand(t *testing.T) {
+	expectedCommandString := "docker logout ghcr.io"
+
+	actualCommand := dockerLogoutCommand("ghcr.io", &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
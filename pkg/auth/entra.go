@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com"
+	azureManagementScope      = "https://management.azure.com/.default"
+	// Audience of GitHub Actions OIDC tokens trusted by Entra ID federated credentials.
+	azureFederatedTokenAudience = "api://AzureADTokenExchange"
+)
+
+const entraRequestTimeout = 30 * time.Second
+
+// azureAuthorityHost returns the Entra ID authority, respecting AZURE_AUTHORITY_HOST like the Azure SDKs.
+func azureAuthorityHost() string {
+	if authorityHost := os.Getenv("AZURE_AUTHORITY_HOST"); authorityHost != "" {
+		return strings.TrimSuffix(authorityHost, "/")
+	}
+
+	return defaultAzureAuthorityHost
+}
+
+type entraAccessToken struct {
+	AccessToken string
+	ExpiresOn   time.Time
+}
+
+// getEntraAccessToken exchanges a federated token, e.g. a GitHub Actions OIDC token,
+// for an Entra ID access token using the client credentials flow.
+func getEntraAccessToken(
+	authorityHost string,
+	tenantID string,
+	clientID string,
+	federatedToken string,
+	scope string,
+) (entraAccessToken, error) {
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {clientID},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {federatedToken},
+		"scope":                 {scope},
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", authorityHost, url.PathEscape(tenantID))
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := postForm(tokenURL, form, &tokenResponse); err != nil {
+		return entraAccessToken{}, fmt.Errorf("Failed to get Entra ID access token: %w", err)
+	}
+
+	if tokenResponse.AccessToken == "" {
+		return entraAccessToken{}, fmt.Errorf("Entra ID token response did not contain an access token")
+	}
+
+	return entraAccessToken{
+		AccessToken: tokenResponse.AccessToken,
+		ExpiresOn:   time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// exchangeACRRefreshToken exchanges an Entra ID access token for an ACR refresh token,
+// which Docker can use as password, like `az acr login` does.
+func exchangeACRRefreshToken(
+	registryURL string,
+	registry string,
+	tenantID string,
+	accessToken string,
+) (string, error) {
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"tenant":       {tenantID},
+		"access_token": {accessToken},
+	}
+
+	var exchangeResponse struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := postForm(registryURL+"/oauth2/exchange", form, &exchangeResponse); err != nil {
+		return "", fmt.Errorf("Failed to exchange Entra ID token for %s: %w", registry, err)
+	}
+
+	if exchangeResponse.RefreshToken == "" {
+		return "", fmt.Errorf("ACR token exchange response did not contain a refresh token")
+	}
+
+	return exchangeResponse.RefreshToken, nil
+}
+
+func postForm(requestURL string, form url.Values, response any) error {
+	client := &http.Client{Timeout: entraRequestTimeout}
+
+	httpResponse, err := client.PostForm(requestURL, form)
+	if err != nil {
+		return err
+	}
+
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		var errorResponse struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		json.NewDecoder(httpResponse.Body).Decode(&errorResponse)
+
+		if errorResponse.Error != "" {
+			return fmt.Errorf("Unexpected status code %d: %s %s", httpResponse.StatusCode, errorResponse.Error, errorResponse.ErrorDescription)
+		}
+
+		return fmt.Errorf("Unexpected status code %d", httpResponse.StatusCode)
+	}
+
+	if err := json.NewDecoder(httpResponse.Body).Decode(response); err != nil {
+		return fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// authenticateACRNative logs Docker in to the registry without the Azure CLI,
+// by exchanging the federated token for an Entra ID access token and then an ACR refresh token.
+func authenticateACRNative(
+	registry string,
+	registryURL string,
+	authorityHost string,
+	tenantID string,
+	clientID string,
+	federatedToken string,
+) error {
+	accessToken, err := getEntraAccessToken(
+		authorityHost,
+		tenantID,
+		clientID,
+		federatedToken,
+		azureManagementScope,
+	)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := exchangeACRRefreshToken(registryURL, registry, tenantID, accessToken.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	return storeDockerCredentials(
+		registry,
+		DockerCredentials{Username: acrTokenUsername, Secret: refreshToken},
+	)
+}
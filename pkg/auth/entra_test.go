@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testClientID       = "test-client-id"
+	testFederatedToken = "test-federated-token"
+)
+
+// newTestEntraServer returns a stand-in for the Entra ID token endpoint and the ACR token exchange endpoint.
+func newTestEntraServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+
+		switch r.URL.Path {
+		case "/" + ElviaTenantID + "/oauth2/v2.0/token":
+			if r.PostForm.Get("client_id") != testClientID || r.PostForm.Get("client_assertion") != testFederatedToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "invalid_client", "error_description": "AADSTS700016: Application not found."}`))
+				return
+			}
+
+			if r.PostForm.Get("scope") != azureManagementScope {
+				t.Errorf("Expected scope %s, got %s", azureManagementScope, r.PostForm.Get("scope"))
+			}
+
+			w.Write([]byte(`{"token_type": "Bearer", "expires_in": 3599, "access_token": "test-access-token"}`))
+
+		case "/oauth2/exchange":
+			if r.PostForm.Get("grant_type") != "access_token" || r.PostForm.Get("access_token") != "test-access-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if r.PostForm.Get("service") != ElviaContainerRegistry || r.PostForm.Get("tenant") != ElviaTenantID {
+				t.Errorf("Expected service %s and tenant %s, got %v", ElviaContainerRegistry, ElviaTenantID, r.PostForm)
+			}
+
+			w.Write([]byte(`{"refresh_token": "test-refresh-token"}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetEntraAccessToken1(t *testing.T) {
+	server := newTestEntraServer(t)
+	defer server.Close()
+
+	accessToken, err := getEntraAccessToken(server.URL, ElviaTenantID, testClientID, testFederatedToken, azureManagementScope)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if accessToken.AccessToken != "test-access-token" {
+		t.Errorf("Expected %s, got %s", "test-access-token", accessToken.AccessToken)
+	}
+
+	if accessToken.ExpiresOn.IsZero() {
+		t.Errorf("Expected expiry to be set")
+	}
+}
+
+func TestGetEntraAccessToken2(t *testing.T) {
+	server := newTestEntraServer(t)
+	defer server.Close()
+
+	_, err := getEntraAccessToken(server.URL, ElviaTenantID, "unknown-client-id", testFederatedToken, azureManagementScope)
+	if err == nil {
+		t.Fatalf("Expected error for unknown client, got nil")
+	}
+
+	const expected = "Failed to get Entra ID access token: Unexpected status code 401: invalid_client AADSTS700016: Application not found."
+	if err.Error() != expected {
+		t.Errorf("Expected %s, got %s", expected, err)
+	}
+}
+
+func TestAuthenticateACRNative1(t *testing.T) {
+	server := newTestEntraServer(t)
+	defer server.Close()
+
+	writeTestDockerConfig(t, `{"auths": {"ghcr.io": {"auth": "b2N0b2NhdDpnaHBfdG9rZW4="}}, "currentContext": "default"}`)
+
+	err := authenticateACRNative(
+		ElviaContainerRegistry,
+		server.URL,
+		server.URL,
+		ElviaTenantID,
+		testClientID,
+		testFederatedToken,
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	credentials, err := getDockerCredentials(ElviaContainerRegistry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := DockerCredentials{Username: acrTokenUsername, Secret: "test-refresh-token"}
+	if credentials == nil || *credentials != expected {
+		t.Errorf("Expected %+v, got %+v", expected, credentials)
+	}
+
+	if ghcrCredentials, err := getDockerCredentials("ghcr.io"); err != nil || ghcrCredentials == nil {
+		t.Errorf("Expected existing credentials to be kept, got %+v, %v", ghcrCredentials, err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	var config map[string]any
+	if err := json.Unmarshal(content, &config); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if config["currentContext"] != "default" {
+		t.Errorf("Expected unknown fields to be kept, got %s", content)
+	}
+}
+
+func TestAuthenticateACRNative2(t *testing.T) {
+	server := newTestEntraServer(t)
+	defer server.Close()
+
+	t.Setenv("DOCKER_CONFIG", filepath.Join(t.TempDir(), "docker"))
+
+	err := authenticateACRNative(
+		ElviaContainerRegistry,
+		server.URL,
+		server.URL,
+		ElviaTenantID,
+		testClientID,
+		"invalid-federated-token",
+	)
+	if err == nil {
+		t.Fatalf("Expected error for invalid federated token, got nil")
+	}
+
+	if _, err := os.Stat(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json")); err == nil {
+		t.Errorf("Expected no Docker config to be written")
+	}
+}
+
+func TestResolveFederatedToken1(t *testing.T) {
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+
+	token, err := resolveFederatedToken(testFederatedToken)
+	if err != nil || token != testFederatedToken {
+		t.Errorf("Expected %s, got %s, %v", testFederatedToken, token, err)
+	}
+
+	token, err = resolveFederatedToken("")
+	if err != nil || token != "" {
+		t.Errorf("Expected no token outside GitHub Actions, got %s, %v", token, err)
+	}
+}
+
+func TestResolveFederatedToken2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("audience") != azureFederatedTokenAudience {
+			t.Errorf("Expected audience %s, got %s", azureFederatedTokenAudience, r.URL.Query().Get("audience"))
+		}
+
+		w.Write([]byte(`{"value": "test-github-oidc-token"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL)
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "test-request-token")
+
+	token, err := resolveFederatedToken("")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if token != "test-github-oidc-token" {
+		t.Errorf("Expected %s, got %s", "test-github-oidc-token", token)
+	}
+}
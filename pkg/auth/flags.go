@@ -0,0 +1,108 @@
+package auth
+
+import "github.com/urfave/cli/v2"
+
+// AzureFederatedFlags returns the flags authenticating with Azure using a federated token instead of the Azure CLI,
+// shared by the commands authenticating with Azure.
+func AzureFederatedFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "azure-client-id",
+			Usage:   "The client ID to use when authenticating with Azure using a federated token, from --azure-federated-token or GitHub Actions. Authenticates without the Azure CLI.",
+			Hidden:  true,
+			EnvVars: []string{"3LV_AZURE_CLIENT_ID"},
+		},
+		&cli.StringFlag{
+			Name:    "azure-federated-token",
+			Usage:   "The federated token to use when authenticating with Azure. Must be combined with --azure-client-id.",
+			Hidden:  true,
+			EnvVars: []string{"3LV_AZURE_FEDERATED_TOKEN"},
+		},
+	}
+}
+
+// AzLoginOptionsFromContext reads the flags added by AzureFederatedFlags.
+func AzLoginOptionsFromContext(c *cli.Context) *AzLoginCommandOptions {
+	return &AzLoginCommandOptions{
+		ClientID:       c.String("azure-client-id"),
+		FederatedToken: c.String("azure-federated-token"),
+	}
+}
+
+// GoogleAuthFlags returns the flags authenticating with Google Cloud, shared by the commands authenticating with Google Cloud.
+func GoogleAuthFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "gcp-credentials-file",
+			Usage:   "Path to a Google Cloud service account key file or workload identity federation credential configuration file. Defaults to GOOGLE_APPLICATION_CREDENTIALS.",
+			Hidden:  true,
+			EnvVars: []string{"3LV_GCP_CREDENTIALS_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "gcp-workload-identity-provider",
+			Usage:   "The full resource name of the Google Cloud workload identity provider to authenticate with using the GitHub Actions OIDC token.",
+			Hidden:  true,
+			EnvVars: []string{"3LV_GCP_WORKLOAD_IDENTITY_PROVIDER"},
+		},
+		&cli.StringFlag{
+			Name:    "gcp-service-account",
+			Usage:   "The Google Cloud service account to impersonate when using workload identity federation.",
+			Hidden:  true,
+			EnvVars: []string{"3LV_GCP_SERVICE_ACCOUNT"},
+		},
+	}
+}
+
+// GoogleAuthOptionsFromContext reads the flags added by GoogleAuthFlags.
+func GoogleAuthOptionsFromContext(c *cli.Context) *GoogleAuthOptions {
+	return &GoogleAuthOptions{
+		CredentialsFile:          c.String("gcp-credentials-file"),
+		WorkloadIdentityProvider: c.String("gcp-workload-identity-provider"),
+		ServiceAccount:           c.String("gcp-service-account"),
+	}
+}
+
+// RegistryAuthOptions configures authentication with the Azure and Google Cloud registries.
+type RegistryAuthOptions struct {
+	// Defaults to the Elvia tenant when empty.
+	AzureTenantID string
+	// Defaults to the Elvia default runtime subscription when empty.
+	AzureSubscriptionID string
+	AzLoginOptions      *AzLoginCommandOptions
+	GoogleAuthOptions   *GoogleAuthOptions
+}
+
+// RegistryAuthFlags returns the flags authenticating with the Azure and Google Cloud registries,
+// shared by the commands pushing and pulling images.
+func RegistryAuthFlags() []cli.Flag {
+	return append(
+		append(
+			[]cli.Flag{
+				&cli.StringFlag{
+					Name:    "azure-tenant-id",
+					Usage:   "The tenant ID to use when authenticating with the Azure Container Registry.",
+					Hidden:  true,
+					EnvVars: []string{"3LV_AZURE_TENANT_ID"},
+				},
+				&cli.StringFlag{
+					Name:    "azure-subscription-id",
+					Usage:   "The subscription ID to use when authenticating with the Azure Container Registry.",
+					Hidden:  true,
+					EnvVars: []string{"3LV_AZURE_SUBSCRIPTION_ID"},
+				},
+			},
+			AzureFederatedFlags()...,
+		),
+		GoogleAuthFlags()...,
+	)
+}
+
+// RegistryAuthOptionsFromContext reads the flags added by RegistryAuthFlags.
+func RegistryAuthOptionsFromContext(c *cli.Context) RegistryAuthOptions {
+	return RegistryAuthOptions{
+		AzureTenantID:       c.String("azure-tenant-id"),
+		AzureSubscriptionID: c.String("azure-subscription-id"),
+		AzLoginOptions:      AzLoginOptionsFromContext(c),
+		GoogleAuthOptions:   GoogleAuthOptionsFromContext(c),
+	}
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/3lvia/cli/pkg/registry"
+)
+
+const GitHubContainerRegistry = "ghcr.io"
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+const registryRequestTimeout = 30 * time.Second
+
+func IsGitHubContainerRegistry(registry string) bool {
+	return registryHost(registry) == GitHubContainerRegistry
+}
+
+// AuthenticateGHCR logs Docker in to GitHub Container Registry, using GITHUB_TOKEN, GH_TOKEN or the token of the GitHub CLI.
+func AuthenticateGHCR() (DockerCredentials, error) {
+	token, err := resolveGitHubToken()
+	if err != nil {
+		return DockerCredentials{}, err
+	}
+
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	username, err := resolveGitHubUsername(strings.TrimSuffix(apiURL, "/"), token)
+	if err != nil {
+		return DockerCredentials{}, err
+	}
+
+	log.Printf("Authenticating to %s as %s\n", GitHubContainerRegistry, username)
+
+	credentials := DockerCredentials{Username: username, Secret: token}
+	if err := storeDockerCredentials(GitHubContainerRegistry, credentials); err != nil {
+		return DockerCredentials{}, fmt.Errorf("Failed to authenticate to GitHub Container Registry: %w", err)
+	}
+
+	return credentials, nil
+}
+
+func resolveGitHubToken() (string, error) {
+	for _, variable := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(variable); token != "" {
+			return token, nil
+		}
+	}
+
+	// The token is not read using command.Run, since it logs the output.
+	token, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return "", fmt.Errorf(
+			"No GitHub token found, please set GITHUB_TOKEN or login using the command `gh auth login` first: %w",
+			err,
+		)
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
+
+// resolveGitHubUsername returns the user owning the token. The GITHUB_TOKEN of GitHub Actions
+// does not belong to a user, so GITHUB_ACTOR is used when set.
+func resolveGitHubUsername(apiURL string, token string) (string, error) {
+	if actor := os.Getenv("GITHUB_ACTOR"); actor != "" {
+		return actor, nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, apiURL+"/user", nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/vnd.github+json")
+
+	client := &http.Client{Timeout: registryRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get GitHub user: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to get GitHub user, unexpected status code %d. Is the GitHub token valid?", response.StatusCode)
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&user); err != nil {
+		return "", fmt.Errorf("Failed to parse GitHub user: %w", err)
+	}
+
+	return user.Login, nil
+}
+
+// CheckGHCRPushPermission checks that the credentials are allowed to push the image to GitHub Container Registry,
+// so that missing permissions are reported before the image is built.
+func CheckGHCRPushPermission(imageName string, credentials DockerCredentials) error {
+	host, repository, found := strings.Cut(imageName, "/")
+	if !found || host != GitHubContainerRegistry {
+		return fmt.Errorf("Invalid GitHub Container Registry image name: %s", imageName)
+	}
+
+	client := registry.NewClient(
+		registry.StaticCredentials(registry.Credentials{Username: credentials.Username, Secret: credentials.Secret}),
+	)
+
+	err := client.CheckPushPermission(registry.Reference{Host: host, Repository: repository})
+	if err != nil {
+		return fmt.Errorf(
+			"Not allowed to push %s, make sure the token has the write:packages scope, or the workflow has the packages: write permission: %w",
+			imageName,
+			err,
+		)
+	}
+
+	return nil
+}
+
+func ghcrStatus() ProviderStatus {
+	return dockerRegistryStatus("GHCR", GitHubContainerRegistry)
+}
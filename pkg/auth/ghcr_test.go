@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsGitHubContainerRegistry(t *testing.T) {
+	expected := map[string]bool{
+		"ghcr.io":                           true,
+		"ghcr.io/3lvia":                     true,
+		"ghcr.io.example.com":               false,
+		"containerregistryelvia.azurecr.io": false,
+	}
+
+	for registry, expectedResult := range expected {
+		if IsGitHubContainerRegistry(registry) != expectedResult {
+			t.Errorf("Expected %t for %s", expectedResult, registry)
+		}
+	}
+}
+
+func TestResolveGitHubToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-token")
+
+	token, err := resolveGitHubToken()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if token != "gh-token" {
+		t.Errorf("Expected %s, got %s", "gh-token", token)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "github-token")
+
+	if token, _ := resolveGitHubToken(); token != "github-token" {
+		t.Errorf("Expected GITHUB_TOKEN to take precedence, got %s", token)
+	}
+}
+
+func TestResolveGitHubUsername1(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" || r.Header.Get("Authorization") != "Bearer ghp_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"login": "octocat"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("GITHUB_ACTOR", "")
+
+	username, err := resolveGitHubUsername(server.URL, "ghp_token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if username != "octocat" {
+		t.Errorf("Expected %s, got %s", "octocat", username)
+	}
+
+	if _, err := resolveGitHubUsername(server.URL, "invalid-token"); err == nil {
+		t.Errorf("Expected error for invalid token, got nil")
+	}
+}
+
+func TestResolveGitHubUsername2(t *testing.T) {
+	t.Setenv("GITHUB_ACTOR", "github-actions-user")
+
+	username, err := resolveGitHubUsername("http://localhost:0", "ghs_token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if username != "github-actions-user" {
+		t.Errorf("Expected %s, got %s", "github-actions-user", username)
+	}
+}
+
+func TestCheckGHCRPushPermission(t *testing.T) {
+	if err := CheckGHCRPushPermission("containerregistryelvia.azurecr.io/core-demo-api", DockerCredentials{}); err == nil {
+		t.Errorf("Expected error for image not in GitHub Container Registry, got nil")
+	}
+}
@@ -1,17 +1,307 @@
 package auth
 
-func AuthenticateGoogle() error {
-	/*
-		gcloudAuthLoginOutput := gcloudAuthLoginCommand(nil)
-		if command.IsError(gcloudAuthLoginOutput) {
-			return fmt.Errorf("Failed to authenticate to Google Cloud: %w", gcloudAuthLoginOutput.Error)
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+type GoogleAuthOptions struct {
+	// Path to a service account key file, or a credential configuration file for workload identity federation.
+	// Defaults to GOOGLE_APPLICATION_CREDENTIALS.
+	CredentialsFile string
+	// Full resource name of the workload identity provider, e.g.
+	// projects/123456789/locations/global/workloadIdentityPools/github/providers/github.
+	WorkloadIdentityProvider string
+	// Service account to impersonate when using workload identity federation.
+	ServiceAccount string
+	// OIDC token to exchange when using workload identity federation.
+	// Requested from GitHub Actions when not set.
+	OIDCToken  string
+	RunOptions *command.RunOptions
+}
+
+const (
+	googleAuthMethodCredentialsFile  = "credentials file"
+	googleAuthMethodWorkloadIdentity = "workload identity federation"
+	googleAuthMethodActiveAccount    = "active account"
+)
+
+const (
+	googleWorkloadIdentitySubjectTokenType   = "urn:ietf:params:oauth:token-type:jwt"
+	googleSecurityTokenServiceURL            = "https://sts.googleapis.com/v1/token"
+	googleServiceAccountImpersonationURLBase = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/"
+)
+
+const gkeAuthPluginName = "gke-gcloud-auth-plugin"
+
+// googleAuthMethod decides how to authenticate with Google Cloud, preferring explicit credentials
+// over workload identity federation, and falling back to the active gcloud account.
+func googleAuthMethod(options *GoogleAuthOptions) string {
+	if options.CredentialsFile != "" {
+		return googleAuthMethodCredentialsFile
+	}
+
+	if options.WorkloadIdentityProvider != "" {
+		return googleAuthMethodWorkloadIdentity
+	}
+
+	return googleAuthMethodActiveAccount
+}
+
+func AuthenticateGoogle(options *GoogleAuthOptions) error {
+	if options == nil {
+		options = &GoogleAuthOptions{}
+	}
+
+	if options.CredentialsFile == "" {
+		options.CredentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+
+	switch googleAuthMethod(options) {
+	case googleAuthMethodCredentialsFile:
+		log.Printf("Authenticating to Google Cloud using credentials file %s\n", options.CredentialsFile)
+
+		return gcloudLoginWithCredentialsFile(options.CredentialsFile, options.RunOptions)
+
+	case googleAuthMethodWorkloadIdentity:
+		log.Printf("Authenticating to Google Cloud using workload identity provider %s\n", options.WorkloadIdentityProvider)
+
+		oidcToken := options.OIDCToken
+		if oidcToken == "" {
+			token, err := getGitHubActionsOIDCToken(
+				os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"),
+				os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"),
+				googleWorkloadIdentityAudience(options.WorkloadIdentityProvider),
+			)
+			if err != nil {
+				return fmt.Errorf("Failed to get OIDC token for workload identity federation: %w", err)
+			}
+			oidcToken = token
 		}
-	*/
+
+		credentialsFile, err := writeWorkloadIdentityCredentials(
+			oidcToken,
+			options.WorkloadIdentityProvider,
+			options.ServiceAccount,
+		)
+		if err != nil {
+			return err
+		}
+
+		return gcloudLoginWithCredentialsFile(credentialsFile, options.RunOptions)
+	}
+
+	gcloudActiveAccountOutput := gcloudActiveAccountCommand(nil)
+	if command.IsError(gcloudActiveAccountOutput) {
+		return fmt.Errorf("Failed to check Google Cloud account, is gcloud installed? %w", gcloudActiveAccountOutput.Error)
+	}
+
+	if account := strings.TrimSpace(gcloudActiveAccountOutput.Output); account != "" {
+		log.Printf("Using active Google Cloud account %s\n", account)
+		return nil
+	}
+
+	if os.Getenv("CI") != "" {
+		return fmt.Errorf(
+			"No active Google Cloud account found. In CI, use a credentials file or workload identity federation to authenticate",
+		)
+	}
+
+	gcloudAuthLoginOutput := gcloudAuthLoginCommand(nil)
+	if command.IsError(gcloudAuthLoginOutput) {
+		return fmt.Errorf("Failed to authenticate to Google Cloud: %w", gcloudAuthLoginOutput.Error)
+	}
+
+	return nil
+}
+
+func gcloudLoginWithCredentialsFile(credentialsFile string, runOptions *command.RunOptions) error {
+	if _, err := os.Stat(credentialsFile); err != nil {
+		return fmt.Errorf("Failed to read Google Cloud credentials file: %w", err)
+	}
+
+	gcloudAuthLoginOutput := gcloudAuthLoginCredentialsFileCommand(credentialsFile, runOptions)
+	if command.IsError(gcloudAuthLoginOutput) {
+		return fmt.Errorf("Failed to authenticate to Google Cloud: %w", gcloudAuthLoginOutput.Error)
+	}
+
+	return nil
+}
+
+// googleWorkloadIdentityAudience returns the audience of the workload identity provider,
+// which must be the audience of the OIDC token exchanged for Google Cloud credentials.
+func googleWorkloadIdentityAudience(workloadIdentityProvider string) string {
+	return "//iam.googleapis.com/" + strings.TrimPrefix(workloadIdentityProvider, "/")
+}
+
+// getGitHubActionsOIDCToken requests an OIDC token for the audience from GitHub Actions.
+// Requires the workflow to have the id-token: write permission.
+func getGitHubActionsOIDCToken(requestURL string, requestToken string, audience string) (string, error) {
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf(
+			"ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN not set, make sure the workflow has the id-token: write permission",
+		)
+	}
+
+	parsedURL, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("Invalid OIDC token request URL: %w", err)
+	}
+
+	query := parsedURL.Query()
+	query.Set("audience", audience)
+	parsedURL.RawQuery = query.Encode()
+
+	request, err := http.NewRequest(http.MethodGet, parsedURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+requestToken)
+	request.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: registryRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unexpected status code %d when requesting OIDC token", response.StatusCode)
+	}
+
+	var tokenResponse struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("Failed to parse OIDC token response: %w", err)
+	}
+
+	if tokenResponse.Value == "" {
+		return "", fmt.Errorf("OIDC token response did not contain a token")
+	}
+
+	return tokenResponse.Value, nil
+}
+
+type googleExternalAccountCredentials struct {
+	Type                           string `json:"type"`
+	Audience                       string `json:"audience"`
+	SubjectTokenType               string `json:"subject_token_type"`
+	TokenURL                       string `json:"token_url"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url,omitempty"`
+	CredentialSource               struct {
+		File string `json:"file"`
+	} `json:"credential_source"`
+}
+
+func toGoogleExternalAccountCredentials(
+	tokenFile string,
+	workloadIdentityProvider string,
+	serviceAccount string,
+) googleExternalAccountCredentials {
+	credentials := googleExternalAccountCredentials{
+		Type:             "external_account",
+		Audience:         googleWorkloadIdentityAudience(workloadIdentityProvider),
+		SubjectTokenType: googleWorkloadIdentitySubjectTokenType,
+		TokenURL:         googleSecurityTokenServiceURL,
+	}
+	credentials.CredentialSource.File = tokenFile
+
+	if serviceAccount != "" {
+		credentials.ServiceAccountImpersonationURL = googleServiceAccountImpersonationURLBase +
+			serviceAccount + ":generateAccessToken"
+	}
+
+	return credentials
+}
+
+// writeWorkloadIdentityCredentials writes the OIDC token and a credential configuration file referring to it,
+// and returns the path to the credential configuration file. The files are kept, as gcloud reads the token
+// from the file whenever it needs to refresh its credentials.
+func writeWorkloadIdentityCredentials(
+	oidcToken string,
+	workloadIdentityProvider string,
+	serviceAccount string,
+) (string, error) {
+	directory, err := os.MkdirTemp("", "3lv-gcloud-")
+	if err != nil {
+		return "", err
+	}
+
+	tokenFile := filepath.Join(directory, "oidc-token")
+	if err := os.WriteFile(tokenFile, []byte(oidcToken), 0600); err != nil {
+		return "", fmt.Errorf("Failed to write OIDC token: %w", err)
+	}
+
+	credentials, err := json.MarshalIndent(
+		toGoogleExternalAccountCredentials(tokenFile, workloadIdentityProvider, serviceAccount),
+		"",
+		"  ",
+	)
+	if err != nil {
+		return "", err
+	}
+
+	credentialsFile := filepath.Join(directory, "credentials.json")
+	if err := os.WriteFile(credentialsFile, credentials, 0600); err != nil {
+		return "", fmt.Errorf("Failed to write Google Cloud credential configuration: %w", err)
+	}
+
+	return credentialsFile, nil
+}
+
+// EnsureGKEAuthPlugin checks that gke-gcloud-auth-plugin, required by kubectl to authenticate with GKE, is installed,
+// and tries to install it using gcloud if not.
+func EnsureGKEAuthPlugin() error {
+	checkGKEAuthPluginOutput := checkGKEAuthPluginInstalledCommand(nil)
+	if !command.IsError(checkGKEAuthPluginOutput) {
+		return nil
+	}
+
+	log.Printf("%s is not installed, will try to install it\n", gkeAuthPluginName)
+
+	installGKEAuthPluginOutput := gcloudInstallGKEAuthPluginCommand(nil)
+	if command.IsError(installGKEAuthPluginOutput) {
+		return fmt.Errorf(
+			"Failed to install %s, if gcloud was installed using a package manager, install the %s package instead: %w",
+			gkeAuthPluginName,
+			"google-cloud-cli-gke-gcloud-auth-plugin",
+			installGKEAuthPluginOutput.Error,
+		)
+	}
 
 	return nil
 }
 
-/*
+func gcloudActiveAccountCommand(
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"gcloud",
+			"auth",
+			"list",
+			"--filter",
+			"status:ACTIVE",
+			"--format",
+			"value(account)",
+		),
+		runOptions,
+	)
+}
+
 func gcloudAuthLoginCommand(
 	runOptions *command.RunOptions,
 ) command.Output {
@@ -24,4 +314,128 @@ func gcloudAuthLoginCommand(
 		runOptions,
 	)
 }
-*/
+
+func gcloudAuthLoginCredentialsFileCommand(
+	credentialsFile string,
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"gcloud",
+			"auth",
+			"login",
+			"--cred-file",
+			credentialsFile,
+			"--quiet",
+		),
+		runOptions,
+	)
+}
+
+func checkGKEAuthPluginInstalledCommand(
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(gkeAuthPluginName, "--version"),
+		runOptions,
+	)
+}
+
+func gcloudInstallGKEAuthPluginCommand(
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"gcloud",
+			"components",
+			"install",
+			gkeAuthPluginName,
+			"--quiet",
+		),
+		runOptions,
+	)
+}
+
+func gkeStatus() ProviderStatus {
+	status := ProviderStatus{Name: "GKE"}
+
+	gcloudConfigHelperOutput := gcloudConfigHelperCommand(&command.RunOptions{Quiet: true})
+	if command.IsError(gcloudConfigHelperOutput) {
+		status.Error = fmt.Errorf("Not logged in to Google Cloud")
+		return status
+	}
+
+	account, project, expiresOn, err := parseGcloudConfigHelper(gcloudConfigHelperOutput.Output)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	if account == "" {
+		status.Error = fmt.Errorf("No active Google Cloud account")
+		return status
+	}
+
+	status.Authenticated = true
+	status.Identity = account
+	if project != "" {
+		status.Details = "project " + project
+	}
+	status.ExpiresOn = expiresOn
+
+	return status
+}
+
+func parseGcloudConfigHelper(configHelperJSON string) (string, string, time.Time, error) {
+	var configHelper struct {
+		Configuration struct {
+			Properties struct {
+				Core struct {
+					Account string `json:"account"`
+					Project string `json:"project"`
+				} `json:"core"`
+			} `json:"properties"`
+		} `json:"configuration"`
+		Credential struct {
+			TokenExpiry time.Time `json:"token_expiry"`
+		} `json:"credential"`
+	}
+	if err := json.Unmarshal([]byte(configHelperJSON), &configHelper); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("Failed to parse gcloud configuration: %w", err)
+	}
+
+	return configHelper.Configuration.Properties.Core.Account,
+		configHelper.Configuration.Properties.Core.Project,
+		configHelper.Credential.TokenExpiry,
+		nil
+}
+
+// gcloudConfigHelperCommand only selects the account, project and token expiry, so that the access token is not logged.
+func gcloudConfigHelperCommand(
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"gcloud",
+			"config",
+			"config-helper",
+			"--format",
+			"json(configuration.properties.core.account,configuration.properties.core.project,credential.token_expiry)",
+		),
+		runOptions,
+	)
+}
+
+func gcloudAuthRevokeCommand(
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"gcloud",
+			"auth",
+			"revoke",
+			"--quiet",
+		),
+		runOptions,
+	)
+}
@@ -1 +1,232 @@
 package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+const testWorkloadIdentityProvider = "projects/123456789/locations/global/workloadIdentityPools/github/providers/github"
+
+func TestGoogleAuthMethod(t *testing.T) {
+	expected := map[string]*GoogleAuthOptions{
+		googleAuthMethodCredentialsFile: {
+			CredentialsFile:          "key.json",
+			WorkloadIdentityProvider: testWorkloadIdentityProvider,
+		},
+		googleAuthMethodWorkloadIdentity: {WorkloadIdentityProvider: testWorkloadIdentityProvider},
+		googleAuthMethodActiveAccount:    {},
+	}
+
+	for expectedMethod, options := range expected {
+		actualMethod := googleAuthMethod(options)
+		if actualMethod != expectedMethod {
+			t.Errorf("Expected %s, got %s", expectedMethod, actualMethod)
+		}
+	}
+}
+
+func TestToGoogleExternalAccountCredentials1(t *testing.T) {
+	credentials := toGoogleExternalAccountCredentials("/tmp/oidc-token", testWorkloadIdentityProvider, "")
+
+	const expectedAudience = "//iam.googleapis.com/" + testWorkloadIdentityProvider
+	if credentials.Audience != expectedAudience {
+		t.Errorf("Expected %s, got %s", expectedAudience, credentials.Audience)
+	}
+
+	if credentials.Type != "external_account" {
+		t.Errorf("Expected %s, got %s", "external_account", credentials.Type)
+	}
+
+	if credentials.CredentialSource.File != "/tmp/oidc-token" {
+		t.Errorf("Expected %s, got %s", "/tmp/oidc-token", credentials.CredentialSource.File)
+	}
+
+	if credentials.ServiceAccountImpersonationURL != "" {
+		t.Errorf("Expected no service account impersonation, got %s", credentials.ServiceAccountImpersonationURL)
+	}
+}
+
+func TestToGoogleExternalAccountCredentials2(t *testing.T) {
+	credentials := toGoogleExternalAccountCredentials(
+		"/tmp/oidc-token",
+		testWorkloadIdentityProvider,
+		"deploy@elvia-project.iam.gserviceaccount.com",
+	)
+
+	const expectedURL = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/deploy@elvia-project.iam.gserviceaccount.com:generateAccessToken"
+	if credentials.ServiceAccountImpersonationURL != expectedURL {
+		t.Errorf("Expected %s, got %s", expectedURL, credentials.ServiceAccountImpersonationURL)
+	}
+}
+
+func TestWriteWorkloadIdentityCredentials(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	credentialsFile, err := writeWorkloadIdentityCredentials("test-oidc-token", testWorkloadIdentityProvider, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	content, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	var credentials googleExternalAccountCredentials
+	if err := json.Unmarshal(content, &credentials); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expectedTokenFile := filepath.Join(filepath.Dir(credentialsFile), "oidc-token")
+	if credentials.CredentialSource.File != expectedTokenFile {
+		t.Errorf("Expected %s, got %s", expectedTokenFile, credentials.CredentialSource.File)
+	}
+
+	token, err := os.ReadFile(expectedTokenFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if string(token) != "test-oidc-token" {
+		t.Errorf("Expected %s, got %s", "test-oidc-token", token)
+	}
+}
+
+func TestGetGitHubActionsOIDCToken1(t *testing.T) {
+	const audience = "//iam.googleapis.com/" + testWorkloadIdentityProvider
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("audience") != audience {
+			t.Errorf("Expected audience %s, got %s", audience, r.URL.Query().Get("audience"))
+		}
+
+		if r.Header.Get("Authorization") != "Bearer test-request-token" {
+			t.Errorf("Expected bearer token, got %s", r.Header.Get("Authorization"))
+		}
+
+		w.Write([]byte(`{"value":"test-oidc-token"}`))
+	}))
+	defer server.Close()
+
+	token, err := getGitHubActionsOIDCToken(server.URL+"/token?api-version=2.0", "test-request-token", audience)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if token != "test-oidc-token" {
+		t.Errorf("Expected %s, got %s", "test-oidc-token", token)
+	}
+}
+
+func TestGetGitHubActionsOIDCToken2(t *testing.T) {
+	if _, err := getGitHubActionsOIDCToken("", "", "audience"); err == nil {
+		t.Errorf("Expected error when not running in GitHub Actions, got nil")
+	}
+}
+
+func TestGcloudActiveAccountCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"gcloud",
+			"auth",
+			"list",
+			"--filter",
+			"status:ACTIVE",
+			"--format",
+			"value(account)",
+		},
+		" ",
+	)
+
+	actualCommand := gcloudActiveAccountCommand(&command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestGcloudAuthLoginCredentialsFileCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"gcloud",
+			"auth",
+			"login",
+			"--cred-file",
+			"key.json",
+			"--quiet",
+		},
+		" ",
+	)
+
+	actualCommand := gcloudAuthLoginCredentialsFileCommand("key.json", &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestCheckGKEAuthPluginInstalledCommand(t *testing.T) {
+	expectedCommandString := "gke-gcloud-auth-plugin --version"
+
+	actualCommand := checkGKEAuthPluginInstalledCommand(&command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestGcloudInstallGKEAuthPluginCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"gcloud",
+			"components",
+			"install",
+			"gke-gcloud-auth-plugin",
+			"--quiet",
+		},
+		" ",
+	)
+
+	actualCommand := gcloudInstallGKEAuthPluginCommand(&command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestParseGcloudConfigHelper(t *testing.T) {
+	const configHelperJSON = `{
+  "configuration": { "properties": { "core": { "account": "user@elvia.no", "project": "elvia-runtimeservice-dev" } } },
+  "credential": { "token_expiry": "2024-07-01T12:00:00Z" }
+}`
+
+	account, project, expiresOn, err := parseGcloudConfigHelper(configHelperJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if account != "user@elvia.no" || project != "elvia-runtimeservice-dev" {
+		t.Errorf("Expected user@elvia.no in elvia-runtimeservice-dev, got %s in %s", account, project)
+	}
+
+	expectedExpiry := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	if !expiresOn.Equal(expectedExpiry) {
+		t.Errorf("Expected %s, got %s", expectedExpiry, expiresOn)
+	}
+}
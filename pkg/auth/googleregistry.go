@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+const (
+	googleCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	// Username Google registries accept with an OAuth access token as password.
+	googleRegistryTokenUsername = "oauth2accesstoken"
+)
+
+// IsGoogleArtifactRegistry returns true for Docker repositories in Artifact Registry, e.g. europe-west1-docker.pkg.dev.
+func IsGoogleArtifactRegistry(registry string) bool {
+	return strings.HasSuffix(registryHost(registry), "-docker.pkg.dev")
+}
+
+// IsGoogleContainerRegistry returns true for Container Registry, e.g. gcr.io or eu.gcr.io.
+func IsGoogleContainerRegistry(registry string) bool {
+	host := registryHost(registry)
+	return host == "gcr.io" || strings.HasSuffix(host, ".gcr.io")
+}
+
+func IsGoogleRegistry(registry string) bool {
+	return IsGoogleArtifactRegistry(registry) || IsGoogleContainerRegistry(registry)
+}
+
+// AuthenticateGoogleRegistry logs Docker in to Artifact Registry or Container Registry. With workload identity federation
+// or GOOGLE_OAUTH_ACCESS_TOKEN, an access token is written to the Docker config without gcloud.
+// Otherwise, gcloud is authenticated and configured as Docker credential helper for the registry.
+func AuthenticateGoogleRegistry(registry string, options *GoogleAuthOptions) error {
+	if options == nil {
+		options = &GoogleAuthOptions{}
+	}
+
+	host := registryHost(registry)
+
+	accessToken, err := getNativeGoogleAccessToken(options)
+	if err != nil {
+		return err
+	}
+
+	if accessToken != "" {
+		log.Printf("Authenticating to %s using an access token\n", host)
+
+		return storeDockerCredentials(
+			host,
+			DockerCredentials{Username: googleRegistryTokenUsername, Secret: accessToken},
+		)
+	}
+
+	if err := AuthenticateGoogle(options); err != nil {
+		return err
+	}
+
+	gcloudConfigureDockerOutput := gcloudConfigureDockerCommand(host, nil)
+	if command.IsError(gcloudConfigureDockerOutput) {
+		return fmt.Errorf("Failed to configure gcloud as Docker credential helper for %s: %w", host, gcloudConfigureDockerOutput.Error)
+	}
+
+	return nil
+}
+
+// getNativeGoogleAccessToken returns an access token obtained without gcloud, or an empty string if gcloud must be used.
+func getNativeGoogleAccessToken(options *GoogleAuthOptions) (string, error) {
+	if options.CredentialsFile != "" || os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "" {
+		return "", nil
+	}
+
+	if options.WorkloadIdentityProvider != "" {
+		oidcToken := options.OIDCToken
+		if oidcToken == "" {
+			requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+			if requestURL == "" {
+				return "", nil
+			}
+
+			token, err := getGitHubActionsOIDCToken(
+				requestURL,
+				os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"),
+				googleWorkloadIdentityAudience(options.WorkloadIdentityProvider),
+			)
+			if err != nil {
+				return "", fmt.Errorf("Failed to get OIDC token for workload identity federation: %w", err)
+			}
+			oidcToken = token
+		}
+
+		accessToken, err := exchangeGoogleFederatedToken(
+			googleSecurityTokenServiceURL,
+			options.WorkloadIdentityProvider,
+			oidcToken,
+		)
+		if err != nil || options.ServiceAccount == "" {
+			return accessToken, err
+		}
+
+		return impersonateGoogleServiceAccount(
+			googleServiceAccountImpersonationURLBase,
+			options.ServiceAccount,
+			accessToken,
+		)
+	}
+
+	return os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"), nil
+}
+
+// exchangeGoogleFederatedToken exchanges an OIDC token for a federated access token using the Security Token Service.
+func exchangeGoogleFederatedToken(
+	securityTokenServiceURL string,
+	workloadIdentityProvider string,
+	oidcToken string,
+) (string, error) {
+	form := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"audience":             {googleWorkloadIdentityAudience(workloadIdentityProvider)},
+		"scope":                {googleCloudPlatformScope},
+		"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+		"subject_token_type":   {googleWorkloadIdentitySubjectTokenType},
+		"subject_token":        {oidcToken},
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := postForm(securityTokenServiceURL, form, &tokenResponse); err != nil {
+		return "", fmt.Errorf("Failed to exchange OIDC token for Google Cloud access token: %w", err)
+	}
+
+	if tokenResponse.AccessToken == "" {
+		return "", fmt.Errorf("Security Token Service response did not contain an access token")
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+// impersonateGoogleServiceAccount exchanges a federated access token for an access token of the service account.
+func impersonateGoogleServiceAccount(
+	impersonationURLBase string,
+	serviceAccount string,
+	accessToken string,
+) (string, error) {
+	body, err := json.Marshal(
+		map[string]any{
+			"scope":    []string{googleCloudPlatformScope},
+			"lifetime": "3600s",
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequest(
+		http.MethodPost,
+		impersonationURLBase+serviceAccount+":generateAccessToken",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: registryRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Failed to impersonate service account %s: %w", serviceAccount, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(
+			"Failed to impersonate service account %s, unexpected status code %d. Does the workload identity have the Service Account Token Creator role?",
+			serviceAccount,
+			response.StatusCode,
+		)
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("Failed to parse service account access token: %w", err)
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+func gcloudConfigureDockerCommand(
+	registryHost string,
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"gcloud",
+			"auth",
+			"configure-docker",
+			registryHost,
+			"--quiet",
+		),
+		runOptions,
+	)
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestIsGoogleRegistry(t *testing.T) {
+	expected := map[string][2]bool{
+		"europe-west1-docker.pkg.dev/elvia-core/images": {true, false},
+		"europe-west1-docker.pkg.dev":                   {true, false},
+		"gcr.io/elvia-core":                             {false, true},
+		"eu.gcr.io":                                     {false, true},
+		"ghcr.io":                                       {false, false},
+		"containerregistryelvia.azurecr.io":             {false, false},
+	}
+
+	for registry, expectedResults := range expected {
+		if IsGoogleArtifactRegistry(registry) != expectedResults[0] {
+			t.Errorf("Expected IsGoogleArtifactRegistry to be %t for %s", expectedResults[0], registry)
+		}
+
+		if IsGoogleContainerRegistry(registry) != expectedResults[1] {
+			t.Errorf("Expected IsGoogleContainerRegistry to be %t for %s", expectedResults[1], registry)
+		}
+	}
+}
+
+func TestExchangeGoogleFederatedToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+
+		if r.PostForm.Get("audience") != "//iam.googleapis.com/"+testWorkloadIdentityProvider {
+			t.Errorf("Expected audience of the workload identity provider, got %s", r.PostForm.Get("audience"))
+		}
+
+		if r.PostForm.Get("subject_token") != "test-oidc-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant", "error_description": "The audience in ID Token does not match."}`))
+			return
+		}
+
+		w.Write([]byte(`{"access_token": "test-federated-access-token", "token_type": "Bearer", "expires_in": 3599}`))
+	}))
+	defer server.Close()
+
+	accessToken, err := exchangeGoogleFederatedToken(server.URL, testWorkloadIdentityProvider, "test-oidc-token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if accessToken != "test-federated-access-token" {
+		t.Errorf("Expected %s, got %s", "test-federated-access-token", accessToken)
+	}
+
+	if _, err := exchangeGoogleFederatedToken(server.URL, testWorkloadIdentityProvider, "invalid-token"); err == nil {
+		t.Errorf("Expected error for invalid OIDC token, got nil")
+	}
+}
+
+func TestImpersonateGoogleServiceAccount(t *testing.T) {
+	const serviceAccount = "deploy@elvia-core.iam.gserviceaccount.com"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+serviceAccount+":generateAccessToken" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer test-federated-access-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var body struct {
+			Scope []string `json:"scope"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Scope) != 1 {
+			t.Errorf("Expected scope in request body, got %v", body)
+		}
+
+		w.Write([]byte(`{"accessToken": "test-service-account-access-token", "expireTime": "2024-07-01T13:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	accessToken, err := impersonateGoogleServiceAccount(server.URL+"/", serviceAccount, "test-federated-access-token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if accessToken != "test-service-account-access-token" {
+		t.Errorf("Expected %s, got %s", "test-service-account-access-token", accessToken)
+	}
+
+	if _, err := impersonateGoogleServiceAccount(server.URL+"/", serviceAccount, "invalid-token"); err == nil {
+		t.Errorf("Expected error when not allowed to impersonate, got nil")
+	}
+}
+
+func TestAuthenticateGoogleRegistry(t *testing.T) {
+	writeTestDockerConfig(t, `{}`)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "test-access-token")
+
+	if err := AuthenticateGoogleRegistry("europe-west1-docker.pkg.dev/elvia-core/images", nil); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	credentials, err := getDockerCredentials("europe-west1-docker.pkg.dev")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := DockerCredentials{Username: "oauth2accesstoken", Secret: "test-access-token"}
+	if credentials == nil || *credentials != expected {
+		t.Errorf("Expected %+v, got %+v", expected, credentials)
+	}
+}
+
+func TestGcloudConfigureDockerCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"gcloud",
+			"auth",
+			"configure-docker",
+			"europe-west1-docker.pkg.dev",
+			"--quiet",
+		},
+		" ",
+	)
+
+	actualCommand := gcloudConfigureDockerCommand("europe-west1-docker.pkg.dev", &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func kubeStatus() ProviderStatus {
+	status := ProviderStatus{Name: "Kubernetes"}
+
+	kubectlConfigViewOutput := kubectlConfigViewCommand(&command.RunOptions{Quiet: true})
+	if command.IsError(kubectlConfigViewOutput) {
+		status.Error = fmt.Errorf("No current kube context")
+		return status
+	}
+
+	kubeContext, err := parseKubeConfig(kubectlConfigViewOutput.Output)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	if kubeContext.Name == "" {
+		status.Error = fmt.Errorf("No current kube context")
+		return status
+	}
+
+	status.Authenticated = true
+	status.Identity = kubeContext.User
+
+	details := []string{"context " + kubeContext.Name, "cluster " + kubeContext.Cluster}
+	if kubeContext.Namespace != "" {
+		details = append(details, "namespace "+kubeContext.Namespace)
+	}
+	status.Details = strings.Join(details, ", ")
+
+	return status
+}
+
+type kubeContext struct {
+	Name      string
+	Cluster   string
+	User      string
+	Namespace string
+}
+
+// parseKubeConfig returns the current context from the minified kubeconfig.
+func parseKubeConfig(kubeConfigJSON string) (kubeContext, error) {
+	var kubeConfig struct {
+		CurrentContext string `json:"current-context"`
+		Contexts       []struct {
+			Name    string `json:"name"`
+			Context struct {
+				Cluster   string `json:"cluster"`
+				User      string `json:"user"`
+				Namespace string `json:"namespace"`
+			} `json:"context"`
+		} `json:"contexts"`
+	}
+	if err := json.Unmarshal([]byte(kubeConfigJSON), &kubeConfig); err != nil {
+		return kubeContext{}, fmt.Errorf("Failed to parse kubeconfig: %w", err)
+	}
+
+	for _, context := range kubeConfig.Contexts {
+		if context.Name == kubeConfig.CurrentContext {
+			return kubeContext{
+				Name:      context.Name,
+				Cluster:   context.Context.Cluster,
+				User:      context.Context.User,
+				Namespace: context.Context.Namespace,
+			}, nil
+		}
+	}
+
+	return kubeContext{}, nil
+}
+
+// kubectlConfigViewCommand only shows the current context, with credentials redacted.
+func kubectlConfigViewCommand(runOptions *command.RunOptions) command.Output {
+	return command.Run(
+		*exec.Command(
+			"kubectl",
+			"config",
+			"view",
+			"--minify",
+			"--output",
+			"json",
+		),
+		runOptions,
+	)
+}
+
+func kubectlUseContextCommand(
+	contextName string,
+	runOptions *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"kubectl",
+			"config",
+			"use-context",
+			contextName,
+		),
+		runOptions,
+	)
+}
+
+func kubectlUnsetCurrentContextCommand(runOptions *command.RunOptions) command.Output {
+	return command.Run(
+		*exec.Command(
+			"kubectl",
+			"config",
+			"unset",
+			"current-context",
+		),
+		runOptions,
+	)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestParseKubeConfig1(t *testing.T) {
+	const kubeConfigJSON = `{
+  "kind": "Config",
+  "apiVersion": "v1",
+  "current-context": "aksdev",
+  "contexts": [
+    {
+      "name": "aksdev",
+      "context": { "cluster": "aksclusterdev", "user": "clusterUser_RUNTIMESERVICE-RGdev_aksclusterdev", "namespace": "core" }
+    }
+  ],
+  "users": [{ "name": "clusterUser_RUNTIMESERVICE-RGdev_aksclusterdev", "user": { "token": "REDACTED" } }]
+}`
+
+	actual, err := parseKubeConfig(kubeConfigJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := kubeContext{
+		Name:      "aksdev",
+		Cluster:   "aksclusterdev",
+		User:      "clusterUser_RUNTIMESERVICE-RGdev_aksclusterdev",
+		Namespace: "core",
+	}
+	if actual != expected {
+		t.Errorf("Expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestParseKubeConfig2(t *testing.T) {
+	actual, err := parseKubeConfig(`{"kind": "Config", "current-context": "", "contexts": null}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if actual.Name != "" {
+		t.Errorf("Expected no current context, got %+v", actual)
+	}
+}
+
+func TestKubectlConfigViewCommand(t *testing.T) {
+	expectedCommandString := "kubectl config view --minify --output json"
+
+	actualCommand := kubectlConfigViewCommand(&command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestKubectlUseContextCommand(t *testing.T) {
+	expectedCommandString := "kubectl config use-context aksdev"
+
+	actualCommand := kubectlUseContextCommand("aksdev", &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/3lvia/cli/pkg/registry"
+)
+
+func registryHost(registry string) string {
+	host, _, _ := strings.Cut(registry, "/")
+	return host
+}
+
+// RegistryCredentials returns the credentials Docker is logged in to the registry with, for use with registry.NewClient.
+// Returns nil when Docker is not logged in, so the registry is accessed anonymously.
+func RegistryCredentials(host string) (*registry.Credentials, error) {
+	credentials, err := getDockerCredentials(host)
+	if err != nil || credentials == nil {
+		return nil, err
+	}
+
+	return &registry.Credentials{Username: credentials.Username, Secret: credentials.Secret}, nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+// ProviderStatus describes whether and how a provider is authenticated.
+type ProviderStatus struct {
+	Name          string
+	Authenticated bool
+	Identity      string
+	Details       string
+	// Zero if the expiry is unknown.
+	ExpiresOn time.Time
+	// Why the provider is not authenticated.
+	Error error
+}
+
+func formatExpiry(expiresOn time.Time, now time.Time) string {
+	if expiresOn.IsZero() {
+		return "-"
+	}
+
+	if !now.Before(expiresOn) {
+		return "expired " + expiresOn.Local().Format(time.DateTime)
+	}
+
+	return expiresOn.Local().Format(time.DateTime)
+}
+
+func formatStatusTable(statuses []ProviderStatus, now time.Time) string {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROVIDER\tAUTHENTICATED\tIDENTITY\tDETAILS\tEXPIRES")
+
+	for _, status := range statuses {
+		authenticated := "yes"
+		if !status.Authenticated {
+			authenticated = "no"
+		} else if !status.ExpiresOn.IsZero() && !now.Before(status.ExpiresOn) {
+			authenticated = "expired"
+		}
+
+		identity := status.Identity
+		if identity == "" {
+			identity = "-"
+		}
+
+		details := status.Details
+		if status.Error != nil {
+			if details != "" {
+				details += ": "
+			}
+			details += status.Error.Error()
+		}
+		if details == "" {
+			details = "-"
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\n",
+			status.Name,
+			authenticated,
+			identity,
+			details,
+			formatExpiry(status.ExpiresOn, now),
+		)
+	}
+
+	writer.Flush()
+
+	return buffer.String()
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatStatusTable(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.Local)
+
+	statuses := []ProviderStatus{
+		{
+			Name:          "Azure",
+			Authenticated: true,
+			Identity:      "user@elvia.no (user)",
+			Details:       "tenant " + ElviaTenantID,
+			ExpiresOn:     now.Add(time.Hour),
+		},
+		{
+			Name:          "ACR",
+			Authenticated: true,
+			Identity:      "Entra ID token",
+			ExpiresOn:     now.Add(-time.Hour),
+		},
+		{
+			Name:    "GHCR",
+			Details: "ghcr.io",
+			Error:   fmt.Errorf("Docker is not logged in"),
+		},
+	}
+
+	lines := strings.Split(strings.TrimSpace(formatStatusTable(statuses, now)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected %d lines, got %d", 4, len(lines))
+	}
+
+	expectedFields := [][]string{
+		{"PROVIDER", "AUTHENTICATED", "IDENTITY", "DETAILS", "EXPIRES"},
+		{"Azure", "yes", "user@elvia.no (user)", "tenant " + ElviaTenantID, "2024-07-01 13:00:00"},
+		{"ACR", "expired", "Entra ID token", "-", "expired 2024-07-01 11:00:00"},
+		{"GHCR", "no", "-", "ghcr.io: Docker is not logged in", "-"},
+	}
+
+	for i, fields := range expectedFields {
+		for _, field := range fields {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("Expected line %d to contain %s, got %s", i, field, lines[i])
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package build
 import (
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"strings"
 
@@ -19,11 +20,11 @@ var Command *cli.Command = &cli.Command{
 	Name:    commandName,
 	Aliases: []string{"b"},
 	Usage:   "Build the project",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "project-file",
 			Aliases:  []string{"f"},
-			Usage:    "The project file to use",
+			Usage:    "The project file to use, e.g. a .csproj, .sln, go.mod or Dockerfile",
 			Required: true,
 			EnvVars:  []string{"3LV_PROJECT_FILE"},
 		},
@@ -46,6 +47,16 @@ var Command *cli.Command = &cli.Command{
 			Usage:   "The registry to use. Image name will be prefixed with this value.",
 			EnvVars: []string{"3LV_REGISTRY"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "mirror-to",
+			Usage:   "Additional registries to push the image to, named by the naming scheme of each registry. Can be given multiple times.",
+			EnvVars: []string{"3LV_MIRROR_TO"},
+		},
+		&cli.StringFlag{
+			Name:    "project",
+			Usage:   "The name or path of the project to build when the project file is a .NET solution. Defaults to the only project, or the project named like the application.",
+			EnvVars: []string{"3LV_PROJECT"},
+		},
 		&cli.StringFlag{
 			Name:    "go-main-package-directory",
 			Usage:   "The main package directory to use when building a Go application",
@@ -58,35 +69,54 @@ var Command *cli.Command = &cli.Command{
 			EnvVars: []string{"3LV_CACHE_TAG"},
 		},
 		&cli.StringFlag{
-			Name:    "severity",
-			Aliases: []string{"S"},
-			Usage:   "The severity to use when scanning the image: can be any combination of CRITICAL, HIGH, MEDIUM, LOW, or UNKNOWN separated by commas",
-			Value:   "CRITICAL,HIGH",
-			EnvVars: []string{"3LV_SEVERITY"},
+			Name:    "cache-backend",
+			Usage:   "Where to store the build cache: inline, registry, gha, local or s3.",
+			Value:   CacheBackendInline,
+			EnvVars: []string{"3LV_CACHE_BACKEND"},
+			Action: func(c *cli.Context, backend string) error {
+				if err := validateCacheBackend(backend); err != nil {
+					return cli.Exit(err, 1)
+				}
+
+				return nil
+			},
 		},
 		&cli.StringFlag{
-			Name:    "azure-tenant-id",
-			Usage:   "The tenant ID to use when authenticating with the Azure Container Registry.",
-			Hidden:  true,
-			EnvVars: []string{"3LV_AZURE_TENANT_ID"},
+			Name:    "cache-ref",
+			Usage:   "The image reference to store the build cache in when using the registry cache backend. Defaults to the image with the buildcache tag.",
+			EnvVars: []string{"3LV_CACHE_REF"},
 		},
 		&cli.StringFlag{
-			Name:    "azure-subscription-id",
-			Usage:   "The subscription ID to use when authenticating with the Azure Container Registry.",
-			Hidden:  true,
-			EnvVars: []string{"3LV_AZURE_SUBSCRIPTION_ID"},
+			Name:    "cache-dir",
+			Usage:   "The directory to store the build cache in when using the local cache backend. Defaults to a directory in the user cache directory.",
+			EnvVars: []string{"3LV_CACHE_DIR"},
 		},
 		&cli.StringFlag{
-			Name:    "azure-client-id",
-			Usage:   "The client ID to use when authenticating with the registry. Must be combined with --azure-federated-token.",
-			Hidden:  true,
-			EnvVars: []string{"3LV_AZURE_CLIENT_ID"},
+			Name:    "cache-s3-bucket",
+			Usage:   "The bucket to store the build cache in when using the s3 cache backend. Credentials are read from the AWS_ environment variables.",
+			EnvVars: []string{"3LV_CACHE_S3_BUCKET"},
 		},
 		&cli.StringFlag{
-			Name:    "azure-federated-token",
-			Usage:   "The federated token to use when authenticating with the Azure Container Registry. Must be combined with --client-id.",
-			Hidden:  true,
-			EnvVars: []string{"3LV_AZURE_FEDERATED_TOKEN"},
+			Name:    "cache-s3-region",
+			Usage:   "The region of the bucket when using the s3 cache backend.",
+			EnvVars: []string{"3LV_CACHE_S3_REGION", "AWS_REGION"},
+		},
+		&cli.StringFlag{
+			Name:    "cache-s3-endpoint-url",
+			Usage:   "The endpoint of S3-compatible storage, e.g. MinIO, when using the s3 cache backend.",
+			EnvVars: []string{"3LV_CACHE_S3_ENDPOINT_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "severity",
+			Aliases: []string{"S"},
+			Usage:   "The severity to use when scanning the image: can be any combination of CRITICAL, HIGH, MEDIUM, LOW, or UNKNOWN separated by commas",
+			Value:   "CRITICAL,HIGH",
+			EnvVars: []string{"3LV_SEVERITY"},
+		},
+		&cli.StringFlag{
+			Name:    "gcp-project-id",
+			Usage:   "The Google Cloud project of the registry, when the registry is Artifact Registry or Container Registry without a project, e.g. europe-west1-docker.pkg.dev.",
+			EnvVars: []string{"3LV_GCP_PROJECT_ID"},
 		},
 		&cli.StringSliceFlag{
 			Name:    "additional-tags",
@@ -109,11 +139,11 @@ var Command *cli.Command = &cli.Command{
 		&cli.StringSliceFlag{
 			Name:    "scan-formats",
 			Aliases: []string{"F"},
-			Usage:   "The formats to use when outputting the scan results: can be table, json, sarif or markdown.",
+			Usage:   "The formats to use when outputting the scan results: can be table, json, sarif, markdown or junit.",
 			Value:   cli.NewStringSlice("table"),
 			Action: func(c *cli.Context, formats []string) error {
 				for _, format := range formats {
-					if format != "table" && format != "json" && format != "sarif" && format != "markdown" {
+					if format != "table" && format != "json" && format != "sarif" && format != "markdown" && format != "junit" {
 						return cli.Exit("Invalid format provided", 1)
 					}
 				}
@@ -122,6 +152,84 @@ var Command *cli.Command = &cli.Command{
 			},
 			EnvVars: []string{"3LV_SCAN_FORMATS"},
 		},
+		&cli.StringFlag{
+			Name:    "scan-template",
+			Aliases: []string{"T"},
+			Usage:   "Path to a custom Go template used instead of the built-in template when outputting the scan results as markdown.",
+			EnvVars: []string{"3LV_SCAN_TEMPLATE"},
+		},
+		&cli.BoolFlag{
+			Name:    "scan-github-report",
+			Usage:   "When running in GitHub Actions, write the markdown scan report to the job summary and comment it on the pull request. Requires GITHUB_TOKEN or GH_TOKEN to be set.",
+			Value:   false,
+			EnvVars: []string{"3LV_SCAN_GITHUB_REPORT"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-output-dir",
+			Usage:   "The directory to write the scan result files to. Will be created if it does not exist.",
+			Value:   ".",
+			EnvVars: []string{"3LV_SCAN_OUTPUT_DIR"},
+		},
+		&cli.BoolFlag{
+			Name:    "scan-output-per-image",
+			Usage:   "Include the image name in the scan result file names, e.g. trivy-ghcr.io-3lvia-core-cli-latest.json instead of trivy.json. Always done when an output directory is given.",
+			Value:   false,
+			EnvVars: []string{"3LV_SCAN_OUTPUT_PER_IMAGE"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-policy",
+			Usage:   "Path to a JSON policy file deciding which vulnerabilities fail the scan. See the README for the policy format.",
+			EnvVars: []string{"3LV_SCAN_POLICY"},
+		},
+		&cli.BoolFlag{
+			Name:    "scan-ignore-unfixed",
+			Usage:   "Ignore vulnerabilities without a fix available. Use --scan-ignore-unfixed=false to include them.",
+			Value:   true,
+			EnvVars: []string{"3LV_SCAN_IGNORE_UNFIXED"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-epss-feed",
+			Usage:   "File path or URL of EPSS scores used to enrich, sort and gate on vulnerabilities when scanning.",
+			EnvVars: []string{"3LV_SCAN_EPSS_FEED"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-kev-feed",
+			Usage:   "File path or URL of the CISA known exploited vulnerabilities catalog used to enrich, sort and gate on vulnerabilities when scanning.",
+			EnvVars: []string{"3LV_SCAN_KEV_FEED"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-sort-by",
+			Usage:   "How to sort vulnerabilities in the scan output: severity or epss.",
+			Value:   scan.SortBySeverity,
+			Action:  scan.ValidateSortBy,
+			EnvVars: []string{"3LV_SCAN_SORT_BY"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scan-vex",
+			Usage:   "Path to an OpenVEX document for the application. Used to filter scan results, and attached to the image when pushing. Can be given multiple times.",
+			EnvVars: []string{"3LV_SCAN_VEX"},
+		},
+		&cli.BoolFlag{
+			Name:    "scan-licenses",
+			Usage:   "Also scan the image for licenses, checked against --scan-license-allow, --scan-license-warn and --scan-license-deny.",
+			Value:   false,
+			EnvVars: []string{"3LV_SCAN_LICENSES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scan-license-allow",
+			Usage:   "SPDX license identifiers to allow, e.g. MIT,Apache-2.0. A trailing * matches any suffix. When set, licenses not in any list are warned about.",
+			EnvVars: []string{"3LV_SCAN_LICENSE_ALLOW"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scan-license-warn",
+			Usage:   "SPDX license identifiers to warn about, e.g. LGPL-*. A trailing * matches any suffix.",
+			EnvVars: []string{"3LV_SCAN_LICENSE_WARN"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scan-license-deny",
+			Usage:   "SPDX license identifiers failing the scan, e.g. GPL-*,AGPL-*. A trailing * matches any suffix.",
+			EnvVars: []string{"3LV_SCAN_LICENSE_DENY"},
+		},
 		&cli.BoolFlag{
 			Name:    "push",
 			Aliases: []string{"p"},
@@ -150,13 +258,35 @@ var Command *cli.Command = &cli.Command{
 			Value:   false,
 			EnvVars: []string{"3LV_SCAN_SKIP_DB_UPDATE"},
 		},
+		&cli.StringFlag{
+			Name:    "output-tarball",
+			Usage:   "Export the image to a tarball at this path instead of loading it into the Docker daemon. The tarball is scanned without Docker. Can not be combined with --push.",
+			EnvVars: []string{"3LV_OUTPUT_TARBALL"},
+		},
+		&cli.GenericFlag{
+			Name:    "build-arg",
+			Usage:   "Build argument to pass to the build, as KEY=VALUE or KEY to use the environment variable. Can be given multiple times.",
+			Value:   &repeatedFlag{},
+			EnvVars: []string{"3LV_BUILD_ARG"},
+		},
+		&cli.GenericFlag{
+			Name:    "secret",
+			Usage:   "Secret to mount into the build, as id=<id>,src=<file> or id=<id>,env=<variable>. Generated Dockerfiles mount the nugetconfig secret when restoring .NET projects and the netrc secret when downloading Go modules. Can be given multiple times.",
+			Value:   &repeatedFlag{},
+			EnvVars: []string{"3LV_SECRET"},
+		},
+		&cli.StringFlag{
+			Name:    "digest-file",
+			Usage:   "Write the digest of the pushed image to this file, e.g. to deploy it with --image-digest. Requires --push. The digest is also written to the step outputs when running in GitHub Actions.",
+			EnvVars: []string{"3LV_DIGEST_FILE"},
+		},
 		&cli.BoolFlag{
 			Name:    "skip-authentication",
 			Usage:   "Skip authentication when pushing the image to the registry",
 			Value:   false,
 			EnvVars: []string{"3LV_SKIP_AUTHENTICATION"},
 		},
-	},
+	}, append(auth.RegistryAuthFlags(), scan.DBFlags("scan-", "SCAN_")...)...),
 	Action: Build,
 }
 
@@ -194,11 +324,34 @@ func Build(c *cli.Context) error {
 		return cli.Exit(err, 1)
 	}
 
+	buildArgs := utils.RemoveZeroValues(*c.Generic("build-arg").(*repeatedFlag))
+	if err := validateBuildArgs(buildArgs); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	secrets, err := parseBuildSecrets(utils.RemoveZeroValues(*c.Generic("secret").(*repeatedFlag)))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	secrets = addNuGetCredentials(projectFile, secrets)
+
+	buildArgs, secrets, netrcFile, err := addGoPrivateCredentials(projectFile, buildArgs, secrets)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+	if netrcFile != "" {
+		defer os.Remove(netrcFile)
+	}
+
 	generateOptions := GenerateDockerfileOptions{
 		GoMainPackageDirectory: c.String("go-main-package-directory"),
 		BuildContext:           c.String("build-context"),
 		IncludeFiles:           utils.RemoveZeroValues(c.StringSlice("include-files")),
 		IncludeDirectories:     utils.RemoveZeroValues(c.StringSlice("include-directories")),
+		SecretIDs:              secretIDs(secrets),
+		GoPrivate:              hasBuildArg(buildArgs, "GOPRIVATE"),
+		Project:                c.String("project"),
 	}
 
 	dockerfilePath, buildContext, err := generateDockerfile(
@@ -215,109 +368,130 @@ func Build(c *cli.Context) error {
 		return nil
 	}
 
+	vexFiles := utils.RemoveZeroValues(c.StringSlice("scan-vex"))
+	// Fail before building if the VEX documents are invalid.
+	if _, err := scan.ReadVEXFiles(vexFiles); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	outputTarball := c.String("output-tarball")
+	push := c.Bool("push")
+	if outputTarball != "" && push {
+		return cli.Exit("--output-tarball can not be combined with --push", 1)
+	}
+
+	digestFile := c.String("digest-file")
+	if digestFile != "" && !push {
+		return cli.Exit("--digest-file requires --push", 1)
+	}
+
 	cacheTag := c.String("cache-tag")
-	registry := utils.StringWithDefault(c.String("registry"), "containerregistryelvia.azurecr.io")
+	registry := utils.StringWithDefault(c.String("registry"), auth.ElviaContainerRegistry)
 
 	skipAuthentication := c.Bool("skip-authentication")
 
-	if strings.Contains(registry, "azurecr.io") && !skipAuthentication {
-		log.Println("Azure registry detected, will try to authenticate with Azure")
-
-		azureTenantID := utils.StringWithDefault(
-			c.String("azure-tenant-id"),
-			auth.ElviaTenantID,
-		)
-		azureSubscriptionID := utils.StringWithDefault(
-			c.String("azure-subscription-id"),
-			auth.ElviaDefaultRuntimeSubscriptionID,
-		)
+	targets, err := ResolveRegistryTargets(
+		append([]string{registry}, utils.RemoveZeroValues(c.StringSlice("mirror-to"))...),
+		systemName,
+		applicationName,
+		c.String("gcp-project-id"),
+	)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
 
-		options := &auth.AzLoginCommandOptions{
-			ClientID:       c.String("azure-client-id"),
-			FederatedToken: c.String("azure-federated-token"),
-		}
+	imageName := targets[0].ImageName
+	mirrors := targets[1:]
 
-		err := auth.AuthenticateAzure(
-			azureTenantID,
-			azureSubscriptionID,
-			options,
-		)
-		if err != nil {
-			return cli.Exit(err, 1)
-		}
+	if !skipAuthentication {
+		// Mirrors are only used when pushing, while the primary registry is also used as build cache.
+		for i, target := range targets {
+			if i > 0 && !push {
+				break
+			}
 
-		registryName, err := func() (string, error) {
-			split := strings.Split(registry, ".")
-			if len(split) <= 0 {
-				return "", fmt.Errorf("Invalid registry name: %s", registry)
+			if err := AuthenticateRegistry(target, push, auth.RegistryAuthOptionsFromContext(c)); err != nil {
+				return cli.Exit(err, 1)
 			}
-			return split[0], nil
-		}()
-		if err != nil {
-			return cli.Exit(err, 1)
 		}
+	}
 
-		azAcrLoginCommandOutput := azAcrLoginCommand(
-			registryName,
-			nil,
-		)
-		if command.IsError(azAcrLoginCommandOutput) {
-			return cli.Exit(
-				fmt.Errorf(
-					"Failed to authenticate to Azure Container Registry: %w",
-					azAcrLoginCommandOutput.Error,
-				),
-				1,
-			)
-		}
+	additionalTags := utils.RemoveZeroValues(c.StringSlice("additional-tags"))
 
-	}
+	cacheBackend := utils.StringWithDefault(c.String("cache-backend"), CacheBackendInline)
+	log.Printf("Using the %s build cache\n", cacheBackend)
 
-	imageName, err := getImageName(
-		registry,
-		systemName,
-		applicationName,
-	)
+	builder, err := ensureCacheBuilder(cacheBackend)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
 
 	buildImageCommandOutput := buildImageCommand(
 		dockerfilePath,
 		buildContext,
 		imageName,
 		cacheTag,
-		utils.RemoveZeroValues(c.StringSlice("additional-tags")),
-		nil,
+		additionalTags,
+		&BuildImageCommandOptions{
+			OutputTarball: outputTarball,
+			Cache: &CacheOptions{
+				Backend:    cacheBackend,
+				Ref:        c.String("cache-ref"),
+				Directory:  c.String("cache-dir"),
+				S3Bucket:   c.String("cache-s3-bucket"),
+				S3Region:   c.String("cache-s3-region"),
+				S3Endpoint: c.String("cache-s3-endpoint-url"),
+			},
+			Builder:   builder,
+			BuildArgs: buildArgs,
+			Secrets:   secrets,
+		},
 	)
 	if command.IsError(buildImageCommandOutput) {
 		return cli.Exit(buildImageCommandOutput.Error, 1)
 	}
 
+	scanOptions := &scan.ScanImageOptions{
+		TemplateFile:    c.String("scan-template"),
+		GitHubReport:    c.Bool("scan-github-report"),
+		OutputDirectory: c.String("scan-output-dir"),
+		OutputPerImage:  c.Bool("scan-output-per-image"),
+		PolicyFile:      c.String("scan-policy"),
+		IncludeUnfixed:  !c.Bool("scan-ignore-unfixed"),
+		DB:              scan.DBOptionsFromContext(c, "scan-"),
+		DBMaxAge:        c.Duration("scan-db-max-age"),
+		Enrichment: scan.EnrichmentOptions{
+			EPSSFeed: c.String("scan-epss-feed"),
+			KEVFeed:  c.String("scan-kev-feed"),
+		},
+		SortBy:   c.String("scan-sort-by"),
+		VEXFiles: vexFiles,
+	}
+
+	imageToScan := imageName + ":" + cacheTag
+	if outputTarball != "" {
+		imageToScan = outputTarball
+		scanOptions.Input = true
+	}
+
+	if c.Bool("scan-licenses") {
+		scanOptions.Licenses = &scan.LicenseConfig{
+			Allow: utils.RemoveZeroValues(c.StringSlice("scan-license-allow")),
+			Warn:  utils.RemoveZeroValues(c.StringSlice("scan-license-warn")),
+			Deny:  utils.RemoveZeroValues(c.StringSlice("scan-license-deny")),
+		}
+	}
+
 	scanErr := scan.ScanImage(
-		imageName+":"+cacheTag,
+		imageToScan,
 		c.String("severity"),
 		utils.RemoveZeroValues(c.StringSlice("scan-formats")),
 		c.Bool("scan-disable-error"),
 		c.Bool("scan-skip-db-update"),
+		scanOptions,
 	)
 
-	push := c.Bool("push")
-
-	if push && scanErr != nil {
-		pushImageOutput := pushImageCommand(
-			imageName,
-			cacheTag,
-			false,
-			nil,
-		)
-
-		if command.IsError(pushImageOutput) {
-			return fmt.Errorf(
-				"Failed to push Docker image cache to tag %s after scan reported vulnerabilities: %w",
-				cacheTag,
-				err,
-			)
-		}
-	}
-
+	// The image is not pushed when the scan fails, not even the cache tag, since it could then be deployed.
 	if scanErr != nil {
 		return scanErr
 	}
@@ -331,7 +505,39 @@ func Build(c *cli.Context) error {
 		)
 
 		if command.IsError(pushImageOutput) {
-			return fmt.Errorf("Failed to push Docker image. If using GHCR, please login using the command `gh auth login` first. %w", err)
+			return fmt.Errorf("Failed to push Docker image: %w", pushImageOutput.Error)
+		}
+
+		digest, err := resolvePushedDigest(imageName, cacheTag, auth.RegistryCredentials)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		log.Printf("Pushed %s@%s\n", imageName, digest)
+
+		if err := writeBuildOutputs(imageName, digest, digestFile, os.Getenv("GITHUB_OUTPUT")); err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if len(vexFiles) > 0 {
+			if err := attachVEX(imageName+"@"+digest, vexFiles); err != nil {
+				return cli.Exit(err, 1)
+			}
+		}
+
+		if len(mirrors) > 0 {
+			results := append(
+				[]pushResult{{ImageName: imageName}},
+				pushToMirrors(imageName, mirrors, imageTags(cacheTag, additionalTags), cacheTag, vexFiles)...,
+			)
+
+			fmt.Print(formatPushResults(results))
+
+			for _, result := range results {
+				if result.Error != nil {
+					return cli.Exit("Failed to push to all registries", 1)
+				}
+			}
 		}
 	}
 
@@ -353,51 +559,111 @@ func getImageName(
 		return "", fmt.Errorf("getImageName: Application name not provided")
 	}
 
+	if auth.IsGoogleArtifactRegistry(registry) {
+		// Artifact Registry images are named location-docker.pkg.dev/project/repository/image,
+		// with the repository defaulting to the system name.
+		switch strings.Count(registry, "/") {
+		case 0:
+			return "", fmt.Errorf("getImageName: Google Cloud project not provided, use --gcp-project-id or include it in the registry")
+		case 1:
+			return strings.ToLower(fmt.Sprintf("%s/%s/%s", registry, systemName, applicationName)), nil
+		default:
+			return strings.ToLower(fmt.Sprintf("%s/%s-%s", registry, systemName, applicationName)), nil
+		}
+	}
+
+	if auth.IsGoogleContainerRegistry(registry) && !strings.Contains(registry, "/") {
+		return "", fmt.Errorf("getImageName: Google Cloud project not provided, use --gcp-project-id or include it in the registry")
+	}
+
 	if strings.Contains(registry, "azurecr.io") || strings.Contains(registry, "gcr.io") {
 		return strings.ToLower(fmt.Sprintf("%s/%s-%s", registry, systemName, applicationName)), nil
 	}
 	return strings.ToLower(fmt.Sprintf("%s/%s/%s", registry, systemName, applicationName)), nil
 }
 
+// googleRegistryWithProject adds the project to a Google Cloud registry given without one, e.g. gcr.io.
+func googleRegistryWithProject(registry string, projectID string) string {
+	if projectID == "" || strings.Contains(registry, "/") {
+		return registry
+	}
+
+	return registry + "/" + projectID
+}
+
+// imageTags returns the tags the image is built with.
+func imageTags(cacheTag string, additionalTags []string) []string {
+	return append(append([]string{}, additionalTags...), cacheTag)
+}
+
+type BuildImageCommandOptions struct {
+	// Export the image to a tarball at this path instead of loading it into the Docker daemon.
+	OutputTarball string
+	// Defaults to the inline cache.
+	Cache *CacheOptions
+	// The buildx builder to use, defaults to the current builder.
+	Builder string
+	// Build arguments as KEY=VALUE, or KEY to use the environment variable.
+	BuildArgs  []string
+	Secrets    []BuildSecret
+	RunOptions *command.RunOptions
+}
+
 func buildImageCommand(
 	dockerfilePath string,
 	buildContext string,
 	imageName string,
 	cacheTag string,
 	additionalTags []string,
-	options *command.RunOptions,
+	options *BuildImageCommandOptions,
 ) command.Output {
-	tags := func() []string {
-		if len(additionalTags) == 0 {
-			return []string{cacheTag}
-		}
-
-		return append(additionalTags, cacheTag)
-	}()
+	if options == nil {
+		options = &BuildImageCommandOptions{}
+	}
 
 	var tagArguments []string
-	for _, tag := range tags {
+	for _, tag := range imageTags(cacheTag, additionalTags) {
 		tagArguments = append(tagArguments, "-t")
 		tagArguments = append(tagArguments, imageName+":"+tag)
 	}
 
+	cacheArgs, err := cacheArguments(imageName, cacheTag, options.Cache)
+	if err != nil {
+		return command.Error(err)
+	}
+
 	buildCmd := exec.Command(
 		"docker",
 		"buildx",
 		"build",
-		"-f",
-		dockerfilePath,
-		"--load",
-		"--cache-to",
-		"type=inline",
-		"--cache-from",
-		imageName+":"+cacheTag,
 	)
 
+	if options.Builder != "" {
+		buildCmd.Args = append(buildCmd.Args, "--builder", options.Builder)
+	}
+
+	buildCmd.Args = append(buildCmd.Args, "-f", dockerfilePath)
+
+	if options.OutputTarball != "" {
+		buildCmd.Args = append(buildCmd.Args, "--output", "type=docker,dest="+options.OutputTarball)
+	} else {
+		buildCmd.Args = append(buildCmd.Args, "--load")
+	}
+
+	buildCmd.Args = append(buildCmd.Args, cacheArgs...)
+
+	for _, buildArg := range options.BuildArgs {
+		buildCmd.Args = append(buildCmd.Args, "--build-arg", buildArg)
+	}
+
+	for _, secret := range options.Secrets {
+		buildCmd.Args = append(buildCmd.Args, "--secret", secret.String())
+	}
+
 	buildCmd.Args = append(buildCmd.Args, tagArguments...)
 	buildCmd.Args = append(buildCmd.Args, buildContext)
 
-	return command.Run(*buildCmd, options)
+	return command.Run(*buildCmd, options.RunOptions)
 }
 
 func pushImageCommand(
@@ -427,19 +693,3 @@ func pushImageCommand(
 		options,
 	)
 }
-
-func azAcrLoginCommand(
-	registryName string,
-	options *command.RunOptions,
-) command.Output {
-	return command.Run(
-		*exec.Command(
-			"az",
-			"acr",
-			"login",
-			"--name",
-			registryName,
-		),
-		options,
-	)
-}
@@ -75,6 +75,81 @@ func TestGetImageName4(t *testing.T) {
 	}
 }
 
+func TestGetImageName5(t *testing.T) {
+	const registry = "europe-west1-docker.pkg.dev/elvia-core"
+	const systemName = "core"
+	const imageName = "demo-api"
+
+	expectedImageName := registry + "/" + systemName + "/" + imageName
+
+	actualImageName, err := getImageName(registry, systemName, imageName)
+	if err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+
+	if actualImageName != expectedImageName {
+		t.Errorf("Expected %s, got %s", expectedImageName, actualImageName)
+	}
+}
+
+func TestGetImageName6(t *testing.T) {
+	const registry = "europe-west1-docker.pkg.dev/elvia-core/images"
+	const systemName = "core"
+	const imageName = "demo-api"
+
+	expectedImageName := registry + "/" + systemName + "-" + imageName
+
+	actualImageName, err := getImageName(registry, systemName, imageName)
+	if err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+
+	if actualImageName != expectedImageName {
+		t.Errorf("Expected %s, got %s", expectedImageName, actualImageName)
+	}
+}
+
+func TestGetImageName7(t *testing.T) {
+	for _, registry := range []string{"europe-west1-docker.pkg.dev", "eu.gcr.io"} {
+		if _, err := getImageName(registry, "core", "demo-api"); err == nil {
+			t.Errorf("Expected error for %s without project, got nil", registry)
+		}
+	}
+}
+
+func TestGetImageName8(t *testing.T) {
+	const registry = "gcr.io/elvia-core"
+	const systemName = "core"
+	const imageName = "demo-api"
+
+	expectedImageName := registry + "/" + systemName + "-" + imageName
+
+	actualImageName, err := getImageName(registry, systemName, imageName)
+	if err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+
+	if actualImageName != expectedImageName {
+		t.Errorf("Expected %s, got %s", expectedImageName, actualImageName)
+	}
+}
+
+func TestGoogleRegistryWithProject(t *testing.T) {
+	expected := map[string]string{
+		"europe-west1-docker.pkg.dev":            "europe-west1-docker.pkg.dev/elvia-core",
+		"europe-west1-docker.pkg.dev/other":      "europe-west1-docker.pkg.dev/other",
+		"europe-west1-docker.pkg.dev/other/repo": "europe-west1-docker.pkg.dev/other/repo",
+		"gcr.io":                                 "gcr.io/elvia-core",
+	}
+
+	for registry, expectedRegistry := range expected {
+		actualRegistry := googleRegistryWithProject(registry, "elvia-core")
+		if actualRegistry != expectedRegistry {
+			t.Errorf("Expected %s, got %s", expectedRegistry, actualRegistry)
+		}
+	}
+}
+
 func TestBuildCommand1(t *testing.T) {
 	const dockerfilePath = "build/Dockerfile"
 	const buildContext = "src/app"
@@ -108,7 +183,7 @@ func TestBuildCommand1(t *testing.T) {
 		imageName,
 		cacheTag,
 		[]string{},
-		&command.RunOptions{DryRun: true},
+		&BuildImageCommandOptions{RunOptions: &command.RunOptions{DryRun: true}},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -151,7 +226,7 @@ func TestBuildCommand2(t *testing.T) {
 		imageName,
 		cacheTag,
 		[]string{},
-		&command.RunOptions{DryRun: true},
+		&BuildImageCommandOptions{RunOptions: &command.RunOptions{DryRun: true}},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -201,7 +276,154 @@ func TestBuildCommand3(t *testing.T) {
 		imageName,
 		cacheTag,
 		additionalTags,
-		&command.RunOptions{DryRun: true},
+		&BuildImageCommandOptions{RunOptions: &command.RunOptions{DryRun: true}},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestBuildCommand4(t *testing.T) {
+	const dockerfilePath = "Dockerfile"
+	const buildContext = "."
+	const imageName = "ghcr.io/test-image"
+	const cacheTag = "latest-cache"
+
+	imageNameWithCacheTag := imageName + ":" + cacheTag
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"docker",
+			"buildx",
+			"build",
+			"-f",
+			dockerfilePath,
+			"--output",
+			"type=docker,dest=image.tar",
+			"--cache-to",
+			"type=inline",
+			"--cache-from",
+			imageNameWithCacheTag,
+			"-t",
+			imageNameWithCacheTag,
+			buildContext,
+		},
+		" ",
+	)
+
+	actualCommand := buildImageCommand(
+		dockerfilePath,
+		buildContext,
+		imageName,
+		cacheTag,
+		[]string{},
+		&BuildImageCommandOptions{
+			OutputTarball: "image.tar",
+			RunOptions:    &command.RunOptions{DryRun: true},
+		},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestBuildCommand5(t *testing.T) {
+	const dockerfilePath = "Dockerfile"
+	const buildContext = "."
+	const imageName = "ghcr.io/test-image"
+	const cacheTag = "latest-cache"
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"docker",
+			"buildx",
+			"build",
+			"--builder",
+			"3lv",
+			"-f",
+			dockerfilePath,
+			"--load",
+			"--cache-to",
+			"type=registry,ref=" + imageName + ":buildcache,mode=max,image-manifest=true,oci-mediatypes=true",
+			"--cache-from",
+			"type=registry,ref=" + imageName + ":buildcache",
+			"-t",
+			imageName + ":" + cacheTag,
+			buildContext,
+		},
+		" ",
+	)
+
+	actualCommand := buildImageCommand(
+		dockerfilePath,
+		buildContext,
+		imageName,
+		cacheTag,
+		[]string{},
+		&BuildImageCommandOptions{
+			Cache:      &CacheOptions{Backend: CacheBackendRegistry},
+			Builder:    "3lv",
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestBuildCommand6(t *testing.T) {
+	const dockerfilePath = "Dockerfile"
+	const buildContext = "."
+	const imageName = "ghcr.io/test-image"
+	const cacheTag = "latest-cache"
+
+	imageNameWithCacheTag := imageName + ":" + cacheTag
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"docker",
+			"buildx",
+			"build",
+			"-f",
+			dockerfilePath,
+			"--load",
+			"--cache-to",
+			"type=inline",
+			"--cache-from",
+			imageNameWithCacheTag,
+			"--build-arg",
+			"GOPRIVATE=github.com/3lvia/*",
+			"--build-arg",
+			"VERSION=1.0.0",
+			"--secret",
+			"id=netrc,env=GO_NETRC",
+			"-t",
+			imageNameWithCacheTag,
+			buildContext,
+		},
+		" ",
+	)
+
+	actualCommand := buildImageCommand(
+		dockerfilePath,
+		buildContext,
+		imageName,
+		cacheTag,
+		[]string{},
+		&BuildImageCommandOptions{
+			BuildArgs:  []string{"GOPRIVATE=github.com/3lvia/*", "VERSION=1.0.0"},
+			Secrets:    []BuildSecret{{ID: "netrc", Env: "GO_NETRC"}},
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -0,0 +1,204 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+const (
+	CacheBackendInline   = "inline"
+	CacheBackendRegistry = "registry"
+	CacheBackendGHA      = "gha"
+	CacheBackendLocal    = "local"
+	CacheBackendS3       = "s3"
+)
+
+var cacheBackends = []string{
+	CacheBackendInline,
+	CacheBackendRegistry,
+	CacheBackendGHA,
+	CacheBackendLocal,
+	CacheBackendS3,
+}
+
+// Builder created for cache backends the default docker driver can not export to.
+const cacheBuilderName = "3lv"
+
+type CacheOptions struct {
+	// One of inline, registry, gha, local or s3. Defaults to inline.
+	Backend string
+	// The image reference to store the registry cache in, defaults to the image with the buildcache tag.
+	Ref string
+	// The directory to store the local cache in, defaults to a directory in the user cache directory.
+	Directory  string
+	S3Bucket   string
+	S3Region   string
+	S3Endpoint string
+}
+
+func validateCacheBackend(backend string) error {
+	if backend != "" && !slices.Contains(cacheBackends, backend) {
+		return fmt.Errorf("Invalid cache backend %s: must be one of %v", backend, cacheBackends)
+	}
+
+	return nil
+}
+
+// cacheName returns a name for the cache of the image usable in paths and keys, e.g. ghcr.io-3lvia-core-demo-api.
+func cacheName(imageName string) string {
+	return strings.NewReplacer("/", "-", ":", "-").Replace(imageName)
+}
+
+// cacheArguments returns the --cache-from and --cache-to arguments of docker buildx build for the cache backend.
+// All backends except inline store the cache of every stage (mode=max), so that e.g. restoring packages is cached.
+func cacheArguments(imageName string, cacheTag string, options *CacheOptions) ([]string, error) {
+	if options == nil {
+		options = &CacheOptions{}
+	}
+
+	switch options.Backend {
+	case "", CacheBackendInline:
+		return []string{
+			"--cache-to",
+			"type=inline",
+			"--cache-from",
+			imageName + ":" + cacheTag,
+		}, nil
+
+	case CacheBackendRegistry:
+		ref := options.Ref
+		if ref == "" {
+			ref = imageName + ":buildcache"
+		}
+
+		return []string{
+			"--cache-to",
+			"type=registry,ref=" + ref + ",mode=max,image-manifest=true,oci-mediatypes=true",
+			"--cache-from",
+			"type=registry,ref=" + ref,
+		}, nil
+
+	case CacheBackendGHA:
+		scope := cacheName(imageName)
+
+		return []string{
+			"--cache-to",
+			"type=gha,scope=" + scope + ",mode=max",
+			"--cache-from",
+			"type=gha,scope=" + scope,
+		}, nil
+
+	case CacheBackendLocal:
+		directory := options.Directory
+		if directory == "" {
+			userCacheDirectory, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("Failed to find cache directory, use --cache-dir: %w", err)
+			}
+
+			directory = filepath.Join(userCacheDirectory, "3lv", "buildx", cacheName(imageName))
+		}
+
+		return []string{
+			"--cache-to",
+			"type=local,dest=" + directory + ",mode=max",
+			"--cache-from",
+			"type=local,src=" + directory,
+		}, nil
+
+	case CacheBackendS3:
+		if options.S3Bucket == "" {
+			return nil, fmt.Errorf("The s3 cache backend requires --cache-s3-bucket")
+		}
+
+		attributes := "type=s3,bucket=" + options.S3Bucket + ",name=" + cacheName(imageName)
+		if options.S3Region != "" {
+			attributes += ",region=" + options.S3Region
+		}
+		if options.S3Endpoint != "" {
+			// S3-compatible storage, e.g. MinIO, usually does not support virtual-hosted buckets.
+			attributes += ",endpoint_url=" + options.S3Endpoint + ",use_path_style=true"
+		}
+
+		return []string{
+			"--cache-to",
+			attributes + ",mode=max",
+			"--cache-from",
+			attributes,
+		}, nil
+
+	default:
+		return nil, validateCacheBackend(options.Backend)
+	}
+}
+
+// ensureCacheBuilder returns the builder to use for the cache backend. The default docker driver can only export the
+// inline cache, so a docker-container builder is created for other backends unless the current builder is one already.
+// Returns an empty string to use the current builder.
+func ensureCacheBuilder(backend string) (string, error) {
+	if backend == "" || backend == CacheBackendInline {
+		return "", nil
+	}
+
+	inspectOutput := dockerBuildxInspectCommand("", &command.RunOptions{Quiet: true})
+	if !command.IsError(inspectOutput) && buildxDriver(inspectOutput.Output) != "docker" {
+		return "", nil
+	}
+
+	if command.IsError(dockerBuildxInspectCommand(cacheBuilderName, &command.RunOptions{Quiet: true})) {
+		createOutput := dockerBuildxCreateCommand(cacheBuilderName, nil)
+		if command.IsError(createOutput) {
+			return "", fmt.Errorf("Failed to create builder for the %s cache backend: %w", backend, createOutput.Error)
+		}
+	}
+
+	return cacheBuilderName, nil
+}
+
+// buildxDriver returns the driver from the output of docker buildx inspect.
+func buildxDriver(inspectOutput string) string {
+	for _, line := range strings.Split(inspectOutput, "\n") {
+		if driver, found := strings.CutPrefix(strings.TrimSpace(line), "Driver:"); found {
+			return strings.TrimSpace(driver)
+		}
+	}
+
+	return ""
+}
+
+func dockerBuildxInspectCommand(
+	builder string,
+	options *command.RunOptions,
+) command.Output {
+	cmd := exec.Command("docker", "buildx", "inspect")
+	if builder != "" {
+		cmd.Args = append(cmd.Args, builder)
+	}
+
+	return command.Run(*cmd, options)
+}
+
+func dockerBuildxCreateCommand(
+	builder string,
+	options *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"docker",
+			"buildx",
+			"create",
+			"--name",
+			builder,
+			"--driver",
+			"docker-container",
+			"--bootstrap",
+		),
+		options,
+	)
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCacheArguments1(t *testing.T) {
+	const imageName = "ghcr.io/3lvia/core/demo-api"
+
+	expected := map[*CacheOptions][]string{
+		nil: {
+			"--cache-to", "type=inline",
+			"--cache-from", imageName + ":latest-cache",
+		},
+		{Backend: CacheBackendRegistry}: {
+			"--cache-to", "type=registry,ref=" + imageName + ":buildcache,mode=max,image-manifest=true,oci-mediatypes=true",
+			"--cache-from", "type=registry,ref=" + imageName + ":buildcache",
+		},
+		{Backend: CacheBackendRegistry, Ref: "ghcr.io/3lvia/cache:demo-api"}: {
+			"--cache-to", "type=registry,ref=ghcr.io/3lvia/cache:demo-api,mode=max,image-manifest=true,oci-mediatypes=true",
+			"--cache-from", "type=registry,ref=ghcr.io/3lvia/cache:demo-api",
+		},
+		{Backend: CacheBackendGHA}: {
+			"--cache-to", "type=gha,scope=ghcr.io-3lvia-core-demo-api,mode=max",
+			"--cache-from", "type=gha,scope=ghcr.io-3lvia-core-demo-api",
+		},
+		{Backend: CacheBackendLocal, Directory: "/tmp/cache"}: {
+			"--cache-to", "type=local,dest=/tmp/cache,mode=max",
+			"--cache-from", "type=local,src=/tmp/cache",
+		},
+		{Backend: CacheBackendS3, S3Bucket: "buildcache", S3Region: "eu-north-1"}: {
+			"--cache-to", "type=s3,bucket=buildcache,name=ghcr.io-3lvia-core-demo-api,region=eu-north-1,mode=max",
+			"--cache-from", "type=s3,bucket=buildcache,name=ghcr.io-3lvia-core-demo-api,region=eu-north-1",
+		},
+		{Backend: CacheBackendS3, S3Bucket: "buildcache", S3Endpoint: "http://localhost:9000"}: {
+			"--cache-to", "type=s3,bucket=buildcache,name=ghcr.io-3lvia-core-demo-api,endpoint_url=http://localhost:9000,use_path_style=true,mode=max",
+			"--cache-from", "type=s3,bucket=buildcache,name=ghcr.io-3lvia-core-demo-api,endpoint_url=http://localhost:9000,use_path_style=true",
+		},
+	}
+
+	for options, expectedArguments := range expected {
+		actualArguments, err := cacheArguments(imageName, "latest-cache", options)
+		if err != nil {
+			t.Errorf("Expected no error, got %s", err)
+			continue
+		}
+
+		if !slices.Equal(actualArguments, expectedArguments) {
+			t.Errorf("Expected %s, got %s", strings.Join(expectedArguments, " "), strings.Join(actualArguments, " "))
+		}
+	}
+}
+
+func TestCacheArguments2(t *testing.T) {
+	for _, options := range []*CacheOptions{
+		{Backend: CacheBackendS3},
+		{Backend: "nfs"},
+	} {
+		if _, err := cacheArguments("ghcr.io/3lvia/core/demo-api", "latest-cache", options); err == nil {
+			t.Errorf("Expected error for %+v, got nil", options)
+		}
+	}
+}
+
+func TestCacheArguments3(t *testing.T) {
+	actualArguments, err := cacheArguments("ghcr.io/3lvia/core/demo-api", "latest-cache", &CacheOptions{Backend: CacheBackendLocal})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if !strings.HasSuffix(actualArguments[1], "ghcr.io-3lvia-core-demo-api,mode=max") {
+		t.Errorf("Expected local cache directory named after the image, got %s", actualArguments[1])
+	}
+}
+
+func TestBuildxDriver(t *testing.T) {
+	const inspectOutput = `Name:          default
+Driver:        docker
+Last Activity: 2026-10-19 08:12:44 +0000 UTC
+
+Nodes:
+Name:      default
+Endpoint:  default
+Status:    running
+`
+
+	if driver := buildxDriver(inspectOutput); driver != "docker" {
+		t.Errorf("Expected %s, got %s", "docker", driver)
+	}
+
+	if driver := buildxDriver(strings.Replace(inspectOutput, "docker\n", "docker-container\n", 1)); driver != "docker-container" {
+		t.Errorf("Expected %s, got %s", "docker-container", driver)
+	}
+}
@@ -0,0 +1,64 @@
+package build
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/3lvia/cli/pkg/registry"
+)
+
+// resolvePushedDigest returns the digest of the image pushed with the tag. Unlike the tag, the digest always refers to the same image.
+func resolvePushedDigest(
+	imageName string,
+	tag string,
+	credentials registry.CredentialsFunc,
+) (string, error) {
+	reference, err := registry.ParseReference(imageName + ":" + tag)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := registry.NewClient(credentials).HeadManifest(reference)
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve digest of pushed image %s: %w", reference, err)
+	}
+
+	return digest, nil
+}
+
+// writeBuildOutputs writes the digest to the digest file, and as outputs of the step when running in GitHub Actions.
+// Either file is skipped when its path is empty.
+func writeBuildOutputs(
+	imageName string,
+	digest string,
+	digestFile string,
+	githubOutputFile string,
+) error {
+	if digestFile != "" {
+		if err := os.WriteFile(digestFile, []byte(digest+"\n"), 0644); err != nil {
+			return fmt.Errorf("Failed to write digest file: %w", err)
+		}
+	}
+
+	if githubOutputFile != "" {
+		file, err := os.OpenFile(githubOutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("Failed to open GitHub Actions output file: %w", err)
+		}
+		defer file.Close()
+
+		_, err = fmt.Fprintf(
+			file,
+			"image-name=%s\nimage-digest=%s\nimage=%s@%s\n",
+			imageName,
+			digest,
+			imageName,
+			digest,
+		)
+		if err != nil {
+			return fmt.Errorf("Failed to write GitHub Actions outputs: %w", err)
+		}
+	}
+
+	return nil
+}
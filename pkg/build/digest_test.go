@@ -0,0 +1,94 @@
+package build
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/3lvia/cli/pkg/registry"
+)
+
+const testDigest = "sha256:4c1e1f8b2b1f0c4c0e1a3f4e8d5c5e2b6a9d1f3b7c2e8a4d6f0b1c3e5a7d9f2b"
+
+func noCredentials(host string) (*registry.Credentials, error) {
+	return nil, nil
+}
+
+func TestResolvePushedDigest1(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/v2/core/demo-api/manifests/latest-cache" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Docker-Content-Digest", testDigest)
+		w.Header().Set("Content-Type", registry.MediaTypeDockerManifest)
+	}))
+	defer server.Close()
+
+	imageName := strings.TrimPrefix(server.URL, "http://") + "/core/demo-api"
+
+	digest, err := resolvePushedDigest(imageName, "latest-cache", noCredentials)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if digest != testDigest {
+		t.Errorf("Expected %s, got %s", testDigest, digest)
+	}
+}
+
+func TestResolvePushedDigest2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	imageName := strings.TrimPrefix(server.URL, "http://") + "/core/demo-api"
+
+	if _, err := resolvePushedDigest(imageName, "latest-cache", noCredentials); err == nil {
+		t.Errorf("Expected error for missing image, got nil")
+	}
+}
+
+func TestWriteBuildOutputs(t *testing.T) {
+	directory := t.TempDir()
+	digestFile := filepath.Join(directory, "digest")
+	githubOutputFile := filepath.Join(directory, "github-output")
+
+	// GitHub Actions outputs are appended to the file, which may contain outputs of earlier commands.
+	if err := os.WriteFile(githubOutputFile, []byte("other=value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const imageName = "ghcr.io/3lvia/core/demo-api"
+
+	if err := writeBuildOutputs(imageName, testDigest, digestFile, githubOutputFile); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDigest, err := os.ReadFile(digestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actualDigest) != testDigest+"\n" {
+		t.Errorf("Expected %s, got %s", testDigest, actualDigest)
+	}
+
+	actualOutputs, err := os.ReadFile(githubOutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutputs := "other=value\n" +
+		"image-name=" + imageName + "\n" +
+		"image-digest=" + testDigest + "\n" +
+		"image=" + imageName + "@" + testDigest + "\n"
+	if string(actualOutputs) != expectedOutputs {
+		t.Errorf("Expected %s, got %s", expectedOutputs, actualOutputs)
+	}
+}
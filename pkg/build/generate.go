@@ -3,14 +3,15 @@ package build
 import (
 	"bytes"
 	"embed"
-	"encoding/xml"
+	"encoding/json"
 	"fmt"
-	"html/template"
-	"io"
+	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"text/template"
 )
 
 //go:embed *.tmpl*
@@ -21,6 +22,12 @@ type GenerateDockerfileOptions struct {
 	BuildContext           string
 	IncludeFiles           []string
 	IncludeDirectories     []string
+	// IDs of the secrets given to the build, mounted by the generated Dockerfile where it knows how to use them.
+	SecretIDs []string
+	// Set when the GOPRIVATE build argument is given.
+	GoPrivate bool
+	// Name or path of the project to build when the project file is a solution.
+	Project string
 }
 
 func generateDockerfile(
@@ -33,6 +40,19 @@ func generateDockerfile(
 		return "", "", fmt.Errorf("Failed to create temporary directory: %s", err)
 	}
 
+	if isSolutionFile(projectFile) {
+		selectedProjectFile, err := selectSolutionProject(projectFile, options.Project, applicationName)
+		if err != nil {
+			return "", "", err
+		}
+
+		if options.BuildContext == "" {
+			options.BuildContext = filepath.Dir(projectFile)
+		}
+
+		projectFile = selectedProjectFile
+	}
+
 	if strings.HasSuffix(projectFile, ".csproj") {
 		dockerfile, buildContext, err := generateDockerfileForDotNet(
 			projectFile,
@@ -75,6 +95,25 @@ type DockerfileVariablesDotnet struct {
 	AssemblyName     string // required
 	BaseImageTag     string // required
 	RuntimeBaseImage string // required
+	// Restore with the NuGet.config mounted as the nugetconfig secret.
+	NuGetConfigSecret bool
+	// Where the NuGet.config is copied to when restoring with credentials, relative to the build context. NuGet resolves
+	// relative local package sources against the directory of the config, so it is placed next to the nuget.config
+	// of the project, or in the build context when there is none.
+	NuGetTemporaryConfigFile string
+	// Restore with the nuget.config of the project, relative to the build context, authenticating with the
+	// private package sources using the nugettoken secret.
+	NuGetConfigFile string
+	NuGetSources    []string
+	// Set when the project targets several frameworks.
+	Framework string
+	// Files needed to restore the project, copied before the rest of the build context so that restoring is cached.
+	RestoreFiles []DockerfileCopy
+}
+
+type DockerfileCopy struct {
+	Source      string
+	Destination string
 }
 
 func generateDockerfileForDotNet(
@@ -82,40 +121,101 @@ func generateDockerfileForDotNet(
 	directory string,
 	options GenerateDockerfileOptions,
 ) (string, string, error) {
-	csprojFileName, buildContext := getProjectFileAndBuildContext(
-		projectFile,
-		options.BuildContext,
-	)
+	project, err := evaluateDotNetProject(projectFile)
+	if err != nil {
+		return "", "", err
+	}
 
-	assemblyName, err := findAssemblyName(
-		projectFile,
-		csprojFileName,
-	)
+	targetFramework, multipleTargetFrameworks, err := project.targetFramework()
+	if err != nil {
+		return "", "", err
+	}
+
+	version, err := dotnetVersion(targetFramework)
 	if err != nil {
 		return "", "", err
 	}
 
-	baseImageTag, err := findBaseImageTag(projectFile)
+	runtimeBaseImage, err := project.runtimeBaseImage()
 	if err != nil {
 		return "", "", err
 	}
 
-	runtimeBaseImage, err := findRuntimeBaseImage(projectFile)
+	restoreFiles, err := project.restoreFiles()
 	if err != nil {
 		return "", "", err
 	}
 
+	buildContext, err := dotnetBuildContext(restoreFiles, options.BuildContext)
+	if err != nil {
+		return "", "", err
+	}
+
+	buildContextPath, err := filepath.Abs(buildContext)
+	if err != nil {
+		return "", "", err
+	}
+
+	csprojFileName, err := filepath.Rel(buildContextPath, project.Path)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The nuget.config is needed to restore from its package sources, also without credentials for them.
+	nugetConfigFile, sources, err := findPrivateNuGetSources(projectFile, buildContext)
+	if err != nil {
+		return "", "", err
+	}
+
+	if nugetConfigFile != "" {
+		nugetConfigPath := filepath.Join(buildContextPath, filepath.FromSlash(nugetConfigFile))
+		restoreFiles = append(restoreFiles, nugetConfigPath)
+
+		// Packages in local package sources, e.g. ./packages, are restored from the build context.
+		localSources, err := localNuGetSources(nugetConfigPath)
+		if err != nil {
+			return "", "", err
+		}
+
+		restoreFiles = append(restoreFiles, localSources...)
+	}
+
+	dockerfileVariables := DockerfileVariablesDotnet{
+		CsprojFile:        filepath.ToSlash(csprojFileName),
+		AssemblyName:      project.assemblyName(),
+		BaseImageTag:      version + "-alpine",
+		RuntimeBaseImage:  runtimeBaseImage,
+		NuGetConfigSecret: slices.Contains(options.SecretIDs, nugetConfigSecretID),
+		// The nuget.config of the project is empty when there is none, placing the temporary config in the build context.
+		NuGetTemporaryConfigFile: path.Join(path.Dir(nugetConfigFile), nugetTemporaryConfigFileName),
+		RestoreFiles:             restoreFileCopies(restoreFiles, buildContextPath),
+	}
+
+	if multipleTargetFrameworks {
+		dockerfileVariables.Framework = targetFramework
+	}
+
+	if !dockerfileVariables.NuGetConfigSecret {
+		if len(sources) > 0 && slices.Contains(options.SecretIDs, nugetTokenSecretID) {
+			dockerfileVariables.NuGetConfigFile = nugetConfigFile
+			dockerfileVariables.NuGetSources = sources
+		} else if len(sources) > 0 {
+			log.Printf(
+				"WARNING: %s has private package sources %s, but no credentials for them were given. Set %s or pass --secret id=%s,env=<variable>.\n",
+				nugetConfigFile,
+				strings.Join(sources, ", "),
+				nugetTokenEnvironmentVariable,
+				nugetTokenSecretID,
+			)
+		}
+	}
+
 	const templateFile = "Dockerfile.dotnet.tmpl"
 
 	dockerfilePath, err := writeDockerfile(
 		directory,
 		templateFile,
-		DockerfileVariablesDotnet{
-			CsprojFile:       csprojFileName,
-			AssemblyName:     assemblyName,
-			BaseImageTag:     baseImageTag,
-			RuntimeBaseImage: runtimeBaseImage,
-		},
+		dockerfileVariables,
 	)
 	if err != nil {
 		return "", "", err
@@ -124,12 +224,30 @@ func generateDockerfileForDotNet(
 	return dockerfilePath, buildContext, nil
 }
 
+// findPrivateNuGetSources returns the nuget.config of the project relative to the build context, and its private package sources.
+func findPrivateNuGetSources(projectFile string, buildContext string) (string, []string, error) {
+	nugetConfigFile, err := findNuGetConfig(projectFile, buildContext)
+	if err != nil || nugetConfigFile == "" {
+		return "", nil, err
+	}
+
+	sources, err := privateNuGetSources(filepath.Join(buildContext, nugetConfigFile))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return filepath.ToSlash(nugetConfigFile), sources, nil
+}
+
 type DockerfileVariablesGo struct {
 	ModuleDirectory      string // required
 	BuildContext         string // required
 	MainPackageDirectory string // required
 	IncludeFiles         []string
 	IncludeDirectories   []string
+	// Download private modules with GOPRIVATE from the build argument, and the .netrc mounted as the netrc secret.
+	GoPrivate   bool
+	NetrcSecret bool
 }
 
 func generateDockerfileForGo(
@@ -157,6 +275,8 @@ func generateDockerfileForGo(
 		MainPackageDirectory: mainPackageDirectory,
 		IncludeFiles:         options.IncludeFiles,
 		IncludeDirectories:   options.IncludeDirectories,
+		GoPrivate:            options.GoPrivate,
+		NetrcSecret:          slices.Contains(options.SecretIDs, netrcSecretID),
 	}
 
 	const templateFile = "Dockerfile.go.tmpl"
@@ -181,7 +301,10 @@ func writeDockerfile(
 
 	defer dockerfile.Close()
 
-	dockerfileTemplate, err := template.New(templateFile).ParseFS(templates, templateFile)
+	// Dockerfiles are not HTML, so values are quoted where needed by the templates instead of being HTML-escaped.
+	dockerfileTemplate, err := template.New(templateFile).
+		Funcs(template.FuncMap{"shellQuote": shellQuote, "json": jsonString}).
+		ParseFS(templates, templateFile)
 	if err != nil {
 		return "", fmt.Errorf("Failed to parse Dockerfile template: %s", err)
 	}
@@ -199,102 +322,101 @@ func writeDockerfile(
 	return dockerfilePath, nil
 }
 
-type CSharpProjectFile struct {
-	XMLName       xml.Name `xml:"Project"`
-	SDK           string   `xml:"Sdk,attr"`
-	PropertyGroup PropertyGroup
+// shellQuote quotes the value as a single word in the shell of RUN instructions.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
 }
 
-type PropertyGroup struct {
-	AssemblyName    string
-	TargetFramework string
+// jsonString quotes the value as a JSON string, used in the JSON form of instructions, e.g. COPY ["src", "dest/"],
+// which allows paths with spaces.
+func jsonString(value string) (string, error) {
+	quoted, err := json.Marshal(value)
+	return string(quoted), err
 }
 
-func getXMLFromFile(fileName string) (*CSharpProjectFile, error) {
-	file, err := os.Open(fileName)
+// dotnetBuildContext returns the build context, defaulting to the closest directory containing all files needed to
+// restore the project, e.g. the projects it references. Project references outside of a given build context are errors,
+// while props files outside of it are left out.
+func dotnetBuildContext(restoreFiles []string, buildContext string) (string, error) {
+	workingDirectory, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("getXMLFromFile: Failed to open file: %s", err)
+		return "", err
 	}
 
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("getXMLFromFile: Failed to read file: %s", err)
-	}
+	if buildContext == "" {
+		directory := filepath.Dir(restoreFiles[0])
+		for _, file := range restoreFiles[1:] {
+			// Props files above the working directory are not part of the repository.
+			if !isProjectFile(file) && !isWithinDirectory(file, workingDirectory) {
+				continue
+			}
+
+			for !isWithinDirectory(file, directory) {
+				directory = filepath.Dir(directory)
+			}
+		}
 
-	var project CSharpProjectFile
-	err = xml.Unmarshal(bytes, &project)
-	if err != nil {
-		return nil, fmt.Errorf("getXMLFromFile: Failed to unmarshal file: %s", err)
+		return filepath.Rel(workingDirectory, directory)
 	}
 
-	return &project, nil
-}
-
-func findAssemblyName(
-	csprojFileRelativePath string,
-	csprojFileName string,
-) (string, error) {
-	var assemblyName string
-	csprojXml, err := getXMLFromFile(csprojFileRelativePath)
+	buildContextPath, err := filepath.Abs(buildContext)
 	if err != nil {
 		return "", err
 	}
 
-	assemblyName = csprojXml.PropertyGroup.AssemblyName
-
-	if len(assemblyName) == 0 {
-		basename := filepath.Base(csprojFileName)
-		withoutExtension := strings.TrimSuffix(basename, filepath.Ext(basename))
-
-		return withoutExtension + ".dll", nil
+	for _, file := range restoreFiles {
+		if !isWithinDirectory(file, buildContextPath) && isProjectFile(file) {
+			return "", fmt.Errorf(
+				"Project %s is outside of the build context %s, use --build-context to include it",
+				file,
+				buildContext,
+			)
+		}
 	}
 
-	return assemblyName + ".dll", nil
+	return buildContext, nil
 }
 
-func findBaseImageTag(csprojFileRelativePath string) (string, error) {
-	csprojXml, err := getXMLFromFile(csprojFileRelativePath)
-	if err != nil {
-		return "", err
-	}
+func isProjectFile(file string) bool {
+	return strings.HasSuffix(file, ".csproj")
+}
 
-	targetFramework := csprojXml.PropertyGroup.TargetFramework
-	if len(targetFramework) == 0 {
-		return "", fmt.Errorf(
-			"findBaseImageTag: TargetFramework not found in csproj file: %s",
-			csprojFileRelativePath,
-		)
-	}
+func isWithinDirectory(file string, directory string) bool {
+	relativePath, err := filepath.Rel(directory, file)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
 
-	return targetFramework[3:] + "-alpine", nil
+// restoreFileCopies returns the COPY instructions of the files and directories needed to restore the project, keeping
+// the directory structure of the build context so that project references, imports and local package sources resolve.
+func restoreFileCopies(restoreFiles []string, buildContextPath string) []DockerfileCopy {
+	var copies []DockerfileCopy
+	for _, file := range restoreFiles {
+		if !isWithinDirectory(file, buildContextPath) {
+			continue
+		}
 
-}
+		relativePath, err := filepath.Rel(buildContextPath, file)
+		if err != nil {
+			continue
+		}
 
-func findRuntimeBaseImage(csprojFileRelativePath string) (string, error) {
-	csprojXml, err := getXMLFromFile(csprojFileRelativePath)
-	if err != nil {
-		return "", err
-	}
+		source := filepath.ToSlash(relativePath)
+		destination := "./"
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			// The contents of a directory are copied, not the directory itself.
+			destination = source + "/"
+		} else if directory := path.Dir(source); directory != "." {
+			destination = directory + "/"
+		}
 
-	sdk := csprojXml.SDK
-	if len(sdk) == 0 {
-		return "", fmt.Errorf(
-			"SDK not found in csproj file: %s",
-			csprojFileRelativePath,
-		)
+		copies = append(copies, DockerfileCopy{Source: source, Destination: destination})
 	}
 
-	switch sdk {
-	case "Microsoft.NET.Sdk":
-		return "mcr.microsoft.com/dotnet/runtime", nil
-	case "Microsoft.NET.Sdk.Web",
-		"Microsoft.NET.Sdk.BlazorWebAssembly",
-		"Microsoft.NET.Sdk.Razor",
-		"Microsoft.NET.Sdk.Worker":
-		return "mcr.microsoft.com/dotnet/aspnet", nil
-	default:
-		return "", fmt.Errorf("Unknown SDK: %s", sdk)
-	}
+	slices.SortFunc(copies, func(a, b DockerfileCopy) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+
+	return copies
 }
 
 func getProjectFileAndBuildContext(
@@ -2,7 +2,6 @@ package build
 
 import (
 	"os"
-	"path/filepath"
 	"testing"
 )
 
@@ -96,54 +95,52 @@ func TestGetProjectFileAndBuildContext5(t *testing.T) {
 	}
 }
 
-func TestFindAssemblyName1(t *testing.T) {
-	const projectFile = "_test/no-assembly-name.csproj"
+func TestAssemblyName1(t *testing.T) {
 	const expectedAssemblyName = "no-assembly-name.dll"
 
-	actualAssemblyName, err := findAssemblyName(
-		projectFile,
-		filepath.Base(projectFile),
-	)
+	project, err := evaluateDotNetProject("_test/no-assembly-name.csproj")
 	if err != nil {
-		t.Errorf("Error finding assembly name: %v", err)
+		t.Fatalf("Expected no error, got %s", err)
 	}
 
-	if expectedAssemblyName != actualAssemblyName {
+	if actualAssemblyName := project.assemblyName(); expectedAssemblyName != actualAssemblyName {
 		t.Errorf("Assembly name mismatch: expected %s, got %s", expectedAssemblyName, actualAssemblyName)
 	}
 }
 
-func TestFindAssemblyName2(t *testing.T) {
-	const projectFile = "_test/assembly-name.csproj"
+func TestAssemblyName2(t *testing.T) {
 	const expectedAssemblyName = "SelfDefinedAssemblyName.dll"
 
-	actualAssemblyName, err := findAssemblyName(
-		projectFile,
-		filepath.Base(projectFile),
-	)
+	project, err := evaluateDotNetProject("_test/assembly-name.csproj")
 	if err != nil {
-		t.Errorf("Error finding assembly name: %v", err)
+		t.Fatalf("Expected no error, got %s", err)
 	}
 
-	if expectedAssemblyName != actualAssemblyName {
+	if actualAssemblyName := project.assemblyName(); expectedAssemblyName != actualAssemblyName {
 		t.Errorf("Assembly name mismatch: expected %s, got %s", expectedAssemblyName, actualAssemblyName)
 	}
 }
 
-func TestFindRuntimeBaseImage1(t *testing.T) {
-	const projectFile = "_test/no-sdk.csproj"
+func TestRuntimeBaseImage1(t *testing.T) {
+	project, err := evaluateDotNetProject("_test/no-sdk.csproj")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
 
-	_, err := findRuntimeBaseImage(projectFile)
-	if err == nil {
+	if _, err := project.runtimeBaseImage(); err == nil {
 		t.Errorf("Expected error finding runtime base image")
 	}
 }
 
-func TestFindRuntimeBaseImage2(t *testing.T) {
-	const projectFile = "_test/dotnet-runtime.csproj"
+func TestRuntimeBaseImage2(t *testing.T) {
 	const expectedBaseImage = "mcr.microsoft.com/dotnet/runtime"
 
-	actualBaseImage, err := findRuntimeBaseImage(projectFile)
+	project, err := evaluateDotNetProject("_test/dotnet-runtime.csproj")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualBaseImage, err := project.runtimeBaseImage()
 	if err != nil {
 		t.Errorf("Error finding runtime base image: %v", err)
 	}
@@ -153,7 +150,7 @@ func TestFindRuntimeBaseImage2(t *testing.T) {
 	}
 }
 
-func TestFindRuntimeBaseImage3(t *testing.T) {
+func TestRuntimeBaseImage3(t *testing.T) {
 	const expectedBaseImage = "mcr.microsoft.com/dotnet/aspnet"
 	projectFiles := []string{
 		"_test/dotnet-aspnet-web.csproj",
@@ -163,7 +160,12 @@ func TestFindRuntimeBaseImage3(t *testing.T) {
 	}
 
 	for _, projectFile := range projectFiles {
-		actualBaseImage, err := findRuntimeBaseImage(projectFile)
+		project, err := evaluateDotNetProject(projectFile)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+
+		actualBaseImage, err := project.runtimeBaseImage()
 		if err != nil {
 			t.Errorf("Error finding runtime base image: %v", err)
 		}
@@ -174,43 +176,47 @@ func TestFindRuntimeBaseImage3(t *testing.T) {
 	}
 }
 
-func TestFindBaseImageTag1(t *testing.T) {
-	const projectFile = "_test/this-does-not-exists.csproj"
-
-	_, err := findBaseImageTag(projectFile)
-	if err == nil {
-		t.Errorf("Expected error finding base image tag")
+func TestEvaluateDotNetProject3(t *testing.T) {
+	if _, err := evaluateDotNetProject("_test/this-does-not-exists.csproj"); err == nil {
+		t.Errorf("Expected error evaluating project")
 	}
 }
 
-func TestFindBaseImageTag2(t *testing.T) {
-	const projectFile = "_test/no-target-framework.csproj"
+func TestTargetFramework3(t *testing.T) {
+	project := &dotnetProject{Properties: map[string]string{}}
 
-	_, err := findBaseImageTag(projectFile)
-	if err == nil {
-		t.Errorf("Expected error finding base image tag")
+	if _, _, err := project.targetFramework(); err == nil {
+		t.Errorf("Expected error for project without target framework")
 	}
 }
 
-func TestFindBaseImageTag3(t *testing.T) {
+func TestTargetFramework4(t *testing.T) {
 	dotnetFrameworkVersions := []string{
 		"6.0",
 		"7.0",
 		"8.0",
 		"9.0",
+		"10.0",
 	}
 
 	for _, frameworkVersion := range dotnetFrameworkVersions {
-		projectFile := "_test/dotnet-" + frameworkVersion + ".csproj"
-		expectedBaseImageTag := frameworkVersion + "-alpine"
+		project, err := evaluateDotNetProject("_test/dotnet-" + frameworkVersion + ".csproj")
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+
+		targetFramework, multipleTargetFrameworks, err := project.targetFramework()
+		if err != nil {
+			t.Errorf("Error finding target framework: %v", err)
+		}
 
-		actualBaseImageTag, err := findBaseImageTag(projectFile)
+		actualVersion, err := dotnetVersion(targetFramework)
 		if err != nil {
-			t.Errorf("Error finding base image tag: %v", err)
+			t.Errorf("Error finding .NET version: %v", err)
 		}
 
-		if expectedBaseImageTag != actualBaseImageTag {
-			t.Errorf("Base image tag mismatch: expected %s, got %s", expectedBaseImageTag, actualBaseImageTag)
+		if frameworkVersion != actualVersion || multipleTargetFrameworks {
+			t.Errorf("Version mismatch: expected %s, got %s, %t", frameworkVersion, actualVersion, multipleTargetFrameworks)
 		}
 	}
 }
@@ -248,6 +254,61 @@ func TestGenerateGoDockerfile1(t *testing.T) {
 	}
 }
 
+func TestGenerateGoDockerfile2(t *testing.T) {
+	expectedDockerfile, err := os.ReadFile("_test/Dockerfile.test2")
+	if err != nil {
+		t.Errorf("Error reading file: %v", err)
+	}
+
+	actualDockerfilePath, _, err := generateDockerfile(
+		"go.mod",
+		"demo-api",
+		GenerateDockerfileOptions{SecretIDs: []string{"netrc"}},
+	)
+	if err != nil {
+		t.Errorf("Error generating Dockerfile: %v", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Errorf("Error reading file: %v", err)
+	}
+
+	if string(expectedDockerfile) != string(actualDockerfile) {
+		t.Errorf("Dockerfile mismatch: expected %s, got %s", expectedDockerfile, actualDockerfile)
+	}
+}
+
+func TestGenerateDotNetDockerfile1(t *testing.T) {
+	expectedDockerfile, err := os.ReadFile("_test/Dockerfile.test3")
+	if err != nil {
+		t.Errorf("Error reading file: %v", err)
+	}
+	const expectedBuildContext = "_test"
+
+	actualDockerfilePath, actualBuildContext, err := generateDockerfile(
+		"_test/dotnet-8.0.csproj",
+		"demo-api",
+		GenerateDockerfileOptions{SecretIDs: []string{"other", "nugetconfig"}},
+	)
+	if err != nil {
+		t.Errorf("Error generating Dockerfile: %v", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Errorf("Error reading file: %v", err)
+	}
+
+	if string(expectedDockerfile) != string(actualDockerfile) {
+		t.Errorf("Dockerfile mismatch: expected %s, got %s", expectedDockerfile, actualDockerfile)
+	}
+
+	if expectedBuildContext != actualBuildContext {
+		t.Errorf("Build context mismatch: expected %s, got %s", expectedBuildContext, actualBuildContext)
+	}
+}
+
 func TestGenerateDockerfileWithDockerfile1(t *testing.T) {
 	const projectFile = "Dockerfile.test" // doesn't need to exist
 	const expectedBuildContext = "."
@@ -346,3 +407,18 @@ func TestDotIfEmpty3(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, actual)
 	}
 }
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"github":    "'github'",
+		"R&D feed":  "'R&D feed'",
+		"elvia's":   `'elvia'\''s'`,
+		"$(whoami)": "'$(whoami)'",
+	}
+
+	for value, expected := range tests {
+		if actual := shellQuote(value); expected != actual {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package build
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// msbuildElement is any element of an MSBuild file, keeping the order of the children, which properties depend on.
+type msbuildElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr       `xml:",any,attr"`
+	Value    string           `xml:",chardata"`
+	Children []msbuildElement `xml:",any"`
+}
+
+func (e msbuildElement) attribute(name string) string {
+	for _, attribute := range e.Attrs {
+		if strings.EqualFold(attribute.Name.Local, name) {
+			return attribute.Value
+		}
+	}
+
+	return ""
+}
+
+// dotnetProject is a .NET project evaluated like MSBuild does, limited to what is needed to generate a Dockerfile:
+// properties from all property groups and imported props files, and project references.
+type dotnetProject struct {
+	// Absolute path of the project file.
+	Path       string
+	SDK        string
+	Properties map[string]string
+	// Absolute paths of the referenced project files.
+	ProjectReferences []string
+	// Absolute paths of the props files imported when evaluating the project, e.g. Directory.Build.props.
+	Imports []string
+}
+
+var (
+	propertyReferencePattern  = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_.-]*)\)`)
+	existsConditionPattern    = regexp.MustCompile(`(?i)^!?\s*exists\(\s*'([^']*)'\s*\)$`)
+	getPathOfFileAbovePattern = regexp.MustCompile(
+		`(?i)\$\(\[MSBuild\]::GetPathOfFileAbove\(\s*'?([^',)]+)'?`,
+	)
+	targetFrameworkPattern = regexp.MustCompile(`^net(?:coreapp)?(\d+)\.(\d+)$`)
+)
+
+func readMSBuildFile(path string) (msbuildElement, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return msbuildElement{}, fmt.Errorf("Failed to read %s: %w", path, err)
+	}
+
+	var root msbuildElement
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return msbuildElement{}, fmt.Errorf("Failed to parse %s: %w", path, err)
+	}
+
+	return root, nil
+}
+
+// evaluateDotNetProject evaluates the project file, with the Directory.Build.props above it imported first like the SDK does.
+func evaluateDotNetProject(projectFile string) (*dotnetProject, error) {
+	path, err := filepath.Abs(projectFile)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := readMSBuildFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.XMLName.Local != "Project" {
+		return nil, fmt.Errorf("%s is not an MSBuild project file", projectFile)
+	}
+
+	project := &dotnetProject{
+		Path: path,
+		SDK:  projectSDK(root),
+		Properties: map[string]string{
+			"Configuration":           "Release",
+			"MSBuildProjectName":      strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			"MSBuildProjectDirectory": filepath.Dir(path),
+		},
+	}
+
+	if directoryBuildProps := findFileAbove(filepath.Dir(path), "Directory.Build.props"); directoryBuildProps != "" {
+		if err := project.importFile(directoryBuildProps); err != nil {
+			return nil, err
+		}
+	}
+
+	project.evaluate(root, filepath.Dir(path), true)
+
+	return project, nil
+}
+
+// projectSDK returns the SDK of the project, from the Sdk attribute or element, without version.
+func projectSDK(root msbuildElement) string {
+	sdk := root.attribute("Sdk")
+	if sdk == "" {
+		for _, child := range root.Children {
+			if child.XMLName.Local == "Sdk" {
+				sdk = child.attribute("Name")
+				break
+			}
+		}
+	}
+
+	sdk, _, _ = strings.Cut(strings.Split(sdk, ";")[0], "/")
+
+	return strings.TrimSpace(sdk)
+}
+
+func (p *dotnetProject) importFile(path string) error {
+	// Props files importing each other, e.g. through GetPathOfFileAbove, must not loop.
+	if slices.Contains(p.Imports, path) {
+		return nil
+	}
+	p.Imports = append(p.Imports, path)
+
+	root, err := readMSBuildFile(path)
+	if err != nil {
+		return err
+	}
+
+	// MSBuildThisFileDirectory is the directory of the imported file only while evaluating it.
+	importingFileDirectory := p.Properties["MSBuildThisFileDirectory"]
+	p.evaluate(root, filepath.Dir(path), false)
+	p.Properties["MSBuildThisFileDirectory"] = importingFileDirectory
+
+	return nil
+}
+
+// evaluate sets the properties of the file in document order, following imports.
+// Project references are only read from the project file itself.
+func (p *dotnetProject) evaluate(root msbuildElement, directory string, isProjectFile bool) {
+	p.Properties["MSBuildThisFileDirectory"] = directory + string(filepath.Separator)
+
+	for _, element := range root.Children {
+		if !p.evaluateCondition(element.attribute("Condition"), directory) {
+			continue
+		}
+
+		switch element.XMLName.Local {
+		case "PropertyGroup":
+			for _, property := range element.Children {
+				if p.evaluateCondition(property.attribute("Condition"), directory) {
+					p.Properties[property.XMLName.Local] = strings.TrimSpace(p.expand(property.Value))
+				}
+			}
+
+		case "Import":
+			// SDK imports are part of the SDK, not of the repository.
+			if element.attribute("Sdk") != "" {
+				continue
+			}
+
+			if importPath := p.resolveImport(element.attribute("Project"), directory); importPath != "" {
+				// Missing or unreadable imports are skipped, like MSBuild does for conditional imports.
+				_ = p.importFile(importPath)
+			}
+
+		case "ItemGroup":
+			if !isProjectFile {
+				continue
+			}
+
+			for _, item := range element.Children {
+				if item.XMLName.Local != "ProjectReference" || !p.evaluateCondition(item.attribute("Condition"), directory) {
+					continue
+				}
+
+				for _, include := range strings.Split(p.expand(item.attribute("Include")), ";") {
+					include = strings.TrimSpace(strings.ReplaceAll(include, `\`, "/"))
+					if include == "" {
+						continue
+					}
+
+					reference := filepath.Clean(filepath.Join(directory, filepath.FromSlash(include)))
+					if !slices.Contains(p.ProjectReferences, reference) {
+						p.ProjectReferences = append(p.ProjectReferences, reference)
+					}
+				}
+			}
+		}
+	}
+}
+
+// resolveImport returns the absolute path of the imported file, or an empty string when it does not exist.
+func (p *dotnetProject) resolveImport(project string, directory string) string {
+	if match := getPathOfFileAbovePattern.FindStringSubmatch(project); match != nil {
+		// Usually used to import the Directory.Build.props of the parent directory.
+		start := directory
+		if strings.Contains(project, "../") || strings.Contains(project, `..\`) {
+			start = filepath.Dir(directory)
+		}
+
+		return findFileAbove(start, strings.TrimSpace(match[1]))
+	}
+
+	project = strings.ReplaceAll(p.expand(project), `\`, "/")
+	if project == "" || strings.Contains(project, "$(") {
+		return ""
+	}
+
+	path := filepath.FromSlash(project)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(directory, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+
+	return filepath.Clean(path)
+}
+
+// expand replaces property references, e.g. $(TargetFramework), with the values of the properties.
+// Undefined properties are empty, like in MSBuild.
+func (p *dotnetProject) expand(value string) string {
+	return propertyReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		return p.Properties[reference[2:len(reference)-1]]
+	})
+}
+
+// evaluateCondition evaluates the common conditions: comparisons, Exists, and combinations of them with and or or.
+// Other conditions are false.
+func (p *dotnetProject) evaluateCondition(condition string, directory string) bool {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return true
+	}
+
+	if parts := splitCondition(condition, " or "); len(parts) > 1 {
+		return slices.ContainsFunc(parts, func(part string) bool {
+			return p.evaluateCondition(part, directory)
+		})
+	}
+
+	if parts := splitCondition(condition, " and "); len(parts) > 1 {
+		for _, part := range parts {
+			if !p.evaluateCondition(part, directory) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	condition = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(condition, "("), ")"))
+	expanded := p.expand(condition)
+
+	if match := existsConditionPattern.FindStringSubmatch(expanded); match != nil {
+		path := filepath.FromSlash(strings.ReplaceAll(match[1], `\`, "/"))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(directory, path)
+		}
+
+		_, err := os.Stat(path)
+		return (err == nil) != strings.HasPrefix(expanded, "!")
+	}
+
+	for _, operator := range []string{"==", "!="} {
+		if left, right, found := strings.Cut(expanded, operator); found {
+			equal := strings.EqualFold(unquoteCondition(left), unquoteCondition(right))
+			return equal == (operator == "==")
+		}
+	}
+
+	return false
+}
+
+func splitCondition(condition string, separator string) []string {
+	var parts []string
+	lower := strings.ToLower(condition)
+
+	for {
+		index := strings.Index(lower, separator)
+		if index < 0 {
+			return append(parts, condition)
+		}
+
+		parts = append(parts, condition[:index])
+		condition = condition[index+len(separator):]
+		lower = lower[index+len(separator):]
+	}
+}
+
+func unquoteCondition(value string) string {
+	return strings.Trim(strings.TrimSpace(value), "'")
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// findFileAbove returns the path of the file in the directory or the closest parent directory, or an empty string.
+func findFileAbove(directory string, fileName string) string {
+	for {
+		path := filepath.Join(directory, fileName)
+		if fileExists(path) {
+			return path
+		}
+
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return ""
+		}
+
+		directory = parent
+	}
+}
+
+func (p *dotnetProject) assemblyName() string {
+	assemblyName := p.Properties["AssemblyName"]
+	if assemblyName == "" {
+		assemblyName = p.Properties["MSBuildProjectName"]
+	}
+
+	return assemblyName + ".dll"
+}
+
+// targetFramework returns the framework to publish, and whether the project targets several frameworks, in which case
+// the framework must be given when publishing. The newest of several target frameworks is used.
+func (p *dotnetProject) targetFramework() (string, bool, error) {
+	if targetFramework := p.Properties["TargetFramework"]; targetFramework != "" {
+		if _, err := dotnetVersion(targetFramework); err != nil {
+			return "", false, err
+		}
+
+		return targetFramework, false, nil
+	}
+
+	var newest string
+	var newestVersion [2]int
+	for _, targetFramework := range strings.Split(p.Properties["TargetFrameworks"], ";") {
+		targetFramework = strings.TrimSpace(targetFramework)
+		match := targetFrameworkPattern.FindStringSubmatch(targetFramework)
+		if match == nil {
+			// E.g. netstandard2.0 or net48, which can not run in the .NET base images.
+			continue
+		}
+
+		major, _ := strconv.Atoi(match[1])
+		minor, _ := strconv.Atoi(match[2])
+		if newest == "" || major > newestVersion[0] || (major == newestVersion[0] && minor > newestVersion[1]) {
+			newest = targetFramework
+			newestVersion = [2]int{major, minor}
+		}
+	}
+
+	if newest == "" {
+		return "", false, fmt.Errorf("TargetFramework not found in project file or Directory.Build.props: %s", p.Path)
+	}
+
+	return newest, true, nil
+}
+
+// dotnetVersion returns the .NET version of the target framework, e.g. 8.0 for net8.0 and 10.0 for net10.0.
+func dotnetVersion(targetFramework string) (string, error) {
+	match := targetFrameworkPattern.FindStringSubmatch(targetFramework)
+	if match == nil {
+		return "", fmt.Errorf("Unsupported target framework %s: expected e.g. net8.0", targetFramework)
+	}
+
+	return match[1] + "." + match[2], nil
+}
+
+func (p *dotnetProject) runtimeBaseImage() (string, error) {
+	switch p.SDK {
+	case "":
+		return "", fmt.Errorf("SDK not found in csproj file: %s", p.Path)
+	case "Microsoft.NET.Sdk":
+		return "mcr.microsoft.com/dotnet/runtime", nil
+	case "Microsoft.NET.Sdk.Web",
+		"Microsoft.NET.Sdk.BlazorWebAssembly",
+		"Microsoft.NET.Sdk.Razor",
+		"Microsoft.NET.Sdk.Worker":
+		return "mcr.microsoft.com/dotnet/aspnet", nil
+	default:
+		return "", fmt.Errorf("Unknown SDK: %s", p.SDK)
+	}
+}
+
+// restoreFiles returns the absolute paths of the files needed to restore the project: the project file, the project files
+// it references directly or indirectly, and the props, targets, lock and global.json files of each of them.
+func (p *dotnetProject) restoreFiles() ([]string, error) {
+	var files []string
+	add := func(file string) {
+		if file != "" && !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+
+	projects := []*dotnetProject{p}
+	evaluated := map[string]bool{p.Path: true}
+
+	for i := 0; i < len(projects); i++ {
+		project := projects[i]
+
+		add(project.Path)
+		for _, imported := range project.Imports {
+			add(imported)
+		}
+
+		directory := filepath.Dir(project.Path)
+		add(findFileAbove(directory, "Directory.Build.targets"))
+		add(findFileAbove(directory, "Directory.Packages.props"))
+		// global.json selects the SDK, and the lock file is required when restoring in locked mode.
+		add(findFileAbove(directory, "global.json"))
+		if lockFile := filepath.Join(directory, "packages.lock.json"); fileExists(lockFile) {
+			add(lockFile)
+		}
+
+		for _, reference := range project.ProjectReferences {
+			if evaluated[reference] {
+				continue
+			}
+			evaluated[reference] = true
+
+			referencedProject, err := evaluateDotNetProject(reference)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to evaluate project %s referenced by %s: %w", reference, project.Path, err)
+			}
+
+			projects = append(projects, referencedProject)
+		}
+	}
+
+	return files, nil
+}
+
+// solutionProjectPattern matches the projects of a .sln file, e.g.
+// Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "demo-api", "src\demo-api\demo-api.csproj", "{...}".
+var solutionProjectPattern = regexp.MustCompile(`(?m)^Project\("\{[^}]*\}"\)\s*=\s*"([^"]*)",\s*"([^"]*)"`)
+
+// solutionProjects returns the paths of the C# projects in the .sln or .slnx file, relative to the solution directory.
+func solutionProjects(solutionFile string) ([]string, error) {
+	content, err := os.ReadFile(solutionFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read solution file: %w", err)
+	}
+
+	var paths []string
+	if strings.HasSuffix(solutionFile, ".slnx") {
+		var root msbuildElement
+		if err := xml.Unmarshal(content, &root); err != nil {
+			return nil, fmt.Errorf("Failed to parse %s: %w", solutionFile, err)
+		}
+
+		var walk func(element msbuildElement)
+		walk = func(element msbuildElement) {
+			for _, child := range element.Children {
+				if child.XMLName.Local == "Project" {
+					paths = append(paths, child.attribute("Path"))
+				}
+				walk(child)
+			}
+		}
+		walk(root)
+	} else {
+		for _, match := range solutionProjectPattern.FindAllStringSubmatch(string(content), -1) {
+			paths = append(paths, match[2])
+		}
+	}
+
+	var projects []string
+	for _, path := range paths {
+		path = strings.ReplaceAll(path, `\`, "/")
+		if strings.HasSuffix(path, ".csproj") {
+			projects = append(projects, path)
+		}
+	}
+
+	return projects, nil
+}
+
+// selectSolutionProject returns the path of the project to build from the solution: the project given by name or path,
+// the only project, or the project named like the application.
+func selectSolutionProject(solutionFile string, project string, applicationName string) (string, error) {
+	projects, err := solutionProjects(solutionFile)
+	if err != nil {
+		return "", err
+	}
+
+	if len(projects) == 0 {
+		return "", fmt.Errorf("No C# projects found in solution %s", solutionFile)
+	}
+
+	matches := func(name string) []string {
+		var matching []string
+		for _, path := range projects {
+			projectName := strings.TrimSuffix(filepath.Base(path), ".csproj")
+			if strings.EqualFold(projectName, name) || strings.EqualFold(path, filepath.ToSlash(filepath.Clean(name))) {
+				matching = append(matching, path)
+			}
+		}
+
+		return matching
+	}
+
+	var selected []string
+	switch {
+	case project != "":
+		selected = matches(project)
+		if len(selected) == 0 {
+			return "", fmt.Errorf("Project %s not found in solution %s, must be one of: %s", project, solutionFile, strings.Join(projects, ", "))
+		}
+	case len(projects) == 1:
+		selected = projects
+	default:
+		selected = matches(applicationName)
+		if len(selected) == 0 {
+			return "", fmt.Errorf(
+				"Solution %s contains several projects, select one with --project: %s",
+				solutionFile,
+				strings.Join(projects, ", "),
+			)
+		}
+	}
+
+	if len(selected) > 1 {
+		return "", fmt.Errorf("Several projects in solution %s match %s, select one by path with --project: %s", solutionFile, project, strings.Join(selected, ", "))
+	}
+
+	return filepath.Join(filepath.Dir(solutionFile), filepath.FromSlash(selected[0])), nil
+}
+
+func isSolutionFile(projectFile string) bool {
+	return strings.HasSuffix(projectFile, ".sln") || strings.HasSuffix(projectFile, ".slnx")
+}
+
+func isDotNetProjectFile(projectFile string) bool {
+	return strings.HasSuffix(projectFile, ".csproj") || isSolutionFile(projectFile)
+}
@@ -0,0 +1,331 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateDotNetProject1(t *testing.T) {
+	project, err := evaluateDotNetProject("_test/solution/src/Demo.Api/Demo.Api.csproj")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expectedProperties := map[string]string{
+		// From Directory.Build.props.
+		"TargetFramework": "net10.0",
+		// From the property group with a condition on the configuration.
+		"TreatWarningsAsErrors": "true",
+		// From the second property group, referencing the first.
+		"AssemblyName": "Demo.Api.Service",
+	}
+
+	for name, expected := range expectedProperties {
+		if actual := project.Properties[name]; expected != actual {
+			t.Errorf("Expected %s to be %s, got %s", name, expected, actual)
+		}
+	}
+
+	if project.SDK != "Microsoft.NET.Sdk.Web" {
+		t.Errorf("Expected SDK Microsoft.NET.Sdk.Web, got %s", project.SDK)
+	}
+
+	expectedReference, err := filepath.Abs("_test/solution/src/Demo.Core/Demo.Core.csproj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(project.ProjectReferences, []string{expectedReference}) {
+		t.Errorf("Expected project references %v, got %v", []string{expectedReference}, project.ProjectReferences)
+	}
+}
+
+func TestEvaluateDotNetProject2(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "Directory.Build.props"), `<Project>
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+    <AssemblyName>Shared</AssemblyName>
+  </PropertyGroup>
+</Project>`)
+	writeTestFile(t, filepath.Join(directory, "src", "Directory.Build.props"), `<Project>
+  <Import Project="$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', '$(MSBuildThisFileDirectory)../'))" />
+  <PropertyGroup>
+    <TargetFramework Condition="'$(TargetFramework)' == ''">net6.0</TargetFramework>
+    <LangVersion>latest</LangVersion>
+  </PropertyGroup>
+</Project>`)
+	writeTestFile(t, filepath.Join(directory, "src", "demo-api", "demo-api.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web/10.0.100">
+  <PropertyGroup>
+    <AssemblyName Condition="'$(Configuration)' == 'Debug'">Debug</AssemblyName>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(TargetFramework)' == 'net8.0' and '$(LangVersion)' != ''">
+    <TargetFramework>net9.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+
+	project, err := evaluateDotNetProject(filepath.Join(directory, "src", "demo-api", "demo-api.csproj"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if project.Properties["TargetFramework"] != "net9.0" {
+		t.Errorf("Expected TargetFramework net9.0, got %s", project.Properties["TargetFramework"])
+	}
+
+	if project.assemblyName() != "Shared.dll" {
+		t.Errorf("Expected assembly name Shared.dll, got %s", project.assemblyName())
+	}
+
+	if project.SDK != "Microsoft.NET.Sdk.Web" {
+		t.Errorf("Expected SDK Microsoft.NET.Sdk.Web, got %s", project.SDK)
+	}
+
+	if len(project.Imports) != 2 {
+		t.Errorf("Expected both Directory.Build.props to be imported, got %v", project.Imports)
+	}
+}
+
+func TestTargetFramework1(t *testing.T) {
+	project := &dotnetProject{
+		Properties: map[string]string{"TargetFrameworks": "netstandard2.0;net8.0; net10.0 ;net9.0"},
+	}
+
+	targetFramework, multipleTargetFrameworks, err := project.targetFramework()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if targetFramework != "net10.0" || !multipleTargetFrameworks {
+		t.Errorf("Expected net10.0 with multiple target frameworks, got %s, %t", targetFramework, multipleTargetFrameworks)
+	}
+}
+
+func TestTargetFramework2(t *testing.T) {
+	project := &dotnetProject{
+		Properties: map[string]string{"TargetFrameworks": "netstandard2.0;net48"},
+	}
+
+	if _, _, err := project.targetFramework(); err == nil {
+		t.Errorf("Expected error for target frameworks without .NET")
+	}
+}
+
+func TestDotnetVersion(t *testing.T) {
+	tests := map[string]string{
+		"net8.0":        "8.0",
+		"net10.0":       "10.0",
+		"netcoreapp3.1": "3.1",
+		"net48":         "",
+		"netstandard2":  "",
+	}
+
+	for targetFramework, expected := range tests {
+		actual, err := dotnetVersion(targetFramework)
+		if expected == "" && err == nil {
+			t.Errorf("Expected error for %s", targetFramework)
+		}
+
+		if expected != actual {
+			t.Errorf("Expected %s for %s, got %s", expected, targetFramework, actual)
+		}
+	}
+}
+
+func TestSelectSolutionProject1(t *testing.T) {
+	const expected = "_test/solution/src/Demo.Api/Demo.Api.csproj"
+
+	for _, project := range []string{"Demo.Api", "demo.api", "src/Demo.Api/Demo.Api.csproj"} {
+		actual, err := selectSolutionProject("_test/solution/demo.sln", project, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+
+		if expected != filepath.ToSlash(actual) {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	}
+}
+
+func TestSelectSolutionProject2(t *testing.T) {
+	const expected = "_test/solution/src/Demo.Core/Demo.Core.csproj"
+
+	actual, err := selectSolutionProject("_test/solution/demo.sln", "", "demo.core")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if expected != filepath.ToSlash(actual) {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestSelectSolutionProject3(t *testing.T) {
+	_, err := selectSolutionProject("_test/solution/demo.sln", "", "demo-api")
+	if err == nil || !strings.Contains(err.Error(), "--project") {
+		t.Errorf("Expected error asking for --project, got %v", err)
+	}
+
+	_, err = selectSolutionProject("_test/solution/demo.sln", "Demo.Web", "")
+	if err == nil {
+		t.Errorf("Expected error for project not in solution")
+	}
+}
+
+func TestSelectSolutionProject4(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "demo.slnx"), `<Solution>
+  <Folder Name="/src/">
+    <Project Path="src/demo-api/demo-api.csproj" />
+  </Folder>
+  <Project Path="build/build.proj" />
+</Solution>`)
+
+	actual, err := selectSolutionProject(filepath.Join(directory, "demo.slnx"), "", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := filepath.Join(directory, "src", "demo-api", "demo-api.csproj")
+	if expected != actual {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestGenerateDotNetDockerfile3(t *testing.T) {
+	expectedDockerfile, err := os.ReadFile("_test/Dockerfile.test5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expectedBuildContext = "_test/solution"
+
+	actualDockerfilePath, actualBuildContext, err := generateDockerfile(
+		"_test/solution/demo.sln",
+		"demo-api",
+		GenerateDockerfileOptions{Project: "Demo.Api"},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expectedDockerfile) != string(actualDockerfile) {
+		t.Errorf("Dockerfile mismatch: expected %s, got %s", expectedDockerfile, actualDockerfile)
+	}
+
+	if expectedBuildContext != actualBuildContext {
+		t.Errorf("Build context mismatch: expected %s, got %s", expectedBuildContext, actualBuildContext)
+	}
+}
+
+func TestGenerateDotNetDockerfile4(t *testing.T) {
+	const expectedBuildContext = "_test/solution"
+
+	// The build context defaults to the directory containing the referenced projects and Directory.Build.props.
+	_, actualBuildContext, err := generateDockerfile(
+		"_test/solution/src/Demo.Api/Demo.Api.csproj",
+		"demo-api",
+		GenerateDockerfileOptions{},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if expectedBuildContext != actualBuildContext {
+		t.Errorf("Build context mismatch: expected %s, got %s", expectedBuildContext, actualBuildContext)
+	}
+
+	_, _, err = generateDockerfile(
+		"_test/solution/src/Demo.Api/Demo.Api.csproj",
+		"demo-api",
+		GenerateDockerfileOptions{BuildContext: "_test/solution/src/Demo.Api"},
+	)
+	if err == nil || !strings.Contains(err.Error(), "Demo.Core.csproj") {
+		t.Errorf("Expected error for referenced project outside of the build context, got %v", err)
+	}
+}
+
+func TestGenerateDotNetDockerfile5(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "demo-api.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Worker">
+  <PropertyGroup>
+    <TargetFrameworks>net8.0;net10.0</TargetFrameworks>
+  </PropertyGroup>
+</Project>`)
+
+	actualDockerfilePath, _, err := generateDockerfile(
+		filepath.Join(directory, "demo-api.csproj"),
+		"demo-api",
+		GenerateDockerfileOptions{},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"FROM mcr.microsoft.com/dotnet/sdk:10.0-alpine AS build",
+		"FROM mcr.microsoft.com/dotnet/aspnet:10.0-alpine AS runtime",
+		"--framework net10.0",
+		"RUN dotnet restore 'demo-api.csproj'\n",
+	} {
+		if !strings.Contains(string(actualDockerfile), expected) {
+			t.Errorf("Expected Dockerfile to contain %s, got %s", expected, actualDockerfile)
+		}
+	}
+}
+
+func TestGenerateDotNetDockerfile7(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "global.json"), `{"sdk": {"version": "8.0.100"}}`)
+	writeTestFile(t, filepath.Join(directory, "nuget.config"), `<configuration>
+  <packageSources>
+    <add key="local" value="./packages" />
+    <add key="missing" value="./does-not-exist" />
+  </packageSources>
+</configuration>`)
+	writeTestFile(t, filepath.Join(directory, "packages", "demo.core.1.0.0.nupkg"), "")
+	writeTestFile(t, filepath.Join(directory, "src", "demo api", "demo-api.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+	writeTestFile(t, filepath.Join(directory, "src", "demo api", "packages.lock.json"), "{}")
+
+	actualDockerfilePath, _, err := generateDockerfile(
+		filepath.Join(directory, "src", "demo api", "demo-api.csproj"),
+		"demo-api",
+		GenerateDockerfileOptions{BuildContext: directory},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The local package source, global.json and the lock file are needed to restore, and paths with spaces are kept whole.
+	const expected = `COPY ["global.json", "./"]
+COPY ["nuget.config", "./"]
+COPY ["packages", "packages/"]
+COPY ["src/demo api/demo-api.csproj", "src/demo api/"]
+COPY ["src/demo api/packages.lock.json", "src/demo api/"]
+RUN dotnet restore 'src/demo api/demo-api.csproj'
+`
+	if !strings.Contains(string(actualDockerfile), expected) {
+		t.Errorf("Expected Dockerfile to contain %s, got %s", expected, actualDockerfile)
+	}
+}
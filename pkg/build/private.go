@@ -0,0 +1,258 @@
+package build
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+// Secret ID of the token of private NuGet feeds, used with the package sources of the nuget.config of the project.
+const nugetTokenSecretID = "nugettoken"
+
+// Name of the NuGet.config with credentials written while restoring, and removed afterwards.
+const nugetTemporaryConfigFileName = "nuget.3lv.config"
+
+// Environment variable with the token of private NuGet feeds, also used by actions/setup-dotnet.
+const nugetTokenEnvironmentVariable = "NUGET_AUTH_TOKEN"
+
+func hasSecret(secrets []BuildSecret, id string) bool {
+	return slices.ContainsFunc(secrets, func(secret BuildSecret) bool {
+		return secret.ID == id
+	})
+}
+
+func hasBuildArg(buildArgs []string, key string) bool {
+	return slices.ContainsFunc(buildArgs, func(buildArg string) bool {
+		buildArgKey, _, _ := strings.Cut(buildArg, "=")
+		return buildArgKey == key
+	})
+}
+
+// addNuGetCredentials adds the nugettoken secret from NUGET_AUTH_TOKEN for .NET projects, unless a NuGet secret is given.
+func addNuGetCredentials(projectFile string, secrets []BuildSecret) []BuildSecret {
+	if !isDotNetProjectFile(projectFile) ||
+		hasSecret(secrets, nugetConfigSecretID) ||
+		hasSecret(secrets, nugetTokenSecretID) ||
+		os.Getenv(nugetTokenEnvironmentVariable) == "" {
+		return secrets
+	}
+
+	log.Printf("%s is set, will use it to authenticate with private NuGet feeds\n", nugetTokenEnvironmentVariable)
+
+	return append(secrets, BuildSecret{ID: nugetTokenSecretID, Env: nugetTokenEnvironmentVariable})
+}
+
+// addGoPrivateCredentials adds the GOPRIVATE build argument for Go projects using private modules, and the netrc secret
+// with credentials for them, from GITHUB_TOKEN or GH_TOKEN, or the .netrc of the user.
+// Returns a temporary netrc file to remove after building, if one was written.
+func addGoPrivateCredentials(
+	projectFile string,
+	buildArgs []string,
+	secrets []BuildSecret,
+) ([]string, []BuildSecret, string, error) {
+	if !strings.HasSuffix(projectFile, ".mod") {
+		return buildArgs, secrets, "", nil
+	}
+
+	goPrivate := resolveGoPrivate()
+	if goPrivate == "" {
+		return buildArgs, secrets, "", nil
+	}
+
+	if !hasBuildArg(buildArgs, "GOPRIVATE") {
+		log.Printf("GOPRIVATE is set to %s, will use it to download private Go modules\n", goPrivate)
+		buildArgs = append(buildArgs, "GOPRIVATE="+goPrivate)
+	}
+
+	if hasSecret(secrets, netrcSecretID) {
+		return buildArgs, secrets, "", nil
+	}
+
+	gitHubToken := os.Getenv("GITHUB_TOKEN")
+	if gitHubToken == "" {
+		gitHubToken = os.Getenv("GH_TOKEN")
+	}
+
+	if gitHubToken != "" && strings.Contains(goPrivate, "github.com") {
+		netrcFile, err := writeGitHubNetrc(gitHubToken)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		return buildArgs, append(secrets, BuildSecret{ID: netrcSecretID, Source: netrcFile}), netrcFile, nil
+	}
+
+	if homeDirectory, err := os.UserHomeDir(); err == nil {
+		netrcFile := filepath.Join(homeDirectory, ".netrc")
+		if _, err := os.Stat(netrcFile); err == nil {
+			return buildArgs, append(secrets, BuildSecret{ID: netrcSecretID, Source: netrcFile}), "", nil
+		}
+	}
+
+	log.Printf(
+		"WARNING: GOPRIVATE is set, but no credentials were found for private Go modules. Set GITHUB_TOKEN or pass --secret id=%s,src=<netrc file>.\n",
+		netrcSecretID,
+	)
+
+	return buildArgs, secrets, "", nil
+}
+
+// resolveGoPrivate returns GOPRIVATE from the environment, or the Go environment configured with go env -w.
+func resolveGoPrivate() string {
+	if goPrivate := os.Getenv("GOPRIVATE"); goPrivate != "" {
+		return goPrivate
+	}
+
+	goEnvOutput := goEnvCommand("GOPRIVATE", &command.RunOptions{Quiet: true})
+	if command.IsError(goEnvOutput) {
+		return ""
+	}
+
+	return strings.TrimSpace(goEnvOutput.Output)
+}
+
+// writeGitHubNetrc writes a netrc file with the token for GitHub to a temporary file, readable only by the user.
+func writeGitHubNetrc(token string) (string, error) {
+	file, err := os.CreateTemp("", "3lv-netrc")
+	if err != nil {
+		return "", fmt.Errorf("Failed to create netrc file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "machine github.com\nlogin x-access-token\npassword %s\n", token); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("Failed to write netrc file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
+// findNuGetConfig returns the nuget.config closest to the project file within the build context, relative to the
+// build context, or an empty string if there is none. NuGet looks for it the same way, ignoring case.
+func findNuGetConfig(projectFile string, buildContext string) (string, error) {
+	buildContextPath, err := filepath.Abs(buildContext)
+	if err != nil {
+		return "", err
+	}
+
+	directory, err := filepath.Abs(filepath.Dir(projectFile))
+	if err != nil {
+		return "", err
+	}
+
+	for isWithinDirectory(directory, buildContextPath) {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return "", fmt.Errorf("Failed to read directory %s: %w", directory, err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(entry.Name(), "nuget.config") {
+				return filepath.Rel(buildContextPath, filepath.Join(directory, entry.Name()))
+			}
+		}
+
+		parent := filepath.Dir(directory)
+		if directory == buildContextPath || parent == directory {
+			return "", nil
+		}
+
+		directory = parent
+	}
+
+	return "", nil
+}
+
+type nugetConfig struct {
+	PackageSources struct {
+		Add []struct {
+			Key   string `xml:"key,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"add"`
+	} `xml:"packageSources"`
+}
+
+func readNuGetConfig(nugetConfigFile string) (nugetConfig, error) {
+	var config nugetConfig
+
+	content, err := os.ReadFile(nugetConfigFile)
+	if err != nil {
+		return config, fmt.Errorf("Failed to read %s: %w", nugetConfigFile, err)
+	}
+
+	if err := xml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("Failed to parse %s: %w", nugetConfigFile, err)
+	}
+
+	return config, nil
+}
+
+func isRemoteNuGetSource(value string) bool {
+	return strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://")
+}
+
+// privateNuGetSources returns the names of the package sources in the nuget.config other than nuget.org and local folders.
+func privateNuGetSources(nugetConfigFile string) ([]string, error) {
+	config, err := readNuGetConfig(nugetConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, source := range config.PackageSources.Add {
+		if isRemoteNuGetSource(source.Value) && !strings.Contains(source.Value, "api.nuget.org") {
+			sources = append(sources, source.Key)
+		}
+	}
+
+	return sources, nil
+}
+
+// localNuGetSources returns the absolute paths of the existing local folder package sources in the nuget.config.
+// Relative folders are relative to the nuget.config, as NuGet resolves them.
+func localNuGetSources(nugetConfigFile string) ([]string, error) {
+	config, err := readNuGetConfig(nugetConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, source := range config.PackageSources.Add {
+		if source.Value == "" || isRemoteNuGetSource(source.Value) {
+			continue
+		}
+
+		directory := filepath.FromSlash(strings.ReplaceAll(source.Value, `\`, "/"))
+		if !filepath.IsAbs(directory) {
+			directory = filepath.Join(filepath.Dir(nugetConfigFile), directory)
+		}
+
+		directory, err := filepath.Abs(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		if info, err := os.Stat(directory); err == nil && info.IsDir() {
+			sources = append(sources, directory)
+		}
+	}
+
+	return sources, nil
+}
+
+func goEnvCommand(
+	variable string,
+	options *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command("go", "env", variable),
+		options,
+	)
+}
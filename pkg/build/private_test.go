@@ -0,0 +1,309 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindNuGetConfig1(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "NuGet.Config"), "<configuration />")
+	writeTestFile(t, filepath.Join(directory, "src", "demo-api", "demo-api.csproj"), "<Project />")
+
+	actual, err := findNuGetConfig(filepath.Join(directory, "src", "demo-api", "demo-api.csproj"), directory)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if actual != "NuGet.Config" {
+		t.Errorf("Expected %s, got %s", "NuGet.Config", actual)
+	}
+}
+
+func TestFindNuGetConfig2(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "nuget.config"), "<configuration />")
+	writeTestFile(t, filepath.Join(directory, "src", "nuget.config"), "<configuration />")
+	writeTestFile(t, filepath.Join(directory, "src", "demo-api", "demo-api.csproj"), "<Project />")
+
+	// The closest nuget.config is used, like NuGet does.
+	actual, err := findNuGetConfig(filepath.Join(directory, "src", "demo-api", "demo-api.csproj"), directory)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if expected := filepath.Join("src", "nuget.config"); actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestFindNuGetConfig3(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "nuget.config"), "<configuration />")
+	writeTestFile(t, filepath.Join(directory, "src", "demo-api", "demo-api.csproj"), "<Project />")
+
+	// Files outside the build context are not available when building.
+	actual, err := findNuGetConfig(
+		filepath.Join(directory, "src", "demo-api", "demo-api.csproj"),
+		filepath.Join(directory, "src", "demo-api"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if actual != "" {
+		t.Errorf("Expected no nuget.config, got %s", actual)
+	}
+}
+
+func TestFindNuGetConfig4(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "app2", "nuget.config"), "<configuration />")
+	writeTestFile(t, filepath.Join(directory, "app2", "demo-api.csproj"), "<Project />")
+
+	// A directory sharing a prefix with the build context is not within it.
+	actual, err := findNuGetConfig(filepath.Join(directory, "app2", "demo-api.csproj"), filepath.Join(directory, "app"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if actual != "" {
+		t.Errorf("Expected no nuget.config, got %s", actual)
+	}
+}
+
+func TestPrivateNuGetSources(t *testing.T) {
+	actual, err := privateNuGetSources("_test/nuget/nuget.config")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := []string{"azure-artifacts", "github"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestAddNuGetCredentials(t *testing.T) {
+	t.Setenv("NUGET_AUTH_TOKEN", "token")
+
+	actual := addNuGetCredentials("src/demo-api.csproj", nil)
+	expected := []BuildSecret{{ID: "nugettoken", Env: "NUGET_AUTH_TOKEN"}}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	given := []BuildSecret{{ID: "nugetconfig", Source: "NuGet.config"}}
+	if actual := addNuGetCredentials("src/demo-api.csproj", given); !slices.Equal(actual, given) {
+		t.Errorf("Expected %v, got %v", given, actual)
+	}
+
+	if actual := addNuGetCredentials("go.mod", nil); len(actual) != 0 {
+		t.Errorf("Expected no secrets, got %v", actual)
+	}
+}
+
+func TestAddGoPrivateCredentials1(t *testing.T) {
+	t.Setenv("GOPRIVATE", "github.com/3lvia/*")
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	buildArgs, secrets, netrcFile, err := addGoPrivateCredentials("go.mod", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	defer os.Remove(netrcFile)
+
+	if expected := []string{"GOPRIVATE=github.com/3lvia/*"}; !slices.Equal(buildArgs, expected) {
+		t.Errorf("Expected %v, got %v", expected, buildArgs)
+	}
+
+	if expected := []BuildSecret{{ID: "netrc", Source: netrcFile}}; !slices.Equal(secrets, expected) {
+		t.Errorf("Expected %v, got %v", expected, secrets)
+	}
+
+	netrc, err := os.ReadFile(netrcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "machine github.com\nlogin x-access-token\npassword token\n"; string(netrc) != expected {
+		t.Errorf("Expected %s, got %s", expected, netrc)
+	}
+}
+
+func TestAddGoPrivateCredentials2(t *testing.T) {
+	t.Setenv("GOPRIVATE", "github.com/3lvia/*")
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	givenBuildArgs := []string{"GOPRIVATE=github.com/other/*"}
+	givenSecrets := []BuildSecret{{ID: "netrc", Env: "GO_NETRC"}}
+
+	buildArgs, secrets, netrcFile, err := addGoPrivateCredentials("go.mod", givenBuildArgs, givenSecrets)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if !slices.Equal(buildArgs, givenBuildArgs) || !slices.Equal(secrets, givenSecrets) || netrcFile != "" {
+		t.Errorf("Expected given build arguments and secrets to be used, got %v and %v", buildArgs, secrets)
+	}
+}
+
+func TestAddGoPrivateCredentials3(t *testing.T) {
+	t.Setenv("GOPRIVATE", "github.com/3lvia/*")
+
+	buildArgs, secrets, _, err := addGoPrivateCredentials("src/demo-api.csproj", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(buildArgs) != 0 || len(secrets) != 0 {
+		t.Errorf("Expected no build arguments or secrets for .NET projects, got %v and %v", buildArgs, secrets)
+	}
+}
+
+func TestGenerateDotNetDockerfile2(t *testing.T) {
+	expectedDockerfile, err := os.ReadFile("_test/Dockerfile.test4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nugetConfig, err := os.ReadFile("_test/nuget/nuget.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csproj, err := os.ReadFile("_test/dotnet-8.0.csproj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "nuget.config"), string(nugetConfig))
+	writeTestFile(t, filepath.Join(directory, "dotnet-8.0.csproj"), string(csproj))
+
+	actualDockerfilePath, _, err := generateDockerfile(
+		filepath.Join(directory, "dotnet-8.0.csproj"),
+		"demo-api",
+		GenerateDockerfileOptions{SecretIDs: []string{"nugettoken"}},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expectedDockerfile) != string(actualDockerfile) {
+		t.Errorf("Dockerfile mismatch: expected %s, got %s", expectedDockerfile, actualDockerfile)
+	}
+}
+
+func TestGenerateGoDockerfile3(t *testing.T) {
+	actualDockerfilePath, _, err := generateDockerfile(
+		"go.mod",
+		"demo-api",
+		GenerateDockerfileOptions{GoPrivate: true},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ARG GOPRIVATE\nRUN apk add --no-cache git\nRUN go mod download\n"
+	if !strings.Contains(string(actualDockerfile), expected) {
+		t.Errorf("Expected Dockerfile to contain %s, got %s", expected, actualDockerfile)
+	}
+}
+
+func TestGenerateDotNetDockerfile6(t *testing.T) {
+	csproj, err := os.ReadFile("_test/dotnet-8.0.csproj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "nuget.config"), `<configuration>
+  <packageSources>
+    <add key="R&amp;D feed's" value="https://pkgs.dev.azure.com/elvia/_packaging/r-and-d/nuget/v3/index.json" />
+  </packageSources>
+</configuration>`)
+	writeTestFile(t, filepath.Join(directory, "dotnet-8.0.csproj"), string(csproj))
+
+	actualDockerfilePath, _, err := generateDockerfile(
+		filepath.Join(directory, "dotnet-8.0.csproj"),
+		"demo-api",
+		GenerateDockerfileOptions{SecretIDs: []string{"nugettoken"}},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key is passed to the shell as a single word, without HTML escaping.
+	expected := `dotnet nuget update source 'R&D feed'\''s' \`
+	if !strings.Contains(string(actualDockerfile), expected) {
+		t.Errorf("Expected Dockerfile to contain %s, got %s", expected, actualDockerfile)
+	}
+}
+
+func TestGenerateDotNetDockerfile8(t *testing.T) {
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "src", "nuget.config"), `<configuration>
+  <packageSources>
+    <add key="local" value="./packages" />
+  </packageSources>
+</configuration>`)
+	writeTestFile(t, filepath.Join(directory, "src", "demo-api.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+
+	actualDockerfilePath, _, err := generateDockerfile(
+		filepath.Join(directory, "src", "demo-api.csproj"),
+		"demo-api",
+		GenerateDockerfileOptions{BuildContext: directory, SecretIDs: []string{"nugetconfig"}},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	actualDockerfile, err := os.ReadFile(actualDockerfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The secret is placed next to the nuget.config of the project, so that relative local package sources resolve the same way.
+	const expected = `    cp /run/secrets/nugetconfig 'src/nuget.3lv.config' && \
+    dotnet restore 'src/demo-api.csproj' --configfile 'src/nuget.3lv.config' && \
+    rm 'src/nuget.3lv.config'
+`
+	if !strings.Contains(string(actualDockerfile), expected) {
+		t.Errorf("Expected Dockerfile to contain %s, got %s", expected, actualDockerfile)
+	}
+}
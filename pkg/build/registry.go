@@ -0,0 +1,172 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/3lvia/cli/pkg/auth"
+	"github.com/3lvia/cli/pkg/command"
+	"github.com/3lvia/cli/pkg/utils"
+)
+
+type RegistryTarget struct {
+	Registry  string
+	ImageName string
+}
+
+// ResolveRegistryTargets returns the image name to use in each registry, following the naming scheme of the registry.
+func ResolveRegistryTargets(
+	registries []string,
+	systemName string,
+	applicationName string,
+	gcpProjectID string,
+) ([]RegistryTarget, error) {
+	var targets []RegistryTarget
+	for _, registry := range registries {
+		if auth.IsGoogleRegistry(registry) {
+			registry = googleRegistryWithProject(registry, gcpProjectID)
+		}
+
+		imageName, err := getImageName(registry, systemName, applicationName)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, RegistryTarget{Registry: registry, ImageName: imageName})
+	}
+
+	return targets, nil
+}
+
+// AuthenticateRegistry authenticates Docker with the registry of the target, and optionally checks push permission
+// where the registry supports it.
+func AuthenticateRegistry(target RegistryTarget, checkPushPermission bool, options auth.RegistryAuthOptions) error {
+	registry := target.Registry
+
+	switch {
+	case strings.Contains(registry, "azurecr.io"):
+		log.Printf("Azure registry %s detected, will try to authenticate with Azure\n", registry)
+
+		return auth.AuthenticateACR(
+			registry,
+			utils.StringWithDefault(options.AzureTenantID, auth.ElviaTenantID),
+			utils.StringWithDefault(options.AzureSubscriptionID, auth.ElviaDefaultRuntimeSubscriptionID),
+			options.AzLoginOptions,
+		)
+
+	case auth.IsGoogleRegistry(registry):
+		log.Printf("Google Cloud registry %s detected, will try to authenticate with Google Cloud\n", registry)
+
+		return auth.AuthenticateGoogleRegistry(registry, options.GoogleAuthOptions)
+
+	case auth.IsGitHubContainerRegistry(registry):
+		log.Println("GitHub Container Registry detected, will try to authenticate with GitHub")
+
+		credentials, err := auth.AuthenticateGHCR()
+		if err != nil {
+			return err
+		}
+
+		if checkPushPermission {
+			return auth.CheckGHCRPushPermission(target.ImageName, credentials)
+		}
+	}
+
+	return nil
+}
+
+type pushResult struct {
+	ImageName string
+	Error     error
+}
+
+// pushToMirrors tags the image built for the primary registry for each mirror, and pushes it.
+// All mirrors are attempted, also when pushing to one of them fails.
+func pushToMirrors(
+	imageName string,
+	mirrors []RegistryTarget,
+	tags []string,
+	cacheTag string,
+	vexFiles []string,
+) []pushResult {
+	var results []pushResult
+	for _, mirror := range mirrors {
+		results = append(results, pushResult{
+			ImageName: mirror.ImageName,
+			Error:     pushToMirror(imageName, mirror.ImageName, tags, cacheTag, vexFiles),
+		})
+	}
+
+	return results
+}
+
+func pushToMirror(
+	imageName string,
+	mirrorImageName string,
+	tags []string,
+	cacheTag string,
+	vexFiles []string,
+) error {
+	for _, tag := range tags {
+		dockerTagOutput := dockerTagCommand(imageName+":"+tag, mirrorImageName+":"+tag, nil)
+		if command.IsError(dockerTagOutput) {
+			return fmt.Errorf("Failed to tag image: %w", dockerTagOutput.Error)
+		}
+	}
+
+	pushImageOutput := pushImageCommand(mirrorImageName, cacheTag, true, nil)
+	if command.IsError(pushImageOutput) {
+		return fmt.Errorf("Failed to push Docker image: %w", pushImageOutput.Error)
+	}
+
+	if len(vexFiles) > 0 {
+		digest, err := resolvePushedDigest(mirrorImageName, cacheTag, auth.RegistryCredentials)
+		if err != nil {
+			return err
+		}
+
+		return attachVEX(mirrorImageName+"@"+digest, vexFiles)
+	}
+
+	return nil
+}
+
+func formatPushResults(results []pushResult) string {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "IMAGE\tPUSHED")
+
+	for _, result := range results {
+		pushed := "yes"
+		if result.Error != nil {
+			pushed = "no: " + result.Error.Error()
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\n", result.ImageName, pushed)
+	}
+
+	writer.Flush()
+
+	return buffer.String()
+}
+
+func dockerTagCommand(
+	sourceImage string,
+	targetImage string,
+	options *command.RunOptions,
+) command.Output {
+	return command.Run(
+		*exec.Command(
+			"docker",
+			"tag",
+			sourceImage,
+			targetImage,
+		),
+		options,
+	)
+}
@@ -0,0 +1,110 @@
+package build
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestResolveRegistryTargets1(t *testing.T) {
+	targets, err := ResolveRegistryTargets(
+		[]string{"containerregistryelvia.azurecr.io", "europe-west1-docker.pkg.dev", "ghcr.io/3lvia"},
+		"core",
+		"demo-api",
+		"elvia-core",
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := []RegistryTarget{
+		{Registry: "containerregistryelvia.azurecr.io", ImageName: "containerregistryelvia.azurecr.io/core-demo-api"},
+		{Registry: "europe-west1-docker.pkg.dev/elvia-core", ImageName: "europe-west1-docker.pkg.dev/elvia-core/core/demo-api"},
+		{Registry: "ghcr.io/3lvia", ImageName: "ghcr.io/3lvia/core/demo-api"},
+	}
+
+	if len(targets) != len(expected) {
+		t.Fatalf("Expected %d targets, got %d", len(expected), len(targets))
+	}
+
+	for i, target := range targets {
+		if target != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], target)
+		}
+	}
+}
+
+func TestResolveRegistryTargets2(t *testing.T) {
+	_, err := ResolveRegistryTargets(
+		[]string{"containerregistryelvia.azurecr.io", "europe-west1-docker.pkg.dev"},
+		"core",
+		"demo-api",
+		"",
+	)
+	if err == nil {
+		t.Errorf("Expected error for Artifact Registry mirror without project, got nil")
+	}
+}
+
+func TestFormatPushResults(t *testing.T) {
+	output := formatPushResults(
+		[]pushResult{
+			{ImageName: "containerregistryelvia.azurecr.io/core-demo-api"},
+			{ImageName: "europe-west1-docker.pkg.dev/elvia-core/core/demo-api", Error: fmt.Errorf("Failed to push Docker image: exit status 1")},
+		},
+	)
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected %d lines, got %d", 3, len(lines))
+	}
+
+	if !strings.HasSuffix(lines[1], "yes") {
+		t.Errorf("Expected first push to be reported as successful, got %s", lines[1])
+	}
+
+	if !strings.HasSuffix(lines[2], "no: Failed to push Docker image: exit status 1") {
+		t.Errorf("Expected second push to be reported as failed, got %s", lines[2])
+	}
+}
+
+func TestImageTags(t *testing.T) {
+	additionalTags := []string{"v1.0.0", "latest"}
+
+	tags := imageTags("latest-cache", additionalTags)
+
+	expected := []string{"v1.0.0", "latest", "latest-cache"}
+	if strings.Join(tags, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, tags)
+	}
+
+	if len(additionalTags) != 2 {
+		t.Errorf("Expected additional tags to be unchanged, got %v", additionalTags)
+	}
+}
+
+func TestDockerTagCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"docker",
+			"tag",
+			"containerregistryelvia.azurecr.io/core-demo-api:v1.0.0",
+			"europe-west1-docker.pkg.dev/elvia-core/core/demo-api:v1.0.0",
+		},
+		" ",
+	)
+
+	actualCommand := dockerTagCommand(
+		"containerregistryelvia.azurecr.io/core-demo-api:v1.0.0",
+		"europe-west1-docker.pkg.dev/elvia-core/core/demo-api:v1.0.0",
+		&command.RunOptions{DryRun: true},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
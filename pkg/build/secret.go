@@ -0,0 +1,131 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Secret IDs the generated Dockerfiles mount when given with --secret.
+const (
+	// NuGet.config with the credentials of private NuGet feeds, used when restoring .NET projects.
+	nugetConfigSecretID = "nugetconfig"
+	// .netrc with the credentials of private Go module hosts, used when downloading Go modules.
+	netrcSecretID = "netrc"
+)
+
+// repeatedFlag collects the values of a flag given multiple times.
+// Unlike cli.StringSliceFlag, values are not split on commas, which secrets use to separate their attributes.
+type repeatedFlag []string
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func (f *repeatedFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return strings.Join(*f, " ")
+}
+
+// BuildSecret is a secret mounted into RUN instructions with --mount=type=secret, read from a file or environment variable.
+type BuildSecret struct {
+	ID     string
+	Source string
+	Env    string
+}
+
+// parseBuildSecret parses a secret given like to docker buildx build, e.g. id=nugetconfig,src=NuGet.config or id=token,env=TOKEN.
+func parseBuildSecret(secret string) (BuildSecret, error) {
+	var parsed BuildSecret
+	secretType := ""
+
+	for _, attribute := range strings.Split(secret, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(attribute), "=")
+		if !found || value == "" {
+			return BuildSecret{}, fmt.Errorf("Invalid secret %s, expected id=<id>,src=<file> or id=<id>,env=<variable>", secret)
+		}
+
+		switch key {
+		case "id":
+			parsed.ID = value
+		case "src", "source":
+			parsed.Source = value
+		case "env":
+			parsed.Env = value
+		case "type":
+			secretType = value
+		default:
+			return BuildSecret{}, fmt.Errorf("Invalid secret %s, unknown attribute %s", secret, key)
+		}
+	}
+
+	if parsed.ID == "" {
+		return BuildSecret{}, fmt.Errorf("Invalid secret %s, id not provided", secret)
+	}
+
+	if secretType == "env" && parsed.Env == "" {
+		parsed.Env = parsed.ID
+	}
+
+	if (parsed.Source == "") == (parsed.Env == "") {
+		return BuildSecret{}, fmt.Errorf("Invalid secret %s, exactly one of src or env must be provided", secret)
+	}
+
+	if parsed.Source != "" {
+		if _, err := os.Stat(parsed.Source); err != nil {
+			return BuildSecret{}, fmt.Errorf("Secret file for %s not found: %w", parsed.ID, err)
+		}
+	} else if _, ok := os.LookupEnv(parsed.Env); !ok {
+		return BuildSecret{}, fmt.Errorf("Environment variable %s for secret %s not set", parsed.Env, parsed.ID)
+	}
+
+	return parsed, nil
+}
+
+func parseBuildSecrets(secrets []string) ([]BuildSecret, error) {
+	var parsed []BuildSecret
+	for _, secret := range secrets {
+		buildSecret, err := parseBuildSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, buildSecret)
+	}
+
+	return parsed, nil
+}
+
+// String returns the secret as given to docker buildx build --secret.
+func (s BuildSecret) String() string {
+	if s.Env != "" {
+		return "id=" + s.ID + ",env=" + s.Env
+	}
+
+	return "id=" + s.ID + ",src=" + s.Source
+}
+
+func secretIDs(secrets []BuildSecret) []string {
+	var ids []string
+	for _, secret := range secrets {
+		ids = append(ids, secret.ID)
+	}
+
+	return ids
+}
+
+// validateBuildArgs returns an error unless each build argument is KEY=VALUE, or KEY to use the environment variable.
+func validateBuildArgs(buildArgs []string) error {
+	for _, buildArg := range buildArgs {
+		key, _, _ := strings.Cut(buildArg, "=")
+		if strings.TrimSpace(key) == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("Invalid build argument %s, expected KEY=VALUE", buildArg)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBuildSecret1(t *testing.T) {
+	nugetConfig := filepath.Join(t.TempDir(), "NuGet.config")
+	if err := os.WriteFile(nugetConfig, []byte("<configuration />"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_GO_NETRC", "machine github.com login x-access-token password token")
+
+	expected := map[string]BuildSecret{
+		"id=nugetconfig,src=" + nugetConfig:    {ID: "nugetconfig", Source: nugetConfig},
+		"id=nugetconfig,source=" + nugetConfig: {ID: "nugetconfig", Source: nugetConfig},
+		"id=netrc,env=TEST_GO_NETRC":           {ID: "netrc", Env: "TEST_GO_NETRC"},
+		"type=env,id=TEST_GO_NETRC":            {ID: "TEST_GO_NETRC", Env: "TEST_GO_NETRC"},
+	}
+
+	for secret, expectedSecret := range expected {
+		actualSecret, err := parseBuildSecret(secret)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %s", secret, err)
+			continue
+		}
+
+		if actualSecret != expectedSecret {
+			t.Errorf("Expected %+v, got %+v", expectedSecret, actualSecret)
+		}
+	}
+}
+
+func TestParseBuildSecret2(t *testing.T) {
+	nugetConfig := filepath.Join(t.TempDir(), "NuGet.config")
+	if err := os.WriteFile(nugetConfig, []byte("<configuration />"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_GO_NETRC", "machine github.com login x-access-token password token")
+
+	for _, secret := range []string{
+		"",
+		"nugetconfig",
+		"src=" + nugetConfig,
+		"id=nugetconfig",
+		"id=nugetconfig,src=" + nugetConfig + ",env=TEST_GO_NETRC",
+		"id=nugetconfig,src=does-not-exist.config",
+		"id=netrc,env=TEST_NOT_SET_GO_NETRC",
+		"id=netrc,required=true,env=TEST_GO_NETRC",
+	} {
+		if _, err := parseBuildSecret(secret); err == nil {
+			t.Errorf("Expected error for %s, got nil", secret)
+		}
+	}
+}
+
+func TestBuildSecretString(t *testing.T) {
+	expected := map[BuildSecret]string{
+		{ID: "nugetconfig", Source: "NuGet.config"}: "id=nugetconfig,src=NuGet.config",
+		{ID: "netrc", Env: "GO_NETRC"}:              "id=netrc,env=GO_NETRC",
+	}
+
+	for secret, expectedString := range expected {
+		if secret.String() != expectedString {
+			t.Errorf("Expected %s, got %s", expectedString, secret)
+		}
+	}
+}
+
+func TestValidateBuildArgs(t *testing.T) {
+	if err := validateBuildArgs([]string{"VERSION=1.0.0", "GOPRIVATE=github.com/3lvia/*,github.com/other/*", "HTTP_PROXY"}); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+
+	for _, buildArg := range []string{"=value", "MY VERSION=1.0.0"} {
+		if err := validateBuildArgs([]string{buildArg}); err == nil {
+			t.Errorf("Expected error for %s, got nil", buildArg)
+		}
+	}
+}
+
+func TestRepeatedFlag(t *testing.T) {
+	var flag repeatedFlag
+
+	for _, value := range []string{"id=nugetconfig,src=NuGet.config", "id=netrc,env=GO_NETRC"} {
+		if err := flag.Set(value); err != nil {
+			t.Errorf("Expected no error, got %s", err)
+		}
+	}
+
+	if len(flag) != 2 || flag[0] != "id=nugetconfig,src=NuGet.config" {
+		t.Errorf("Expected values not to be split on commas, got %v", flag)
+	}
+}
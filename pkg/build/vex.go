@@ -0,0 +1,44 @@
+package build
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+const vexArtifactType = "application/vnd.openvex+json"
+
+func orasAttachCommand(
+	imageReference string,
+	vexFiles []string,
+	options *command.RunOptions,
+) command.Output {
+	cmd := exec.Command(
+		"oras",
+		"attach",
+		"--artifact-type",
+		vexArtifactType,
+		imageReference,
+	)
+
+	for _, vexFile := range vexFiles {
+		cmd.Args = append(cmd.Args, vexFile+":"+vexArtifactType)
+	}
+
+	return command.Run(*cmd, options)
+}
+
+// attachVEX attaches the VEX documents to the pushed image, given by digest, as an OCI referrer artifact,
+// so that consumers of the image can discover them from the image digest.
+func attachVEX(imageReference string, vexFiles []string) error {
+	log.Printf("Attaching VEX documents to %s\n", imageReference)
+
+	attachOutput := orasAttachCommand(imageReference, vexFiles, nil)
+	if command.IsError(attachOutput) {
+		return fmt.Errorf("Failed to attach VEX documents to %s: %w", imageReference, attachOutput.Error)
+	}
+
+	return nil
+}
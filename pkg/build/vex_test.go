@@ -0,0 +1,36 @@
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestOrasAttachCommand(t *testing.T) {
+	const imageReference = "ghcr.io/3lvia/core/demo-api@sha256:bbb"
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"oras",
+			"attach",
+			"--artifact-type",
+			"application/vnd.openvex+json",
+			imageReference,
+			"vex.json:application/vnd.openvex+json",
+		},
+		" ",
+	)
+
+	actualCommand := orasAttachCommand(
+		imageReference,
+		[]string{"vex.json"},
+		&command.RunOptions{DryRun: true},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
@@ -35,6 +35,8 @@ func ErrorString(err string) Output {
 
 type RunOptions struct {
 	DryRun bool
+	// Do not log the command or its output, only return it.
+	Quiet bool
 }
 
 func Run(cmd exec.Cmd, options *RunOptions) Output {
@@ -48,11 +50,16 @@ func Run(cmd exec.Cmd, options *RunOptions) Output {
 		}
 	}
 
-	log.Print(cmd.String())
-
 	var errBuf, outBuf bytes.Buffer
-	cmd.Stderr = io.MultiWriter(os.Stderr, &errBuf)
-	cmd.Stdout = io.MultiWriter(os.Stdout, &outBuf)
+	if options.Quiet {
+		cmd.Stderr = &errBuf
+		cmd.Stdout = &outBuf
+	} else {
+		log.Print(cmd.String())
+
+		cmd.Stderr = io.MultiWriter(os.Stderr, &errBuf)
+		cmd.Stdout = io.MultiWriter(os.Stdout, &outBuf)
+	}
 
 	err := cmd.Run()
 	if err != nil {
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"os/exec"
 	"testing"
 )
 
@@ -30,3 +31,15 @@ func TestIsError2(t *testing.T) {
 		t.Errorf("Expected %t to be %t", actual, expected)
 	}
 }
+
+func TestRunQuiet(t *testing.T) {
+	output := Run(*exec.Command("echo", "hello"), &RunOptions{Quiet: true})
+	if IsError(output) {
+		t.Fatalf("Expected no error, got %s", output.Error)
+	}
+
+	const expected = "hello\n"
+	if output.Output != expected {
+		t.Errorf("Expected %q, got %q", expected, output.Output)
+	}
+}
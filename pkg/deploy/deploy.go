@@ -9,6 +9,7 @@ import (
 
 	"github.com/3lvia/cli/pkg/auth"
 	"github.com/3lvia/cli/pkg/command"
+	"github.com/3lvia/cli/pkg/registry"
 	"github.com/3lvia/cli/pkg/utils"
 	"github.com/urfave/cli/v2"
 )
@@ -19,7 +20,7 @@ var Command *cli.Command = &cli.Command{
 	Name:    "deploy",
 	Aliases: []string{"d"},
 	Usage:   "Deploy the project",
-	Flags: []cli.Flag{
+	Flags: append(append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "system-name",
 			Aliases:  []string{"s"},
@@ -38,6 +39,17 @@ var Command *cli.Command = &cli.Command{
 			Usage:    "The image tag to deploy",
 			Required: true,
 		},
+		&cli.StringFlag{
+			Name:  "image-digest",
+			Usage: "The digest of the image to deploy, e.g. sha256:4c1e...9f2b from the output of the build command. Deploys an immutable image reference, also when the tag is moved. Set in addition to --image-tag, which the chart still uses.",
+			Action: func(c *cli.Context, imageDigest string) error {
+				if err := registry.ValidateDigest(imageDigest); err != nil {
+					return cli.Exit(err, 1)
+				}
+
+				return nil
+			},
+		},
 		&cli.StringFlag{
 			Name:    "environment",
 			Aliases: []string{"e"},
@@ -117,18 +129,6 @@ var Command *cli.Command = &cli.Command{
 			Hidden:  true,
 			EnvVars: []string{"3LV_AZURE_TENANT_ID"},
 		},
-		&cli.StringFlag{
-			Name:    "azure-client-id",
-			Usage:   "The client ID to use when authenticating with the registry. Must be combined with --azure-federated-token.",
-			Hidden:  true,
-			EnvVars: []string{"3LV_AZURE_CLIENT_ID"},
-		},
-		&cli.StringFlag{
-			Name:    "azure-federated-token",
-			Usage:   "The federated token to use when authenticating with the Azure Container Registry. Must be combined with --client-id.",
-			Hidden:  true,
-			EnvVars: []string{"3LV_AZURE_FEDERATED_TOKEN"},
-		},
 		&cli.StringFlag{
 			Name:    "aks-subscription-id",
 			Usage:   "The AKS subscription ID to use",
@@ -181,7 +181,7 @@ var Command *cli.Command = &cli.Command{
 			Name:  "run-id",
 			Usage: "The GitHub Actions run ID to use for deployment annotations.",
 		},
-	},
+	}, auth.AzureFederatedFlags()...), auth.GoogleAuthFlags()...),
 	Action: Deploy,
 }
 
@@ -199,6 +199,7 @@ func Deploy(c *cli.Context) error {
 	systemName := c.String("system-name")
 	helmValuesFile := c.String("helm-values-file")
 	imageTag := c.String("image-tag")
+	imageDigest := c.String("image-digest")
 
 	commitHash, err := utils.ResolveCommitHash(c.String("commit-hash"))
 	if err != nil {
@@ -244,16 +245,11 @@ func Deploy(c *cli.Context) error {
 			c.String("azure-tenant-id"),
 			auth.ElviaTenantID,
 		)
-		loginOptions := &auth.AzLoginCommandOptions{
-			ClientID:       c.String("azure-client-id"),
-			FederatedToken: c.String("azure-federated-token"),
-		}
-
 		setupOptions := &SetupAKSOptions{
 			SubscriptionID:    c.String("aks-subscription-id"),
 			ClusterName:       c.String("aks-cluster-name"),
 			ResourceGroupName: c.String("aks-resource-group-name"),
-			AzLoginOptions:    loginOptions,
+			AzLoginOptions:    auth.AzLoginOptionsFromContext(c),
 		}
 		if err := setupAKS(
 			azureTenantID,
@@ -269,6 +265,7 @@ func Deploy(c *cli.Context) error {
 			GKEProjectID:       c.String("gke-project-id"),
 			GKEClusterName:     c.String("gke-cluster-name"),
 			GKEClusterLocation: c.String("gke-cluster-location"),
+			GoogleAuthOptions:  auth.GoogleAuthOptionsFromContext(c),
 		}
 		if err := setupGKE(environment, skipAuthentication, authOptions); err != nil {
 			return cli.Exit(err, 1)
@@ -292,6 +289,7 @@ func Deploy(c *cli.Context) error {
 		environment,
 		workloadType,
 		imageTag,
+		imageDigest,
 		repositoryName,
 		commitHash,
 		dryRun,
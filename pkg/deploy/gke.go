@@ -12,6 +12,7 @@ type SetupGKEOptions struct {
 	GKEProjectID       string
 	GKEClusterName     string
 	GKEClusterLocation string
+	GoogleAuthOptions  *auth.GoogleAuthOptions
 }
 
 func setupGKE(
@@ -20,12 +21,16 @@ func setupGKE(
 	options SetupGKEOptions,
 ) error {
 	if !skipAuthentication {
-		err := auth.AuthenticateGoogle()
+		err := auth.AuthenticateGoogle(options.GoogleAuthOptions)
 		if err != nil {
-			return err
+			return fmt.Errorf("Failed to authenticate with GKE: %w", err)
 		}
 	}
 
+	if err := auth.EnsureGKEAuthPlugin(); err != nil {
+		return err
+	}
+
 	gcloudGetCredentialsOutput := gcloudGetCredentialsCommand(
 		environment,
 		GcloudGetCredentialsCommandOptions{
@@ -56,6 +56,7 @@ func helmDeployCommand(
 	environment string,
 	workloadType string,
 	imageTag string,
+	imageDigest string,
 	repositoryName string,
 	commitHash string,
 	dryRun bool,
@@ -67,6 +68,11 @@ func helmDeployCommand(
 		)
 	}
 
+	// The chart uses the tag also when deploying by digest.
+	if imageTag == "" {
+		return command.ErrorString("imageTag must be set")
+	}
+
 	cmd := exec.Command(
 		"helm",
 		"upgrade",
@@ -82,6 +88,15 @@ func helmDeployCommand(
 		"environment="+environment,
 		"--set-string",
 		"image.tag="+imageTag,
+	)
+
+	// The digest makes the chart deploy an immutable image reference, also when the tag is moved.
+	if imageDigest != "" {
+		cmd.Args = append(cmd.Args, "--set-string", "image.digest="+imageDigest)
+	}
+
+	cmd.Args = append(
+		cmd.Args,
 		"--set-string",
 		"labels.repositoryName="+repositoryName,
 		"--set-string",
@@ -99,6 +99,7 @@ func TestHelmDeployCommand1(t *testing.T) {
 		environment,
 		workloadType,
 		imageTag,
+		"",
 		repositoryName,
 		commitHash,
 		false,
@@ -153,6 +154,7 @@ func TestHelmDeployCommand2(t *testing.T) {
 		environment,
 		workloadType,
 		imageTag,
+		"",
 		repositoryName,
 		commitHash,
 		false,
@@ -183,6 +185,7 @@ func TestHelmDeployCommand3(t *testing.T) {
 		environment,
 		workloadType,
 		imageTag,
+		"",
 		repositoryName,
 		commitHash,
 		false,
@@ -193,3 +196,102 @@ func TestHelmDeployCommand3(t *testing.T) {
 		t.Errorf("Expected error, got %s", commandOutput)
 	}
 }
+
+func TestHelmDeployCommand4(t *testing.T) {
+	const systemName = "core"
+	const helmValuesFile = ".github/deploy/values.yml"
+	const applicationName = "demo-api"
+	const environment = "prod"
+	const workloadType = "deployment"
+	const imageTag = "v420"
+	const imageDigest = "sha256:4c1e1f8b2b1f0c4c0e1a3f4e8d5c5e2b6a9d1f3b7c2e8a4d6f0b1c3e5a7d9f2b"
+	const repositoryName = "core"
+	const commitHash = "abcdef"
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"helm",
+			"upgrade",
+			"--debug",
+			"--install",
+			"-n",
+			systemName,
+			"-f",
+			helmValuesFile,
+			applicationName,
+			"elvia-charts/elvia-" + workloadType,
+			"--set-string",
+			"environment=" + environment,
+			"--set-string",
+			"image.tag=" + imageTag,
+			"--set-string",
+			"image.digest=" + imageDigest,
+			"--set-string",
+			"labels.repositoryName=" + repositoryName,
+			"--set-string",
+			"labels.commitHash=\"" + commitHash + "\"",
+		},
+		" ",
+	)
+
+	actualCommand := helmDeployCommand(
+		applicationName,
+		systemName,
+		helmValuesFile,
+		environment,
+		workloadType,
+		imageTag,
+		imageDigest,
+		repositoryName,
+		commitHash,
+		false,
+		&command.RunOptions{DryRun: true},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestHelmDeployCommand5(t *testing.T) {
+	commandOutput := helmDeployCommand(
+		"demo-api",
+		"core",
+		".github/deploy/values.yml",
+		"dev",
+		"deployment",
+		"",
+		"",
+		"core",
+		"abcdef",
+		false,
+		&command.RunOptions{DryRun: true},
+	)
+
+	if !command.IsError(commandOutput) {
+		t.Errorf("Expected error, got %s", commandOutput)
+	}
+}
+
+func TestHelmDeployCommand6(t *testing.T) {
+	// The chart uses the tag also when deploying by digest, so a digest alone is not enough.
+	commandOutput := helmDeployCommand(
+		"demo-api",
+		"core",
+		".github/deploy/values.yml",
+		"dev",
+		"deployment",
+		"",
+		"sha256:4c1e1f8b2b1f0c4c0e1a3f4e8d5c5e2b6a9d1f3b7c2e8a4d6f0b1c3e5a7d9f2b",
+		"core",
+		"abcdef",
+		false,
+		&command.RunOptions{DryRun: true},
+	)
+
+	if !command.IsError(commandOutput) {
+		t.Errorf("Expected error, got %s", commandOutput)
+	}
+}
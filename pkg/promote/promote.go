@@ -0,0 +1,276 @@
+package promote
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"text/tabwriter"
+
+	"github.com/3lvia/cli/pkg/auth"
+	"github.com/3lvia/cli/pkg/build"
+	"github.com/3lvia/cli/pkg/registry"
+	"github.com/3lvia/cli/pkg/scan"
+	"github.com/3lvia/cli/pkg/utils"
+	"github.com/urfave/cli/v2"
+)
+
+const commandName = "promote"
+
+var Command *cli.Command = &cli.Command{
+	Name:      commandName,
+	Aliases:   []string{"p"},
+	Usage:     "Promote an image to another tag or registry, copying it by digest without rebuilding",
+	ArgsUsage: "<application-name>",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "system-name",
+			Aliases: []string{"s"},
+			Usage:   "The system name to use",
+			EnvVars: []string{"3LV_SYSTEM_NAME"},
+		},
+		&cli.StringFlag{
+			Name:    "registry",
+			Aliases: []string{"r"},
+			Usage:   "The registry to promote the image from",
+			EnvVars: []string{"3LV_REGISTRY"},
+		},
+		&cli.StringFlag{
+			Name:     "from-tag",
+			Usage:    "The tag of the image to promote",
+			Required: true,
+			EnvVars:  []string{"3LV_FROM_TAG"},
+		},
+		&cli.StringFlag{
+			Name:    "to-tag",
+			Usage:   "The tag to promote the image to. Defaults to --from-tag.",
+			EnvVars: []string{"3LV_TO_TAG"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "to-registry",
+			Usage:   "The registries to promote the image to, named by the naming scheme of each registry. Can be given multiple times. Defaults to --registry.",
+			EnvVars: []string{"3LV_TO_REGISTRY"},
+		},
+		&cli.StringFlag{
+			Name:    "gcp-project-id",
+			Usage:   "The Google Cloud project of the registry, when the registry is Artifact Registry or Container Registry without a project, e.g. europe-west1-docker.pkg.dev.",
+			EnvVars: []string{"3LV_GCP_PROJECT_ID"},
+		},
+		&cli.BoolFlag{
+			Name:    "scan",
+			Usage:   "Scan the image before promoting it, and do not promote it if the scan fails",
+			Value:   false,
+			EnvVars: []string{"3LV_SCAN"},
+		},
+		&cli.StringFlag{
+			Name:    "severity",
+			Aliases: []string{"S"},
+			Usage:   "The severity to use when scanning the image: can be any combination of CRITICAL, HIGH, MEDIUM, LOW, or UNKNOWN separated by commas",
+			Value:   "CRITICAL,HIGH",
+			EnvVars: []string{"3LV_SEVERITY"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scan-formats",
+			Aliases: []string{"F"},
+			Usage:   "The formats to use when outputting the scan results: can be table, json, sarif, markdown or junit.",
+			Value:   cli.NewStringSlice("table"),
+			EnvVars: []string{"3LV_SCAN_FORMATS"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-policy",
+			Usage:   "Path to a JSON policy file deciding which vulnerabilities fail the scan. See the README for the policy format.",
+			EnvVars: []string{"3LV_SCAN_POLICY"},
+		},
+		&cli.BoolFlag{
+			Name:    "scan-ignore-unfixed",
+			Usage:   "Ignore vulnerabilities without a fix available. Use --scan-ignore-unfixed=false to include them.",
+			Value:   true,
+			EnvVars: []string{"3LV_SCAN_IGNORE_UNFIXED"},
+		},
+		&cli.StringFlag{
+			Name:    "scan-output-dir",
+			Usage:   "The directory to write the scan result files to. Will be created if it does not exist.",
+			Value:   ".",
+			EnvVars: []string{"3LV_SCAN_OUTPUT_DIR"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "scan-vex",
+			Usage:   "Path to an OpenVEX document for the application, used to filter scan results. Can be given multiple times.",
+			EnvVars: []string{"3LV_SCAN_VEX"},
+		},
+		&cli.BoolFlag{
+			Name:    "skip-authentication",
+			Usage:   "Skip authentication with the registries, using the credentials Docker is already logged in with",
+			Value:   false,
+			EnvVars: []string{"3LV_SKIP_AUTHENTICATION"},
+		},
+	}, append(auth.RegistryAuthFlags(), scan.DBFlags("scan-", "SCAN_")...)...),
+	Action: Promote,
+}
+
+func Promote(c *cli.Context) error {
+	applicationName := c.Args().First()
+	if applicationName == "" {
+		return cli.Exit("Application name not provided", 1)
+	}
+
+	systemName, err := func() (string, error) {
+		if systemName := c.String("system-name"); systemName != "" {
+			return systemName, nil
+		}
+
+		log.Println("System name not provided, will try to use the current git repository name")
+
+		return utils.ResolveRepositoryName("")
+	}()
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fromRegistry := utils.StringWithDefault(c.String("registry"), auth.ElviaContainerRegistry)
+	fromTag := c.String("from-tag")
+	toTag := utils.StringWithDefault(c.String("to-tag"), fromTag)
+
+	toRegistries := utils.RemoveZeroValues(c.StringSlice("to-registry"))
+	if len(toRegistries) == 0 {
+		toRegistries = []string{fromRegistry}
+	}
+
+	targets, err := build.ResolveRegistryTargets(
+		append([]string{fromRegistry}, toRegistries...),
+		systemName,
+		applicationName,
+		c.String("gcp-project-id"),
+	)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	source, destinations, err := promoteReferences(targets, fromTag, toTag)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if !c.Bool("skip-authentication") {
+		registryAuthOptions := auth.RegistryAuthOptionsFromContext(c)
+		for i, target := range targets {
+			// Push permission is only needed for the registries promoted to.
+			if err := build.AuthenticateRegistry(target, i > 0, registryAuthOptions); err != nil {
+				return cli.Exit(err, 1)
+			}
+		}
+	}
+
+	client := registry.NewClient(auth.RegistryCredentials)
+
+	digest, err := client.HeadManifest(source)
+	if err != nil {
+		return cli.Exit(fmt.Errorf("Failed to resolve %s: %w", source, err), 1)
+	}
+
+	// Promote exactly the image that was resolved, also if the source tag is moved while promoting.
+	source = source.WithDigest(digest)
+	log.Printf("Promoting %s\n", source)
+
+	if c.Bool("scan") {
+		err := scan.ScanImage(
+			source.String(),
+			c.String("severity"),
+			utils.RemoveZeroValues(c.StringSlice("scan-formats")),
+			false,
+			false,
+			&scan.ScanImageOptions{
+				OutputDirectory: c.String("scan-output-dir"),
+				PolicyFile:      c.String("scan-policy"),
+				IncludeUnfixed:  !c.Bool("scan-ignore-unfixed"),
+				DB:              scan.DBOptionsFromContext(c, "scan-"),
+				VEXFiles:        utils.RemoveZeroValues(c.StringSlice("scan-vex")),
+			},
+		)
+		if err != nil {
+			return cli.Exit(fmt.Errorf("Scan of %s failed, image not promoted: %w", source, err), 1)
+		}
+	}
+
+	results := promoteImage(client, source, destinations)
+
+	fmt.Print(formatPromoteResults(results))
+
+	for _, result := range results {
+		if result.Error != nil {
+			return cli.Exit("Failed to promote image to one or more registries", 1)
+		}
+	}
+
+	return nil
+}
+
+// promoteReferences returns the source image of the first target, and the destination images of the rest.
+func promoteReferences(
+	targets []build.RegistryTarget,
+	fromTag string,
+	toTag string,
+) (registry.Reference, []registry.Reference, error) {
+	source, err := registry.ParseReference(targets[0].ImageName + ":" + fromTag)
+	if err != nil {
+		return registry.Reference{}, nil, err
+	}
+
+	var destinations []registry.Reference
+	for _, target := range targets[1:] {
+		destination, err := registry.ParseReference(target.ImageName + ":" + toTag)
+		if err != nil {
+			return registry.Reference{}, nil, err
+		}
+
+		if destination == source {
+			return registry.Reference{}, nil, fmt.Errorf("Can not promote %s to itself: --to-tag or --to-registry must differ from the source", source)
+		}
+
+		destinations = append(destinations, destination)
+	}
+
+	return source, destinations, nil
+}
+
+type promoteResult struct {
+	Destination registry.Reference
+	Digest      string
+	Error       error
+}
+
+// promoteImage copies the source image to each destination. All destinations are attempted, also when one of them fails.
+func promoteImage(
+	client *registry.Client,
+	source registry.Reference,
+	destinations []registry.Reference,
+) []promoteResult {
+	var results []promoteResult
+	for _, destination := range destinations {
+		log.Printf("Copying %s to %s\n", source, destination)
+
+		digest, err := client.Copy(source, destination)
+		results = append(results, promoteResult{Destination: destination, Digest: digest, Error: err})
+	}
+
+	return results
+}
+
+func formatPromoteResults(results []promoteResult) string {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "IMAGE\tDIGEST\tPROMOTED")
+
+	for _, result := range results {
+		promoted := "yes"
+		if result.Error != nil {
+			promoted = "no: " + result.Error.Error()
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", result.Destination, result.Digest, promoted)
+	}
+
+	writer.Flush()
+
+	return buffer.String()
+}
@@ -0,0 +1,84 @@
+package promote
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/3lvia/cli/pkg/build"
+	"github.com/3lvia/cli/pkg/registry"
+)
+
+func TestPromoteReferences1(t *testing.T) {
+	targets := []build.RegistryTarget{
+		{Registry: "containerregistryelvia.azurecr.io", ImageName: "containerregistryelvia.azurecr.io/core-demo-api"},
+		{Registry: "containerregistryelvia.azurecr.io", ImageName: "containerregistryelvia.azurecr.io/core-demo-api"},
+		{Registry: "localhost:5000", ImageName: "localhost:5000/core/demo-api"},
+	}
+
+	source, destinations, err := promoteReferences(targets, "v1.0.0-rc.1", "v1.0.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expectedSource := registry.Reference{
+		Host:       "containerregistryelvia.azurecr.io",
+		Repository: "core-demo-api",
+		Tag:        "v1.0.0-rc.1",
+	}
+	if source != expectedSource {
+		t.Errorf("Expected %s, got %s", expectedSource, source)
+	}
+
+	expectedDestinations := []string{
+		"containerregistryelvia.azurecr.io/core-demo-api:v1.0.0",
+		"localhost:5000/core/demo-api:v1.0.0",
+	}
+	if len(destinations) != len(expectedDestinations) {
+		t.Fatalf("Expected %d destinations, got %d", len(expectedDestinations), len(destinations))
+	}
+
+	for i, expectedDestination := range expectedDestinations {
+		if destinations[i].String() != expectedDestination {
+			t.Errorf("Expected %s, got %s", expectedDestination, destinations[i])
+		}
+	}
+}
+
+func TestPromoteReferences2(t *testing.T) {
+	targets := []build.RegistryTarget{
+		{Registry: "ghcr.io/3lvia", ImageName: "ghcr.io/3lvia/core/demo-api"},
+		{Registry: "ghcr.io/3lvia", ImageName: "ghcr.io/3lvia/core/demo-api"},
+	}
+
+	if _, _, err := promoteReferences(targets, "v1.0.0", "v1.0.0"); err == nil {
+		t.Errorf("Expected error when promoting an image to itself, got nil")
+	}
+}
+
+func TestFormatPromoteResults(t *testing.T) {
+	const digest = "sha256:4c1e1f8b2b1f0c4c0e1a3f4e8d5c5e2b6a9d1f3b7c2e8a4d6f0b1c3e5a7d9f2b"
+
+	results := []promoteResult{
+		{
+			Destination: registry.Reference{Host: "ghcr.io", Repository: "3lvia/core/demo-api", Tag: "v1.0.0"},
+			Digest:      digest,
+		},
+		{
+			Destination: registry.Reference{Host: "localhost:5000", Repository: "core/demo-api", Tag: "v1.0.0"},
+			Error:       errors.New("connection refused"),
+		},
+	}
+
+	expected := strings.Join([]string{
+		"IMAGE                                DIGEST                                                                   PROMOTED",
+		"ghcr.io/3lvia/core/demo-api:v1.0.0   " + digest + "  yes",
+		"localhost:5000/core/demo-api:v1.0.0                                                                           no: connection refused",
+		"",
+	}, "\n")
+
+	actual := formatPromoteResults(results)
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
@@ -0,0 +1,179 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func blobPath(reference Reference, digest string) string {
+	return fmt.Sprintf("/v2/%s/blobs/%s", reference.Repository, digest)
+}
+
+func uploadPath(reference Reference) string {
+	return fmt.Sprintf("/v2/%s/blobs/uploads/", reference.Repository)
+}
+
+// BlobExists returns true if the blob exists in the repository of the reference.
+func (c *Client) BlobExists(reference Reference, digest string) (bool, error) {
+	response, err := c.do(request{
+		Method: http.MethodHead,
+		Host:   reference.Host,
+		Path:   blobPath(reference, digest),
+	})
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, unexpectedStatus("check blob "+digest, response)
+	}
+}
+
+// CopyBlob copies the blob from the repository of the source to the repository of the destination.
+// Blobs are mounted when both repositories are in the same registry, and downloaded and uploaded otherwise.
+func (c *Client) CopyBlob(source Reference, destination Reference, digest string) error {
+	exists, err := c.BlobExists(destination, digest)
+	if err != nil || exists {
+		return err
+	}
+
+	query := url.Values{}
+	if source.Host == destination.Host {
+		query.Set("mount", digest)
+		query.Set("from", source.Repository)
+	}
+
+	response, err := c.do(request{
+		Method: http.MethodPost,
+		Host:   destination.Host,
+		Path:   uploadPath(destination),
+		Query:  query,
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusCreated:
+		// Mounted from the source repository.
+		return nil
+	case http.StatusAccepted:
+	default:
+		return unexpectedStatus("start upload of blob "+digest+" to "+destination.Name(), response)
+	}
+
+	location := response.Header.Get("Location")
+	if location == "" {
+		return fmt.Errorf("Registry did not return an upload location for %s", destination.Name())
+	}
+
+	blobFile, size, err := c.downloadBlob(source, digest)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(blobFile)
+
+	uploadResponse, err := c.do(request{
+		Method:  http.MethodPut,
+		Host:    destination.Host,
+		Path:    location,
+		Query:   url.Values{"digest": {digest}},
+		Headers: map[string]string{"Content-Type": "application/octet-stream"},
+		Body: func() io.Reader {
+			file, err := os.Open(blobFile)
+			if err != nil {
+				return strings.NewReader("")
+			}
+			return file
+		},
+		ContentLength: size,
+	})
+	if err != nil {
+		return err
+	}
+	defer uploadResponse.Body.Close()
+
+	if uploadResponse.StatusCode != http.StatusCreated {
+		return unexpectedStatus("upload blob "+digest+" to "+destination.Name(), uploadResponse)
+	}
+
+	return nil
+}
+
+// downloadBlob downloads the blob to a temporary file, verifying its digest, so that the upload can be retried.
+func (c *Client) downloadBlob(source Reference, digest string) (string, int64, error) {
+	response, err := c.do(request{
+		Method: http.MethodGet,
+		Host:   source.Host,
+		Path:   blobPath(source, digest),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", 0, unexpectedStatus("download blob "+digest+" from "+source.Name(), response)
+	}
+
+	file, err := os.CreateTemp("", "3lv-blob-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), response.Body)
+	if err != nil {
+		os.Remove(file.Name())
+		return "", 0, fmt.Errorf("Failed to download blob %s: %w", digest, err)
+	}
+
+	if actualDigest := "sha256:" + hex.EncodeToString(hash.Sum(nil)); actualDigest != digest {
+		os.Remove(file.Name())
+		return "", 0, fmt.Errorf("Blob %s from %s has digest %s", digest, source.Name(), actualDigest)
+	}
+
+	return file.Name(), size, nil
+}
+
+// CheckPushPermission starts an upload to the repository of the reference, which the registry only accepts
+// when the credentials are allowed to push, and then cancels it.
+func (c *Client) CheckPushPermission(reference Reference) error {
+	response, err := c.do(request{
+		Method: http.MethodPost,
+		Host:   reference.Host,
+		Path:   uploadPath(reference),
+	})
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("Registry denied starting an upload with status code %d", response.StatusCode)
+	}
+
+	if location := response.Header.Get("Location"); location != "" {
+		// Cancelling the upload is best effort, unfinished uploads are cleaned up by the registry.
+		cancelResponse, err := c.do(request{Method: http.MethodDelete, Host: reference.Host, Path: location})
+		if err == nil {
+			cancelResponse.Body.Close()
+		}
+	}
+
+	return nil
+}
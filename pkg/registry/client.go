@@ -0,0 +1,274 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const requestTimeout = 10 * time.Minute
+
+type Credentials struct {
+	Username string
+	Secret   string
+}
+
+// CredentialsFunc returns the credentials to use for the registry host, or nil to access it anonymously.
+type CredentialsFunc func(host string) (*Credentials, error)
+
+// Client for the OCI distribution API, authenticating with basic or token authentication as challenged by the registry.
+type Client struct {
+	httpClient  *http.Client
+	credentials CredentialsFunc
+
+	mutex sync.Mutex
+	// Last token or basic authorization used for each host.
+	authorizations map[string]string
+}
+
+func NewClient(credentials CredentialsFunc) *Client {
+	if credentials == nil {
+		credentials = func(string) (*Credentials, error) { return nil, nil }
+	}
+
+	return &Client{
+		httpClient:     &http.Client{Timeout: requestTimeout},
+		credentials:    credentials,
+		authorizations: map[string]string{},
+	}
+}
+
+// StaticCredentials returns a CredentialsFunc using the same credentials for all hosts.
+func StaticCredentials(credentials Credentials) CredentialsFunc {
+	return func(string) (*Credentials, error) { return &credentials, nil }
+}
+
+// baseURL returns the URL of the registry API. Like Docker, plain HTTP is only used for registries on localhost.
+func baseURL(host string) string {
+	hostname := host
+	if index := strings.LastIndex(host, ":"); index >= 0 {
+		hostname = host[:index]
+	}
+
+	if hostname == "localhost" || hostname == "127.0.0.1" || hostname == "[::1]" {
+		return "http://" + host
+	}
+
+	return "https://" + host
+}
+
+type request struct {
+	Method        string
+	Host          string
+	Path          string
+	Query         url.Values
+	Headers       map[string]string
+	Body          func() io.Reader
+	ContentLength int64
+}
+
+// do sends the request, authenticating and retrying once if the registry responds with an authentication challenge.
+func (c *Client) do(r request) (*http.Response, error) {
+	response, err := c.send(r, c.authorization(r.Host))
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+	response.Body.Close()
+
+	authorization, err := c.authenticate(r.Host, response.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.send(r, authorization)
+}
+
+func (c *Client) send(r request, authorization string) (*http.Response, error) {
+	requestURL := r.Path
+	if !strings.HasPrefix(requestURL, "http://") && !strings.HasPrefix(requestURL, "https://") {
+		requestURL = baseURL(r.Host) + r.Path
+	}
+
+	if len(r.Query) > 0 {
+		separator := "?"
+		if strings.Contains(requestURL, "?") {
+			separator = "&"
+		}
+		requestURL += separator + r.Query.Encode()
+	}
+
+	var body io.Reader
+	if r.Body != nil {
+		body = r.Body()
+	}
+
+	httpRequest, err := http.NewRequest(r.Method, requestURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Body != nil {
+		httpRequest.ContentLength = r.ContentLength
+	}
+
+	for key, value := range r.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+
+	if authorization != "" {
+		httpRequest.Header.Set("Authorization", authorization)
+	}
+
+	return c.httpClient.Do(httpRequest)
+}
+
+func (c *Client) authorization(host string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.authorizations[host]
+}
+
+func (c *Client) authenticate(host string, challenge string) (string, error) {
+	credentials, err := c.credentials(host)
+	if err != nil {
+		return "", err
+	}
+
+	var authorization string
+
+	scheme, _, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	if strings.EqualFold(scheme, "Basic") {
+		if credentials == nil {
+			return "", fmt.Errorf("No credentials found for %s", host)
+		}
+
+		request, _ := http.NewRequest(http.MethodGet, "/", nil)
+		request.SetBasicAuth(credentials.Username, credentials.Secret)
+		authorization = request.Header.Get("Authorization")
+	} else {
+		token, err := c.getToken(challenge, credentials)
+		if err != nil {
+			return "", fmt.Errorf("Failed to authenticate to %s: %w", host, err)
+		}
+		authorization = "Bearer " + token
+	}
+
+	c.mutex.Lock()
+	c.authorizations[host] = authorization
+	c.mutex.Unlock()
+
+	return authorization, nil
+}
+
+// getToken gets a token for the scope given in the Bearer challenge of the registry.
+func (c *Client) getToken(challenge string, credentials *Credentials) (string, error) {
+	parameters, err := parseBearerChallenge(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	tokenURL, err := url.Parse(parameters["realm"])
+	if err != nil {
+		return "", fmt.Errorf("Invalid registry token realm: %w", err)
+	}
+
+	query := tokenURL.Query()
+	for _, parameter := range []string{"service", "scope"} {
+		if value := parameters[parameter]; value != "" {
+			query.Set(parameter, value)
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	request, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if credentials != nil {
+		request.SetBasicAuth(credentials.Username, credentials.Secret)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get registry token: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to get registry token, unexpected status code %d", response.StatusCode)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("Failed to parse registry token: %w", err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+// parseBearerChallenge parses a WWW-Authenticate header like
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:3lvia/cli:pull,push".
+func parseBearerChallenge(challenge string) (map[string]string, error) {
+	scheme, parameterString, found := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, fmt.Errorf("Unsupported registry authentication challenge: %s", challenge)
+	}
+
+	parameters := map[string]string{}
+	for parameterString != "" {
+		var key, value string
+
+		key, parameterString, _ = strings.Cut(strings.TrimLeft(parameterString, ", "), "=")
+		if strings.HasPrefix(parameterString, `"`) {
+			value, parameterString, _ = strings.Cut(parameterString[1:], `"`)
+		} else {
+			value, parameterString, _ = strings.Cut(parameterString, ",")
+		}
+
+		parameters[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	if parameters["realm"] == "" {
+		return nil, fmt.Errorf("Registry authentication challenge has no realm: %s", challenge)
+	}
+
+	return parameters, nil
+}
+
+// unexpectedStatus returns an error describing the response, including the registry error if any.
+func unexpectedStatus(action string, response *http.Response) error {
+	var errorResponse struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.NewDecoder(io.LimitReader(response.Body, 64*1024)).Decode(&errorResponse)
+
+	if len(errorResponse.Errors) > 0 {
+		return fmt.Errorf(
+			"Failed to %s, unexpected status code %d: %s %s",
+			action,
+			response.StatusCode,
+			errorResponse.Errors[0].Code,
+			errorResponse.Errors[0].Message,
+		)
+	}
+
+	return fmt.Errorf("Failed to %s, unexpected status code %d", action, response.StatusCode)
+}
@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseBearerChallenge1(t *testing.T) {
+	parameters, err := parseBearerChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:3lvia/cli:pull,push"`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:3lvia/cli:pull,push",
+	}
+	for key, value := range expected {
+		if parameters[key] != value {
+			t.Errorf("Expected %s to be %s, got %s", key, value, parameters[key])
+		}
+	}
+}
+
+func TestParseBearerChallenge2(t *testing.T) {
+	if _, err := parseBearerChallenge(`Basic realm="Registry"`); err == nil {
+		t.Errorf("Expected error for Basic challenge, got nil")
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	expected := map[string]string{
+		"ghcr.io":         "https://ghcr.io",
+		"localhost:5000":  "http://localhost:5000",
+		"127.0.0.1:41234": "http://127.0.0.1:41234",
+		"registry:5000":   "https://registry:5000",
+	}
+
+	for host, expectedURL := range expected {
+		if actualURL := baseURL(host); actualURL != expectedURL {
+			t.Errorf("Expected %s, got %s", expectedURL, actualURL)
+		}
+	}
+}
+
+// newTestTokenRegistryServer returns a stand-in for a registry using token authentication,
+// only allowing octocat to push to 3lvia/core/demo-api.
+func newTestTokenRegistryServer(t *testing.T, uploadCancelled *bool) *httptest.Server {
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			username, password, _ := r.BasicAuth()
+			if username != "octocat" || password != "ghp_token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			if r.URL.Query().Get("scope") != "repository:3lvia/core/demo-api:pull,push" {
+				w.Write([]byte(`{"token": "pull-token"}`))
+				return
+			}
+
+			w.Write([]byte(`{"token": "push-token"}`))
+
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/3lvia/core/demo-api/blobs/uploads/":
+			switch r.Header.Get("Authorization") {
+			case "Bearer push-token":
+				w.Header().Set("Location", "/v2/3lvia/core/demo-api/blobs/uploads/test-upload")
+				w.WriteHeader(http.StatusAccepted)
+			case "":
+				w.Header().Set(
+					"WWW-Authenticate",
+					`Bearer realm="`+server.URL+`/token",service="ghcr.io",scope="repository:3lvia/core/demo-api:pull,push"`,
+				)
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusForbidden)
+			}
+
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/3lvia/core/demo-api/blobs/uploads/test-upload":
+			*uploadCancelled = true
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func TestCheckPushPermission1(t *testing.T) {
+	uploadCancelled := false
+	server := newTestTokenRegistryServer(t, &uploadCancelled)
+	defer server.Close()
+
+	client := NewClient(StaticCredentials(Credentials{Username: "octocat", Secret: "ghp_token"}))
+
+	err := client.CheckPushPermission(Reference{Host: strings.TrimPrefix(server.URL, "http://"), Repository: "3lvia/core/demo-api"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if !uploadCancelled {
+		t.Errorf("Expected upload to be cancelled")
+	}
+}
+
+func TestCheckPushPermission2(t *testing.T) {
+	uploadCancelled := false
+	server := newTestTokenRegistryServer(t, &uploadCancelled)
+	defer server.Close()
+
+	client := NewClient(StaticCredentials(Credentials{Username: "octocat", Secret: "invalid-token"}))
+
+	err := client.CheckPushPermission(Reference{Host: strings.TrimPrefix(server.URL, "http://"), Repository: "3lvia/core/demo-api"})
+	if err == nil {
+		t.Errorf("Expected error for invalid token, got nil")
+	}
+}
@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"fmt"
+	"log"
+)
+
+// Suffixes of the tags cosign uses for signatures, attestations and SBOMs, appended to the referrers tag.
+var cosignTagSuffixes = []string{".sig", ".att", ".sbom"}
+
+type copier struct {
+	client      *Client
+	source      Reference
+	destination Reference
+	// Digests of copied manifests, and of manifests whose referrers are copied.
+	copied          map[string]bool
+	copiedReferrers map[string]bool
+}
+
+// Copy copies the image the source refers to, with its platform manifests and attached artifacts, to the destination.
+// The manifests are copied byte for byte, so the image keeps its digest, which is returned.
+func (c *Client) Copy(source Reference, destination Reference) (string, error) {
+	digest := source.Digest
+	if digest == "" {
+		var err error
+		if digest, err = c.HeadManifest(source); err != nil {
+			return "", err
+		}
+	}
+
+	copier := &copier{client: c, source: source, destination: destination, copied: map[string]bool{}, copiedReferrers: map[string]bool{}}
+	if err := copier.copyWithReferrers(digest); err != nil {
+		return "", err
+	}
+
+	if destination.Tag != "" {
+		manifest, err := c.GetManifest(source.WithDigest(digest))
+		if err != nil {
+			return "", err
+		}
+
+		if err := c.PutManifest(destination.WithTag(destination.Tag), manifest); err != nil {
+			return "", err
+		}
+	}
+
+	return digest, nil
+}
+
+// copyWithReferrers copies the manifest, and then the artifacts referring to it.
+func (c *copier) copyWithReferrers(digest string) error {
+	if c.copiedReferrers[digest] {
+		return nil
+	}
+	c.copiedReferrers[digest] = true
+
+	manifest, err := c.client.GetManifest(c.source.WithDigest(digest))
+	if err != nil {
+		return err
+	}
+
+	if err := c.copyManifest(manifest); err != nil {
+		return err
+	}
+
+	referrers, err := c.client.Referrers(c.source.WithDigest(digest))
+	if err != nil {
+		return err
+	}
+
+	for _, referrer := range referrers {
+		log.Printf("Copying %s attached to %s\n", artifactName(referrer), digest)
+
+		if err := c.copyWithReferrers(referrer.Digest); err != nil {
+			return fmt.Errorf("Failed to copy artifact %s attached to %s: %w", referrer.Digest, digest, err)
+		}
+	}
+
+	// Registries without the referrers API use tags pointing to an index of referrers, and cosign uses tags for its artifacts.
+	for _, suffix := range append([]string{""}, cosignTagSuffixes...) {
+		tag := referrersTag(digest) + suffix
+
+		taggedManifest, err := c.client.GetManifest(c.source.WithTag(tag))
+		if err != nil {
+			continue
+		}
+
+		if err := c.copyManifest(taggedManifest); err != nil {
+			return err
+		}
+
+		if err := c.client.PutManifest(c.destination.WithTag(tag), taggedManifest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyManifest copies the blobs and child manifests of the manifest, and then the manifest itself by digest.
+func (c *copier) copyManifest(manifest Manifest) error {
+	if c.copied[manifest.Digest] {
+		return nil
+	}
+
+	content, err := manifest.parse()
+	if err != nil {
+		return err
+	}
+
+	if manifest.IsIndex() {
+		for _, child := range content.Manifests {
+			childManifest, err := c.client.GetManifest(c.source.WithDigest(child.Digest))
+			if err != nil {
+				return err
+			}
+
+			if err := c.copyManifest(childManifest); err != nil {
+				return err
+			}
+		}
+	} else {
+		blobs := content.Layers
+		if content.Config != nil {
+			blobs = append([]Descriptor{*content.Config}, blobs...)
+		}
+
+		for _, blob := range blobs {
+			// Foreign layers, e.g. of Windows base images, are not stored in the registry.
+			if len(blob.URLs) > 0 {
+				continue
+			}
+
+			if err := c.client.CopyBlob(c.source, c.destination, blob.Digest); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.client.PutManifest(c.destination.WithDigest(manifest.Digest), manifest); err != nil {
+		return err
+	}
+
+	c.copied[manifest.Digest] = true
+
+	return nil
+}
+
+func artifactName(descriptor Descriptor) string {
+	if descriptor.ArtifactType != "" {
+		return descriptor.ArtifactType
+	}
+
+	return descriptor.MediaType
+}
@@ -0,0 +1,185 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func noCredentials(host string) (*Credentials, error) {
+	return nil, nil
+}
+
+// addTestImage adds a multi-arch image with an SBOM attached to the index, returning the index descriptor.
+func addTestImage(t *testing.T, registry *testRegistry, repository string, tag string) Descriptor {
+	t.Helper()
+
+	var platformManifests []Descriptor
+	for _, platform := range []string{"amd64", "arm64"} {
+		config := registry.addBlob(repository, `{"architecture": "`+platform+`", "os": "linux"}`)
+		config.MediaType = "application/vnd.oci.image.config.v1+json"
+		layer := registry.addBlob(repository, "layer for "+platform)
+
+		manifest := registry.addManifest(repository, "", MediaTypeOCIManifest, manifestContent{
+			MediaType: MediaTypeOCIManifest,
+			Config:    &config,
+			Layers:    []Descriptor{layer},
+		})
+		manifest.Annotations = map[string]string{"platform": platform}
+		platformManifests = append(platformManifests, manifest)
+	}
+
+	return registry.addManifest(repository, tag, MediaTypeOCIIndex, manifestContent{
+		MediaType: MediaTypeOCIIndex,
+		Manifests: platformManifests,
+	})
+}
+
+func addTestSBOM(t *testing.T, registry *testRegistry, repository string, subject Descriptor) Descriptor {
+	t.Helper()
+
+	config := registry.addBlob(repository, "{}")
+	config.MediaType = "application/vnd.oci.empty.v1+json"
+	sbom := registry.addBlob(repository, `{"spdxVersion": "SPDX-2.3"}`)
+	sbom.MediaType = "application/spdx+json"
+
+	return registry.addManifest(repository, "", MediaTypeOCIManifest, struct {
+		manifestContent
+		ArtifactType string `json:"artifactType"`
+	}{
+		manifestContent: manifestContent{
+			MediaType: MediaTypeOCIManifest,
+			Config:    &config,
+			Layers:    []Descriptor{sbom},
+			Subject:   &subject,
+		},
+		ArtifactType: "application/spdx+json",
+	})
+}
+
+func TestCopy1(t *testing.T) {
+	registry := newTestRegistry(t, true)
+
+	index := addTestImage(t, registry, "core/demo-api", "v1.0.0")
+	sbom := addTestSBOM(t, registry, "core/demo-api", index)
+
+	client := NewClient(noCredentials)
+
+	digest, err := client.Copy(
+		registry.reference("core/demo-api", "v1.0.0"),
+		registry.reference("core/demo-api-prod", "v1.0.0"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if digest != index.Digest {
+		t.Errorf("Expected %s, got %s", index.Digest, digest)
+	}
+
+	if actualDigest := registry.manifestDigest("core/demo-api-prod", "v1.0.0"); actualDigest != index.Digest {
+		t.Errorf("Expected %s, got %s", index.Digest, actualDigest)
+	}
+
+	if registry.manifestDigest("core/demo-api-prod", sbom.Digest) == "" {
+		t.Errorf("Expected SBOM %s to be copied", sbom.Digest)
+	}
+
+	// All blobs are in the same registry, so they should be mounted instead of uploaded.
+	if registry.mounts != 6 {
+		t.Errorf("Expected %d mounted blobs, got %d", 6, registry.mounts)
+	}
+}
+
+func TestCopy2(t *testing.T) {
+	source := newTestRegistry(t, false)
+	destination := newTestRegistry(t, false)
+
+	index := addTestImage(t, source, "core/demo-api", "v1.0.0")
+	sbom := addTestSBOM(t, source, "core/demo-api", index)
+
+	// Registries without the referrers API store referrers in an index tagged with the subject digest.
+	source.addManifest("core/demo-api", referrersTag(index.Digest), MediaTypeOCIIndex, manifestContent{
+		MediaType: MediaTypeOCIIndex,
+		Manifests: []Descriptor{sbom},
+	})
+
+	signatureLayer := source.addBlob("core/demo-api", "signature")
+	signatureConfig := source.addBlob("core/demo-api", "{}")
+	signature := source.addManifest("core/demo-api", referrersTag(index.Digest)+".sig", MediaTypeOCIManifest, manifestContent{
+		MediaType: MediaTypeOCIManifest,
+		Config:    &signatureConfig,
+		Layers:    []Descriptor{signatureLayer},
+	})
+
+	client := NewClient(noCredentials)
+
+	digest, err := client.Copy(
+		source.reference("core/demo-api", "v1.0.0"),
+		destination.reference("core/demo-api", "prod"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if digest != index.Digest {
+		t.Errorf("Expected %s, got %s", index.Digest, digest)
+	}
+
+	expectedManifests := map[string]string{
+		"prod":                              index.Digest,
+		sbom.Digest:                         sbom.Digest,
+		referrersTag(index.Digest) + ".sig": signature.Digest,
+	}
+	for identifier, expectedDigest := range expectedManifests {
+		if actualDigest := destination.manifestDigest("core/demo-api", identifier); actualDigest != expectedDigest {
+			t.Errorf("Expected %s to be %s, got %s", identifier, expectedDigest, actualDigest)
+		}
+	}
+
+	if !destination.hasBlob("core/demo-api", signatureLayer.Digest) {
+		t.Errorf("Expected blob %s to be copied", signatureLayer.Digest)
+	}
+
+	if source.mounts != 0 || destination.mounts != 0 {
+		t.Errorf("Expected no mounted blobs across registries, got %d", source.mounts+destination.mounts)
+	}
+}
+
+func TestCopy3(t *testing.T) {
+	registry := newTestRegistry(t, true)
+
+	client := NewClient(noCredentials)
+
+	_, err := client.Copy(
+		registry.reference("core/demo-api", "missing"),
+		registry.reference("core/demo-api-prod", "missing"),
+	)
+	if err == nil {
+		t.Errorf("Expected error for missing image, got nil")
+	}
+}
+
+func TestCopyBlob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors": [{"code": "DENIED", "message": "requested access to the resource is denied"}]}`))
+	}))
+	defer server.Close()
+
+	reference := Reference{Host: strings.TrimPrefix(server.URL, "http://"), Repository: "core/demo-api"}
+	digest := computeDigest([]byte("demo-api"))
+
+	// The error of the registry is reported, not only the status code.
+	err := NewClient(noCredentials).CopyBlob(reference, reference, digest)
+	if err == nil || !strings.Contains(err.Error(), "DENIED requested access to the resource is denied") {
+		t.Errorf("Expected error with the error of the registry, got %v", err)
+	}
+}
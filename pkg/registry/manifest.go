@@ -0,0 +1,234 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// Manifests larger than this are rejected, like most registries do.
+const maxManifestSize = 4 * 1024 * 1024
+
+var manifestMediaTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeDockerManifest,
+}
+
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	URLs         []string          `json:"urls,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest as stored in the registry. Content is kept as is, since the digest is computed from the exact bytes.
+type Manifest struct {
+	MediaType string
+	Digest    string
+	Content   []byte
+}
+
+type manifestContent struct {
+	MediaType string       `json:"mediaType"`
+	Config    *Descriptor  `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+	Manifests []Descriptor `json:"manifests"`
+	Subject   *Descriptor  `json:"subject"`
+}
+
+func (m Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+func (m Manifest) parse() (manifestContent, error) {
+	var content manifestContent
+	if err := json.Unmarshal(m.Content, &content); err != nil {
+		return content, fmt.Errorf("Failed to parse manifest %s: %w", m.Digest, err)
+	}
+
+	return content, nil
+}
+
+func computeDigest(content []byte) string {
+	hash := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// ValidateDigest returns an error unless the digest is a sha256 digest, e.g. sha256:4c1e...9f2b.
+func ValidateDigest(digest string) error {
+	hexDigest, found := strings.CutPrefix(digest, "sha256:")
+	if !found || len(hexDigest) != sha256.Size*2 {
+		return fmt.Errorf("Invalid digest %s, expected sha256: followed by 64 hexadecimal characters", digest)
+	}
+
+	if _, err := hex.DecodeString(hexDigest); err != nil || strings.ToLower(hexDigest) != hexDigest {
+		return fmt.Errorf("Invalid digest %s, expected sha256: followed by 64 hexadecimal characters", digest)
+	}
+
+	return nil
+}
+
+func manifestPath(reference Reference) string {
+	return fmt.Sprintf("/v2/%s/manifests/%s", reference.Repository, reference.Identifier())
+}
+
+// HeadManifest returns the digest of the manifest the reference points to.
+func (c *Client) HeadManifest(reference Reference) (string, error) {
+	response, err := c.do(request{
+		Method:  http.MethodHead,
+		Host:    reference.Host,
+		Path:    manifestPath(reference),
+		Headers: map[string]string{"Accept": strings.Join(manifestMediaTypes, ", ")},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", unexpectedStatus("find manifest "+reference.String(), response)
+	}
+
+	digest := response.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		// Some registries do not return the digest for HEAD requests.
+		manifest, err := c.GetManifest(reference)
+		if err != nil {
+			return "", err
+		}
+
+		return manifest.Digest, nil
+	}
+
+	return digest, nil
+}
+
+// GetManifest returns the manifest the reference points to, verifying its digest.
+func (c *Client) GetManifest(reference Reference) (Manifest, error) {
+	response, err := c.do(request{
+		Method:  http.MethodGet,
+		Host:    reference.Host,
+		Path:    manifestPath(reference),
+		Headers: map[string]string{"Accept": strings.Join(manifestMediaTypes, ", ")},
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Manifest{}, unexpectedStatus("get manifest "+reference.String(), response)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxManifestSize+1))
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if len(content) > maxManifestSize {
+		return Manifest{}, fmt.Errorf("Manifest %s is larger than %d bytes", reference, maxManifestSize)
+	}
+
+	digest := computeDigest(content)
+	if reference.Digest != "" && reference.Digest != digest {
+		return Manifest{}, fmt.Errorf("Manifest %s has digest %s", reference, digest)
+	}
+
+	mediaType, _, _ := strings.Cut(response.Header.Get("Content-Type"), ";")
+	if mediaType == "" || mediaType == "application/json" {
+		var parsed manifestContent
+		if err := json.Unmarshal(content, &parsed); err == nil && parsed.MediaType != "" {
+			mediaType = parsed.MediaType
+		}
+	}
+
+	return Manifest{MediaType: mediaType, Digest: digest, Content: content}, nil
+}
+
+// PutManifest uploads the manifest to the tag or digest of the reference.
+func (c *Client) PutManifest(reference Reference, manifest Manifest) error {
+	response, err := c.do(request{
+		Method:        http.MethodPut,
+		Host:          reference.Host,
+		Path:          manifestPath(reference),
+		Headers:       map[string]string{"Content-Type": manifest.MediaType},
+		Body:          func() io.Reader { return bytes.NewReader(manifest.Content) },
+		ContentLength: int64(len(manifest.Content)),
+	})
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusCreated {
+		return unexpectedStatus("put manifest "+reference.String(), response)
+	}
+
+	return nil
+}
+
+// Referrers returns the artifacts, e.g. signatures, SBOMs and VEX documents, attached to the manifest.
+// Uses the referrers API, falling back to the referrers tag schema for registries without it.
+func (c *Client) Referrers(reference Reference) ([]Descriptor, error) {
+	response, err := c.do(request{
+		Method:  http.MethodGet,
+		Host:    reference.Host,
+		Path:    fmt.Sprintf("/v2/%s/referrers/%s", reference.Repository, reference.Digest),
+		Headers: map[string]string{"Accept": MediaTypeOCIIndex},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	var index manifestContent
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(io.LimitReader(response.Body, maxManifestSize)).Decode(&index); err != nil {
+			return nil, fmt.Errorf("Failed to parse referrers of %s: %w", reference, err)
+		}
+
+	// Registries without the referrers API respond with 404, or with 400 or 405 when they do not know the endpoint.
+	case http.StatusNotFound, http.StatusBadRequest, http.StatusMethodNotAllowed:
+		fallback, err := c.GetManifest(reference.WithTag(referrersTag(reference.Digest)))
+		if err != nil {
+			// No referrers tag means no referrers.
+			return nil, nil
+		}
+
+		if index, err = fallback.parse(); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, unexpectedStatus("get referrers of "+reference.String(), response)
+	}
+
+	return index.Manifests, nil
+}
+
+// referrersTag returns the tag of the referrers tag schema, also used by cosign for signatures,
+// e.g. sha256-<hex>.
+func referrersTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1)
+}
@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidateDigest(t *testing.T) {
+	valid := computeDigest([]byte("demo-api"))
+	if err := ValidateDigest(valid); err != nil {
+		t.Errorf("Expected no error for %s, got %s", valid, err)
+	}
+
+	for _, digest := range []string{
+		"",
+		"v1.0.0",
+		"sha256:abc",
+		"md5:" + valid[len("sha256:"):],
+		"sha256:" + "4C1E1F8B2B1F0C4C0E1A3F4E8D5C5E2B6A9D1F3B7C2E8A4D6F0B1C3E5A7D9F2B",
+		"sha256:" + "zc1e1f8b2b1f0c4c0e1a3f4e8d5c5e2b6a9d1f3b7c2e8a4d6f0b1c3e5a7d9f2b",
+	} {
+		if err := ValidateDigest(digest); err == nil {
+			t.Errorf("Expected error for %s, got nil", digest)
+		}
+	}
+}
+
+func TestReferrers(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusBadRequest, http.StatusMethodNotAllowed} {
+		registry := newTestRegistry(t, false)
+		registry.referrersStatus = status
+
+		index := addTestImage(t, registry, "core/demo-api", "v1.0.0")
+		sbom := addTestSBOM(t, registry, "core/demo-api", index)
+
+		client := NewClient(noCredentials)
+		reference := registry.reference("core/demo-api", "v1.0.0").WithDigest(index.Digest)
+
+		// Without a referrers tag, there are no referrers.
+		referrers, err := client.Referrers(reference)
+		if err != nil {
+			t.Fatalf("Expected no error for status %d, got %s", status, err)
+		}
+
+		if len(referrers) != 0 {
+			t.Errorf("Expected no referrers for status %d, got %v", status, referrers)
+		}
+
+		registry.addManifest("core/demo-api", referrersTag(index.Digest), MediaTypeOCIIndex, manifestContent{
+			MediaType: MediaTypeOCIIndex,
+			Manifests: []Descriptor{sbom},
+		})
+
+		referrers, err = client.Referrers(reference)
+		if err != nil {
+			t.Fatalf("Expected no error for status %d, got %s", status, err)
+		}
+
+		if len(referrers) != 1 || referrers[0].Digest != sbom.Digest {
+			t.Errorf("Expected referrers %v for status %d, got %v", []Descriptor{sbom}, status, referrers)
+		}
+	}
+
+	registry := newTestRegistry(t, false)
+	registry.referrersStatus = http.StatusInternalServerError
+
+	index := addTestImage(t, registry, "core/demo-api", "v1.0.0")
+
+	client := NewClient(noCredentials)
+	if _, err := client.Referrers(registry.reference("core/demo-api", "v1.0.0").WithDigest(index.Digest)); err == nil {
+		t.Errorf("Expected error for status %d", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference to an image in a registry, by tag or digest, e.g. ghcr.io/3lvia/core/demo-api:v1.0.0.
+type Reference struct {
+	Host       string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+func ParseReference(reference string) (Reference, error) {
+	host, path, found := strings.Cut(reference, "/")
+	if !found || host == "" || path == "" {
+		return Reference{}, fmt.Errorf("Invalid image reference %s, expected registry/repository:tag or registry/repository@digest", reference)
+	}
+
+	var parsed Reference
+	parsed.Host = host
+
+	if repository, digest, found := strings.Cut(path, "@"); found {
+		if !strings.HasPrefix(digest, "sha256:") {
+			return Reference{}, fmt.Errorf("Invalid digest in image reference %s", reference)
+		}
+		path = repository
+		parsed.Digest = digest
+	}
+
+	// A colon after the last slash separates the tag, colons before are part of the host port.
+	if index := strings.LastIndex(path, ":"); index > strings.LastIndex(path, "/") {
+		parsed.Tag = path[index+1:]
+		path = path[:index]
+	}
+
+	parsed.Repository = path
+
+	if parsed.Tag == "" && parsed.Digest == "" {
+		parsed.Tag = "latest"
+	}
+
+	return parsed, nil
+}
+
+// Name returns the reference without tag or digest.
+func (r Reference) Name() string {
+	return r.Host + "/" + r.Repository
+}
+
+// Identifier returns the digest if set, or else the tag, as used in registry API paths.
+func (r Reference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+func (r Reference) String() string {
+	if r.Digest != "" {
+		return r.Name() + "@" + r.Digest
+	}
+
+	return r.Name() + ":" + r.Tag
+}
+
+// WithDigest returns the reference to the digest in the same repository.
+func (r Reference) WithDigest(digest string) Reference {
+	return Reference{Host: r.Host, Repository: r.Repository, Digest: digest}
+}
+
+// WithTag returns the reference to the tag in the same repository.
+func (r Reference) WithTag(tag string) Reference {
+	return Reference{Host: r.Host, Repository: r.Repository, Tag: tag}
+}
@@ -0,0 +1,53 @@
+package registry
+
+import "testing"
+
+func TestParseReference1(t *testing.T) {
+	expected := map[string]Reference{
+		"ghcr.io/3lvia/core/demo-api:v1.0.0": {Host: "ghcr.io", Repository: "3lvia/core/demo-api", Tag: "v1.0.0"},
+		"ghcr.io/3lvia/core/demo-api":        {Host: "ghcr.io", Repository: "3lvia/core/demo-api", Tag: "latest"},
+		"localhost:5000/core-demo-api@sha256:abc": {
+			Host:       "localhost:5000",
+			Repository: "core-demo-api",
+			Digest:     "sha256:abc",
+		},
+		"localhost:5000/core-demo-api:v1@sha256:abc": {
+			Host:       "localhost:5000",
+			Repository: "core-demo-api",
+			Tag:        "v1",
+			Digest:     "sha256:abc",
+		},
+	}
+
+	for reference, expectedReference := range expected {
+		actualReference, err := ParseReference(reference)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %s", reference, err)
+			continue
+		}
+
+		if actualReference != expectedReference {
+			t.Errorf("Expected %+v, got %+v", expectedReference, actualReference)
+		}
+	}
+}
+
+func TestParseReference2(t *testing.T) {
+	for _, reference := range []string{"demo-api", "ghcr.io/demo-api@md5:abc", "/demo-api"} {
+		if _, err := ParseReference(reference); err == nil {
+			t.Errorf("Expected error for %s, got nil", reference)
+		}
+	}
+}
+
+func TestReferenceString(t *testing.T) {
+	reference := Reference{Host: "ghcr.io", Repository: "3lvia/core/demo-api", Tag: "v1.0.0"}
+
+	if reference.String() != "ghcr.io/3lvia/core/demo-api:v1.0.0" {
+		t.Errorf("Expected %s, got %s", "ghcr.io/3lvia/core/demo-api:v1.0.0", reference)
+	}
+
+	if digestReference := reference.WithDigest("sha256:abc"); digestReference.String() != "ghcr.io/3lvia/core/demo-api@sha256:abc" {
+		t.Errorf("Expected %s, got %s", "ghcr.io/3lvia/core/demo-api@sha256:abc", digestReference)
+	}
+}
@@ -0,0 +1,287 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry is an in-memory stand-in for a registry implementing the parts of the OCI distribution API used by the client.
+type testRegistry struct {
+	t      *testing.T
+	server *httptest.Server
+
+	// Registries without the referrers API respond with 404, and clients fall back to the referrers tag schema.
+	supportsReferrers bool
+	// The status responded to referrers requests when the referrers API is not supported. Defaults to 404.
+	referrersStatus int
+
+	mutex      sync.Mutex
+	blobs      map[string]map[string][]byte
+	manifests  map[string]map[string][]byte
+	mediaTypes map[string]string
+	uploads    map[string]string
+	mounts     int
+}
+
+func newTestRegistry(t *testing.T, supportsReferrers bool) *testRegistry {
+	registry := &testRegistry{
+		t:                 t,
+		supportsReferrers: supportsReferrers,
+		blobs:             map[string]map[string][]byte{},
+		manifests:         map[string]map[string][]byte{},
+		mediaTypes:        map[string]string{},
+		uploads:           map[string]string{},
+	}
+	registry.server = httptest.NewServer(http.HandlerFunc(registry.handle))
+	t.Cleanup(registry.server.Close)
+
+	return registry
+}
+
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func (r *testRegistry) reference(repository string, tag string) Reference {
+	return Reference{Host: r.host(), Repository: repository, Tag: tag}
+}
+
+func (r *testRegistry) addBlob(repository string, content string) Descriptor {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	digest := computeDigest([]byte(content))
+	if r.blobs[repository] == nil {
+		r.blobs[repository] = map[string][]byte{}
+	}
+	r.blobs[repository][digest] = []byte(content)
+
+	return Descriptor{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: digest, Size: int64(len(content))}
+}
+
+func (r *testRegistry) addManifest(repository string, tag string, mediaType string, content any) Descriptor {
+	manifest, err := json.Marshal(content)
+	if err != nil {
+		r.t.Fatalf("Expected no error, got %s", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	digest := r.storeManifest(repository, tag, mediaType, manifest)
+
+	return Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(manifest))}
+}
+
+func (r *testRegistry) storeManifest(repository string, tag string, mediaType string, manifest []byte) string {
+	digest := computeDigest(manifest)
+	if r.manifests[repository] == nil {
+		r.manifests[repository] = map[string][]byte{}
+	}
+	r.manifests[repository][digest] = manifest
+	if tag != "" {
+		r.manifests[repository][tag] = manifest
+	}
+	r.mediaTypes[digest] = mediaType
+
+	return digest
+}
+
+func (r *testRegistry) hasBlob(repository string, digest string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, ok := r.blobs[repository][digest]
+	return ok
+}
+
+func (r *testRegistry) manifestDigest(repository string, identifier string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	manifest, ok := r.manifests[repository][identifier]
+	if !ok {
+		return ""
+	}
+
+	return computeDigest(manifest)
+}
+
+func (r *testRegistry) handle(w http.ResponseWriter, request *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path := strings.TrimPrefix(request.URL.Path, "/v2/")
+
+	switch {
+	case strings.HasPrefix(request.URL.Path, "/uploads/"):
+		r.handleUpload(w, request, strings.TrimPrefix(request.URL.Path, "/uploads/"))
+
+	case strings.HasSuffix(path, "/blobs/uploads/") && request.Method == http.MethodPost:
+		repository := strings.TrimSuffix(path, "/blobs/uploads/")
+
+		if mount, from := request.URL.Query().Get("mount"), request.URL.Query().Get("from"); mount != "" {
+			if blob, ok := r.blobs[from][mount]; ok {
+				if r.blobs[repository] == nil {
+					r.blobs[repository] = map[string][]byte{}
+				}
+				r.blobs[repository][mount] = blob
+				r.mounts++
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		}
+
+		id := fmt.Sprintf("%d", len(r.uploads))
+		r.uploads[id] = repository
+		w.Header().Set("Location", "/uploads/"+id)
+		w.WriteHeader(http.StatusAccepted)
+
+	case strings.Contains(path, "/manifests/"):
+		repository, identifier, _ := strings.Cut(path, "/manifests/")
+		r.handleManifest(w, request, repository, identifier)
+
+	case strings.Contains(path, "/blobs/"):
+		repository, digest, _ := strings.Cut(path, "/blobs/")
+
+		blob, ok := r.blobs[repository][digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+		if request.Method == http.MethodGet {
+			w.Write(blob)
+		}
+
+	case strings.Contains(path, "/referrers/") && r.supportsReferrers:
+		repository, digest, _ := strings.Cut(path, "/referrers/")
+
+		index := manifestContent{MediaType: MediaTypeOCIIndex, Manifests: []Descriptor{}}
+		for identifier, manifest := range r.manifests[repository] {
+			var content struct {
+				ArtifactType string      `json:"artifactType"`
+				Subject      *Descriptor `json:"subject"`
+			}
+			json.Unmarshal(manifest, &content)
+
+			if identifier == computeDigest(manifest) && content.Subject != nil && content.Subject.Digest == digest {
+				index.Manifests = append(index.Manifests, Descriptor{
+					MediaType:    r.mediaTypes[identifier],
+					ArtifactType: content.ArtifactType,
+					Digest:       identifier,
+					Size:         int64(len(manifest)),
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", MediaTypeOCIIndex)
+		json.NewEncoder(w).Encode(index)
+
+	case strings.Contains(path, "/referrers/") && r.referrersStatus != 0:
+		w.WriteHeader(r.referrersStatus)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *testRegistry) handleUpload(w http.ResponseWriter, request *http.Request, id string) {
+	repository, ok := r.uploads[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if request.Method == http.MethodDelete {
+		delete(r.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	blob, _ := io.ReadAll(request.Body)
+	digest := request.URL.Query().Get("digest")
+	if computeDigest(blob) != digest {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors": [{"code": "DIGEST_INVALID", "message": "provided digest did not match uploaded content"}]}`))
+		return
+	}
+
+	if r.blobs[repository] == nil {
+		r.blobs[repository] = map[string][]byte{}
+	}
+	r.blobs[repository][digest] = blob
+	delete(r.uploads, id)
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (r *testRegistry) handleManifest(w http.ResponseWriter, request *http.Request, repository string, identifier string) {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead:
+		manifest, ok := r.manifests[repository][identifier]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`))
+			return
+		}
+
+		digest := computeDigest(manifest)
+		w.Header().Set("Content-Type", r.mediaTypes[digest])
+		w.Header().Set("Docker-Content-Digest", digest)
+		if request.Method == http.MethodGet {
+			w.Write(manifest)
+		}
+
+	case http.MethodPut:
+		manifest, _ := io.ReadAll(request.Body)
+
+		// Like real registries, reject manifests referring to blobs or manifests not in the repository.
+		var content manifestContent
+		json.Unmarshal(manifest, &content)
+
+		var missing []string
+		for _, child := range content.Manifests {
+			if _, ok := r.manifests[repository][child.Digest]; !ok {
+				missing = append(missing, child.Digest)
+			}
+		}
+		blobs := content.Layers
+		if content.Config != nil {
+			blobs = append(blobs, *content.Config)
+		}
+		for _, blob := range blobs {
+			if _, ok := r.blobs[repository][blob.Digest]; !ok && len(blob.URLs) == 0 {
+				missing = append(missing, blob.Digest)
+			}
+		}
+
+		if len(missing) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": [{"code": "MANIFEST_BLOB_UNKNOWN", "message": "` + strings.Join(missing, ", ") + `"}]}`))
+			return
+		}
+
+		tag := identifier
+		if strings.HasPrefix(identifier, "sha256:") {
+			if computeDigest(manifest) != identifier {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			tag = ""
+		}
+
+		r.storeManifest(repository, tag, request.Header.Get("Content-Type"), manifest)
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,282 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultDBRepository     = "ghcr.io/3lvia/trivy-db"
+	defaultJavaDBRepository = "ghcr.io/3lvia/trivy-java-db"
+	defaultDBMaxAge         = 72 * time.Hour
+)
+
+// DBOptions configures where Trivy downloads its databases from, and where they are stored.
+// Empty values use the defaults.
+type DBOptions struct {
+	DBRepository     string
+	JavaDBRepository string
+	CacheDir         string
+}
+
+func (options DBOptions) dbRepository() string {
+	if options.DBRepository == "" {
+		return defaultDBRepository
+	}
+
+	return options.DBRepository
+}
+
+func (options DBOptions) javaDBRepository() string {
+	if options.JavaDBRepository == "" {
+		return defaultJavaDBRepository
+	}
+
+	return options.JavaDBRepository
+}
+
+// cacheDir returns the Trivy cache directory, using the same default as Trivy.
+func (options DBOptions) cacheDir() (string, error) {
+	if options.CacheDir != "" {
+		return options.CacheDir, nil
+	}
+
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("Failed to find cache directory, please specify it with --cache-dir: %w", err)
+	}
+
+	return filepath.Join(userCacheDir, "trivy"), nil
+}
+
+// DBFlags returns the flags configuring the Trivy databases, with the given flag and environment variable prefixes,
+// so that other commands running scans can use the same flags, e.g. scan-db-repository for the build command.
+func DBFlags(prefix string, envPrefix string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    prefix + "db-repository",
+			Usage:   "The OCI repository to download the Trivy vulnerability database from",
+			Value:   defaultDBRepository,
+			EnvVars: []string{"3LV_" + envPrefix + "DB_REPOSITORY"},
+		},
+		&cli.StringFlag{
+			Name:    prefix + "java-db-repository",
+			Usage:   "The OCI repository to download the Trivy Java database from",
+			Value:   defaultJavaDBRepository,
+			EnvVars: []string{"3LV_" + envPrefix + "JAVA_DB_REPOSITORY"},
+		},
+		&cli.StringFlag{
+			Name:    prefix + "cache-dir",
+			Usage:   "The directory Trivy stores its databases and cache in. Defaults to Trivy's default cache directory.",
+			EnvVars: []string{"3LV_" + envPrefix + "CACHE_DIR"},
+		},
+		&cli.DurationFlag{
+			Name:    prefix + "db-max-age",
+			Usage:   "Warn when the local Trivy database is older than this, e.g. 72h. Set to 0 to disable the warning.",
+			Value:   defaultDBMaxAge,
+			EnvVars: []string{"3LV_" + envPrefix + "DB_MAX_AGE"},
+		},
+	}
+}
+
+// DBOptionsFromContext reads the flags added by DBFlags.
+func DBOptionsFromContext(c *cli.Context, prefix string) DBOptions {
+	return DBOptions{
+		DBRepository:     c.String(prefix + "db-repository"),
+		JavaDBRepository: c.String(prefix + "java-db-repository"),
+		CacheDir:         c.String(prefix + "cache-dir"),
+	}
+}
+
+var dbCommand = &cli.Command{
+	Name:  "db",
+	Usage: "Manage the local Trivy databases, e.g. to pre-seed them for offline scanning",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "download",
+			Usage:  "Download the Trivy vulnerability and Java databases",
+			Flags:  DBFlags("", ""),
+			Action: dbDownload,
+		},
+		{
+			Name:   "status",
+			Usage:  "Show the age and version of the local Trivy databases",
+			Flags:  DBFlags("", ""),
+			Action: dbStatus,
+		},
+		{
+			Name:   "path",
+			Usage:  "Print the directory the Trivy databases are stored in",
+			Flags:  DBFlags("", ""),
+			Action: dbPath,
+		},
+	},
+}
+
+func dbDownload(c *cli.Context) error {
+	options := DBOptionsFromContext(c, "")
+
+	if err := downloadDBs(options); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	return nil
+}
+
+func dbStatus(c *cli.Context) error {
+	options := DBOptionsFromContext(c, "")
+
+	cacheDir, err := options.cacheDir()
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	maxAge := c.Duration("db-max-age")
+
+	for _, db := range []string{"db", "java-db"} {
+		metadata, err := readDBMetadata(cacheDir, db)
+		if err != nil {
+			fmt.Printf("%s: not found in %s\n", db, cacheDir)
+			continue
+		}
+
+		age := time.Since(metadata.DownloadedAt).Truncate(time.Minute)
+		fmt.Printf(
+			"%s: version %d, updated at %s, downloaded at %s (%s ago), next update %s\n",
+			db,
+			metadata.Version,
+			metadata.UpdatedAt.Format(time.RFC3339),
+			metadata.DownloadedAt.Format(time.RFC3339),
+			age,
+			metadata.NextUpdate.Format(time.RFC3339),
+		)
+	}
+
+	warnIfDBOutdated(options, maxAge, time.Now())
+
+	return nil
+}
+
+func dbPath(c *cli.Context) error {
+	cacheDir, err := DBOptionsFromContext(c, "").cacheDir()
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Println(cacheDir)
+
+	return nil
+}
+
+func downloadDBs(options DBOptions) error {
+	downloadDBOutput := downloadDBCommand(options, nil)
+	if command.IsError(downloadDBOutput) {
+		return fmt.Errorf("Failed to download Trivy database: %w", downloadDBOutput.Error)
+	}
+
+	downloadJavaDBOutput := downloadJavaDBCommand(options, nil)
+	if command.IsError(downloadJavaDBOutput) {
+		return fmt.Errorf("Failed to download Trivy Java database: %w", downloadJavaDBOutput.Error)
+	}
+
+	return nil
+}
+
+func downloadDBCommand(options DBOptions, runOptions *command.RunOptions) command.Output {
+	cmd := exec.Command(
+		"trivy",
+		"image",
+		"--download-db-only",
+		"--db-repository",
+		options.dbRepository(),
+	)
+
+	if options.CacheDir != "" {
+		cmd.Args = append(cmd.Args, "--cache-dir", options.CacheDir)
+	}
+
+	return command.Run(*cmd, runOptions)
+}
+
+func downloadJavaDBCommand(options DBOptions, runOptions *command.RunOptions) command.Output {
+	cmd := exec.Command(
+		"trivy",
+		"image",
+		"--download-java-db-only",
+		"--java-db-repository",
+		options.javaDBRepository(),
+	)
+
+	if options.CacheDir != "" {
+		cmd.Args = append(cmd.Args, "--cache-dir", options.CacheDir)
+	}
+
+	return command.Run(*cmd, runOptions)
+}
+
+type DBMetadata struct {
+	Version      int       `json:"Version"`
+	NextUpdate   time.Time `json:"NextUpdate"`
+	UpdatedAt    time.Time `json:"UpdatedAt"`
+	DownloadedAt time.Time `json:"DownloadedAt"`
+}
+
+// readDBMetadata reads the metadata Trivy stores next to a database, db being either db or java-db.
+func readDBMetadata(cacheDir string, db string) (DBMetadata, error) {
+	content, err := os.ReadFile(filepath.Join(cacheDir, db, "metadata.json"))
+	if err != nil {
+		return DBMetadata{}, err
+	}
+
+	var metadata DBMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return DBMetadata{}, fmt.Errorf("Failed to parse %s metadata: %w", db, err)
+	}
+
+	return metadata, nil
+}
+
+// checkDBAge returns a warning if the vulnerability database is missing or older than maxAge,
+// or an empty string if the database is fine. A maxAge of zero disables the check.
+func checkDBAge(cacheDir string, maxAge time.Duration, now time.Time) string {
+	if maxAge <= 0 {
+		return ""
+	}
+
+	metadata, err := readDBMetadata(cacheDir, "db")
+	if err != nil {
+		return fmt.Sprintf("Trivy database not found in %s, run `3lv scan db download` first", cacheDir)
+	}
+
+	age := now.Sub(metadata.UpdatedAt)
+	if age > maxAge {
+		return fmt.Sprintf(
+			"Trivy database in %s was last updated %s ago, which is older than %s. Results may be missing recent vulnerabilities.",
+			cacheDir,
+			age.Truncate(time.Minute),
+			maxAge,
+		)
+	}
+
+	return ""
+}
+
+func warnIfDBOutdated(options DBOptions, maxAge time.Duration, now time.Time) {
+	cacheDir, err := options.cacheDir()
+	if err != nil {
+		log.Printf("WARNING: %s\n", err)
+		return
+	}
+
+	if warning := checkDBAge(cacheDir, maxAge, now); warning != "" {
+		log.Printf("WARNING: %s\n", warning)
+	}
+}
@@ -0,0 +1,130 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestDownloadDBCommand1(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"trivy",
+			"image",
+			"--download-db-only",
+			"--db-repository",
+			"ghcr.io/3lvia/trivy-db",
+		},
+		" ",
+	)
+
+	actualCommand := downloadDBCommand(DBOptions{}, &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestDownloadDBCommand2(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"trivy",
+			"image",
+			"--download-db-only",
+			"--db-repository",
+			"registry.example.com/trivy-db",
+			"--cache-dir",
+			"/opt/trivy",
+		},
+		" ",
+	)
+
+	actualCommand := downloadDBCommand(
+		DBOptions{DBRepository: "registry.example.com/trivy-db", CacheDir: "/opt/trivy"},
+		&command.RunOptions{DryRun: true},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestDownloadJavaDBCommand(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"trivy",
+			"image",
+			"--download-java-db-only",
+			"--java-db-repository",
+			"ghcr.io/3lvia/trivy-java-db",
+		},
+		" ",
+	)
+
+	actualCommand := downloadJavaDBCommand(DBOptions{}, &command.RunOptions{DryRun: true})
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func writeTestDBMetadata(t *testing.T, cacheDir string, updatedAt time.Time) {
+	if err := os.MkdirAll(filepath.Join(cacheDir, "db"), 0755); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	metadata := `{"Version": 2, "NextUpdate": "` + updatedAt.Add(24*time.Hour).Format(time.RFC3339) +
+		`", "UpdatedAt": "` + updatedAt.Format(time.RFC3339) +
+		`", "DownloadedAt": "` + updatedAt.Format(time.RFC3339) + `"}`
+	if err := os.WriteFile(filepath.Join(cacheDir, "db", "metadata.json"), []byte(metadata), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+}
+
+func TestReadDBMetadata(t *testing.T) {
+	cacheDir := t.TempDir()
+	updatedAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	writeTestDBMetadata(t, cacheDir, updatedAt)
+
+	metadata, err := readDBMetadata(cacheDir, "db")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if metadata.Version != 2 || !metadata.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("Expected version 2 updated at %s, got %+v", updatedAt, metadata)
+	}
+}
+
+func TestCheckDBAge(t *testing.T) {
+	cacheDir := t.TempDir()
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	if warning := checkDBAge(cacheDir, 72*time.Hour, now); !strings.Contains(warning, "not found") {
+		t.Errorf("Expected missing database warning, got %q", warning)
+	}
+
+	writeTestDBMetadata(t, cacheDir, now.Add(-24*time.Hour))
+	if warning := checkDBAge(cacheDir, 72*time.Hour, now); warning != "" {
+		t.Errorf("Expected no warning, got %q", warning)
+	}
+
+	writeTestDBMetadata(t, cacheDir, now.Add(-96*time.Hour))
+	if warning := checkDBAge(cacheDir, 72*time.Hour, now); !strings.Contains(warning, "older than 72h0m0s") {
+		t.Errorf("Expected outdated database warning, got %q", warning)
+	}
+
+	if warning := checkDBAge(cacheDir, 0, now); warning != "" {
+		t.Errorf("Expected no warning when disabled, got %q", warning)
+	}
+}
@@ -0,0 +1,403 @@
+package scan
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	SortBySeverity = "severity"
+	SortByEPSS     = "epss"
+)
+
+// Feeds downloaded from a URL are cached, and downloaded again when older than this.
+const feedMaxAge = 24 * time.Hour
+
+const feedDownloadTimeout = 2 * time.Minute
+
+// EPSSScore is the Exploit Prediction Scoring System score of a vulnerability,
+// see https://www.first.org/epss.
+type EPSSScore struct {
+	// Probability of exploitation in the next 30 days, between 0 and 1.
+	Probability float64 `json:"Probability"`
+	// Share of all scored vulnerabilities with a lower or equal probability, between 0 and 1.
+	Percentile float64 `json:"Percentile"`
+}
+
+// EnrichmentOptions configures where to read EPSS and KEV data from.
+// Each feed is either a file path or a URL. A feed is not used when empty.
+type EnrichmentOptions struct {
+	// EPSS scores in the CSV format published by FIRST, optionally gzipped, or the JSON format of the EPSS API.
+	EPSSFeed string
+	// Known exploited vulnerabilities in the CISA KEV catalog JSON format.
+	KEVFeed string
+}
+
+func (options EnrichmentOptions) enabled() bool {
+	return options.EPSSFeed != "" || options.KEVFeed != ""
+}
+
+type Enrichment struct {
+	EPSS           map[string]EPSSScore
+	KnownExploited map[string]bool
+}
+
+// loadEnrichment reads the configured feeds. Feeds given as URLs are cached in cacheDir.
+func loadEnrichment(options EnrichmentOptions, cacheDir string, now time.Time) (*Enrichment, error) {
+	enrichment := &Enrichment{}
+
+	if options.EPSSFeed != "" {
+		content, err := readFeed(options.EPSSFeed, cacheDir, now)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read EPSS feed %s: %w", options.EPSSFeed, err)
+		}
+
+		enrichment.EPSS, err = parseEPSS(content)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse EPSS feed %s: %w", options.EPSSFeed, err)
+		}
+	}
+
+	if options.KEVFeed != "" {
+		content, err := readFeed(options.KEVFeed, cacheDir, now)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read KEV feed %s: %w", options.KEVFeed, err)
+		}
+
+		enrichment.KnownExploited, err = parseKnownExploitedVulnerabilities(content)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse KEV feed %s: %w", options.KEVFeed, err)
+		}
+	}
+
+	return enrichment, nil
+}
+
+// parseKnownExploitedVulnerabilities reads vulnerability IDs from the CISA KEV catalog JSON format.
+func parseKnownExploitedVulnerabilities(content []byte) (map[string]bool, error) {
+	var catalog struct {
+		Vulnerabilities []struct {
+			CVEID string `json:"cveID"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(content, &catalog); err != nil {
+		return nil, err
+	}
+
+	knownExploited := make(map[string]bool, len(catalog.Vulnerabilities))
+	for _, vulnerability := range catalog.Vulnerabilities {
+		knownExploited[vulnerability.CVEID] = true
+	}
+
+	return knownExploited, nil
+}
+
+// loadEnrichmentFeeds reads the feeds configured in the scan options, caching downloads next to the Trivy databases.
+func loadEnrichmentFeeds(options *ScanImageOptions) (*Enrichment, error) {
+	cacheDir, err := options.DB.cacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Reading EPSS and KEV feeds")
+
+	return loadEnrichment(options.Enrichment, filepath.Join(cacheDir, "3lv-feeds"), time.Now())
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// readFeed reads a feed from a file, or from a URL through a cache in cacheDir.
+// If downloading fails, a previously cached copy is used even if it is outdated.
+// Gzipped feeds are decompressed.
+func readFeed(source string, cacheDir string, now time.Time) ([]byte, error) {
+	if !isURL(source) {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+
+		return decompressFeed(content)
+	}
+
+	cacheFile := filepath.Join(cacheDir, imageFileName(source))
+
+	if info, err := os.Stat(cacheFile); err == nil && now.Sub(info.ModTime()) < feedMaxAge {
+		content, err := os.ReadFile(cacheFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return decompressFeed(content)
+	}
+
+	content, downloadErr := downloadFeed(source)
+	if downloadErr != nil {
+		cachedContent, err := os.ReadFile(cacheFile)
+		if err != nil {
+			return nil, downloadErr
+		}
+
+		log.Printf("WARNING: Failed to download %s, using cached copy: %s\n", source, downloadErr)
+		return decompressFeed(cachedContent)
+	}
+
+	if err := writeFileAtomically(cacheFile, content); err != nil {
+		log.Printf("WARNING: Failed to cache %s: %s\n", source, err)
+	}
+
+	return decompressFeed(content)
+}
+
+func downloadFeed(url string) ([]byte, error) {
+	client := &http.Client{Timeout: feedDownloadTimeout}
+
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code %d", response.StatusCode)
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+// writeFileAtomically writes the file through a temporary file,
+// so that concurrent scans never read a partially written file.
+func writeFileAtomically(fileName string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), fileName)
+}
+
+func decompressFeed(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		return content, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// parseEPSS parses EPSS scores from the CSV format published by FIRST,
+// or from the JSON format returned by the EPSS API.
+func parseEPSS(content []byte) (map[string]EPSSScore, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		return parseEPSSJSON(content)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]EPSSScore, len(records))
+	for _, record := range records {
+		if len(record) < 3 || record[0] == "cve" {
+			continue
+		}
+
+		score, err := parseEPSSScore(record[1], record[2])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid score for %s: %w", record[0], err)
+		}
+
+		scores[record[0]] = score
+	}
+
+	return scores, nil
+}
+
+func parseEPSSJSON(content []byte) (map[string]EPSSScore, error) {
+	var response struct {
+		Data []struct {
+			CVE        string `json:"cve"`
+			EPSS       string `json:"epss"`
+			Percentile string `json:"percentile"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(content, &response); err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]EPSSScore, len(response.Data))
+	for _, entry := range response.Data {
+		score, err := parseEPSSScore(entry.EPSS, entry.Percentile)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid score for %s: %w", entry.CVE, err)
+		}
+
+		scores[entry.CVE] = score
+	}
+
+	return scores, nil
+}
+
+func parseEPSSScore(probability string, percentile string) (EPSSScore, error) {
+	parsedProbability, err := strconv.ParseFloat(probability, 64)
+	if err != nil {
+		return EPSSScore{}, err
+	}
+
+	parsedPercentile, err := strconv.ParseFloat(percentile, 64)
+	if err != nil {
+		return EPSSScore{}, err
+	}
+
+	return EPSSScore{Probability: parsedProbability, Percentile: parsedPercentile}, nil
+}
+
+// enrichResult sets the EPSS score and known exploited flag of each vulnerability in the result.
+func enrichResult(result *TrivyResult, enrichment *Enrichment) {
+	if enrichment == nil {
+		return
+	}
+
+	for i := range result.Results {
+		for j := range result.Results[i].Vulnerabilities {
+			vulnerability := &result.Results[i].Vulnerabilities[j]
+
+			if score, ok := enrichment.EPSS[vulnerability.VulnerabilityID]; ok {
+				vulnerability.EPSS = &score
+			}
+
+			if enrichment.KnownExploited[vulnerability.VulnerabilityID] {
+				vulnerability.KnownExploited = true
+			}
+		}
+	}
+}
+
+func epssProbability(vulnerability TrivyVulnerability) float64 {
+	if vulnerability.EPSS == nil {
+		return -1
+	}
+
+	return vulnerability.EPSS.Probability
+}
+
+// compareByEPSS orders known exploited vulnerabilities first, then by descending EPSS probability,
+// and finally by severity.
+func compareByEPSS(a, b TrivyVulnerability) int {
+	if a.KnownExploited != b.KnownExploited {
+		if a.KnownExploited {
+			return -1
+		}
+		return 1
+	}
+
+	if result := cmp.Compare(epssProbability(b), epssProbability(a)); result != 0 {
+		return result
+	}
+
+	return severityRank(a.Severity) - severityRank(b.Severity)
+}
+
+func compareBySeverity(a, b TrivyVulnerability) int {
+	return severityRank(a.Severity) - severityRank(b.Severity)
+}
+
+func vulnerabilityComparison(sortBy string) func(a, b TrivyVulnerability) int {
+	if sortBy == SortByEPSS {
+		return compareByEPSS
+	}
+
+	return compareBySeverity
+}
+
+func sortByEPSS(vulnerabilities []TrivyVulnerability) []TrivyVulnerability {
+	sorted := slices.Clone(vulnerabilities)
+	slices.SortStableFunc(sorted, compareByEPSS)
+
+	return sorted
+}
+
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+}
+
+// formatEnrichedTable renders vulnerabilities with their EPSS score and known exploited flag,
+// as Trivy's table output does not include them.
+func formatEnrichedTable(result TrivyResult, sortBy string) string {
+	vulnerabilities := slices.Clone(allVulnerabilities(result.Results))
+	slices.SortStableFunc(vulnerabilities, vulnerabilityComparison(sortBy))
+
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VULNERABILITY\tPACKAGE\tSEVERITY\tEPSS\tPERCENTILE\tKEV")
+
+	for _, vulnerability := range vulnerabilities {
+		epss, percentile := "-", "-"
+		if vulnerability.EPSS != nil {
+			epss = formatPercent(vulnerability.EPSS.Probability)
+			percentile = formatPercent(vulnerability.EPSS.Percentile)
+		}
+
+		knownExploited := "-"
+		if vulnerability.KnownExploited {
+			knownExploited = "yes"
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			vulnerability.VulnerabilityID,
+			vulnerability.PkgName,
+			vulnerability.Severity,
+			epss,
+			percentile,
+			knownExploited,
+		)
+	}
+
+	writer.Flush()
+
+	return buffer.String()
+}
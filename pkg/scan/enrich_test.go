@@ -0,0 +1,234 @@
+package scan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEPSSCSV = `#model_version:v2023.03.01,score_date:2024-07-01T00:00:00+0000
+cve,epss,percentile
+CVE-2024-0001,0.01000,0.50000
+CVE-2024-0002,0.95000,0.99900
+`
+
+const testKEVJSON = `{"vulnerabilities": [{"cveID": "CVE-2024-0001"}]}`
+
+func TestParseEPSS1(t *testing.T) {
+	scores, err := parseEPSS([]byte(testEPSSCSV))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := EPSSScore{Probability: 0.95, Percentile: 0.999}
+	if len(scores) != 2 || scores["CVE-2024-0002"] != expected {
+		t.Errorf("Expected %v for CVE-2024-0002, got %v", expected, scores)
+	}
+}
+
+func TestParseEPSS2(t *testing.T) {
+	const content = `{"status": "OK", "data": [{"cve": "CVE-2024-0001", "epss": "0.000430000", "percentile": "0.089300000"}]}`
+
+	scores, err := parseEPSS([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := EPSSScore{Probability: 0.00043, Percentile: 0.0893}
+	if scores["CVE-2024-0001"] != expected {
+		t.Errorf("Expected %v for CVE-2024-0001, got %v", expected, scores)
+	}
+}
+
+func TestParseKnownExploitedVulnerabilities(t *testing.T) {
+	const content = `{"vulnerabilities": [{"cveID": "CVE-2024-0001"}, {"cveID": "CVE-2021-44228"}]}`
+
+	knownExploited, err := parseKnownExploitedVulnerabilities([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if !knownExploited["CVE-2021-44228"] || knownExploited["CVE-2024-0002"] {
+		t.Errorf("Expected only listed vulnerabilities to be known exploited, got %v", knownExploited)
+	}
+}
+
+func TestReadFeed1(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(testEPSSCSV)); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	writer.Close()
+
+	feedFile := filepath.Join(t.TempDir(), "epss.csv.gz")
+	if err := os.WriteFile(feedFile, compressed.Bytes(), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	content, err := readFeed(feedFile, t.TempDir(), time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if string(content) != testEPSSCSV {
+		t.Errorf("Expected decompressed feed, got %s", content)
+	}
+}
+
+func TestReadFeed2(t *testing.T) {
+	requests := 0
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testKEVJSON))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	now := time.Now()
+
+	for range 2 {
+		content, err := readFeed(server.URL+"/kev.json", cacheDir, now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if string(content) != testKEVJSON {
+			t.Errorf("Expected %s, got %s", testKEVJSON, content)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected %d request with a fresh cache, got %d", 1, requests)
+	}
+
+	// The cache is outdated and the feed is unavailable, so the cached copy is used.
+	available = false
+	content, err := readFeed(server.URL+"/kev.json", cacheDir, now.Add(2*feedMaxAge))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if string(content) != testKEVJSON || requests != 2 {
+		t.Errorf("Expected cached copy after a failed download, got %s after %d requests", content, requests)
+	}
+}
+
+func testEnrichedResult(t *testing.T) TrivyResult {
+	directory := t.TempDir()
+	epssFile := filepath.Join(directory, "epss.csv")
+	kevFile := filepath.Join(directory, "kev.json")
+	if err := os.WriteFile(epssFile, []byte(testEPSSCSV), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if err := os.WriteFile(kevFile, []byte(testKEVJSON), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	enrichment, err := loadEnrichment(EnrichmentOptions{EPSSFeed: epssFile, KEVFeed: kevFile}, directory, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	result := testPolicyResult(t)
+	enrichResult(&result, enrichment)
+
+	return result
+}
+
+func TestEnrichResult(t *testing.T) {
+	result := testEnrichedResult(t)
+
+	vulnerabilities := result.Results[0].Vulnerabilities
+	if !vulnerabilities[0].KnownExploited || vulnerabilities[1].KnownExploited {
+		t.Errorf("Expected only CVE-2024-0001 to be known exploited, got %v", vulnerabilities)
+	}
+
+	if vulnerabilities[1].EPSS == nil || vulnerabilities[1].EPSS.Probability != 0.95 {
+		t.Errorf("Expected EPSS probability 0.95 for CVE-2024-0002, got %v", vulnerabilities[1].EPSS)
+	}
+
+	if result.Results[1].Vulnerabilities[0].EPSS != nil {
+		t.Errorf("Expected no EPSS score for CVE-2024-0003, got %v", result.Results[1].Vulnerabilities[0].EPSS)
+	}
+}
+
+func TestSortByEPSS(t *testing.T) {
+	result := testEnrichedResult(t)
+
+	sorted := sortByEPSS(allVulnerabilities(result.Results))
+
+	expectedOrder := []string{"CVE-2024-0001", "CVE-2024-0002", "CVE-2024-0003", "CVE-2024-0004"}
+	for i, expected := range expectedOrder {
+		if sorted[i].VulnerabilityID != expected {
+			t.Errorf("Expected %s at position %d, got %s", expected, i, sorted[i].VulnerabilityID)
+		}
+	}
+}
+
+func TestToMarkdownWithEnrichment(t *testing.T) {
+	result := testEnrichedResult(t)
+
+	markdown, err := toMarkdown(toTrivyVulnerabilityResultsWithArtifactName(result), nil, SortByEPSS)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expectedSubstrings := []string{
+		":rotating_light: **1** of the vulnerabilities are known to be exploited.",
+		"<summary><b>CRITICAL</b> :rotating_light: <b>KNOWN EXPLOITED</b> CVE-2024-0001 in <code>libcrypto3</code></summary>",
+		"**EPSS**: 95.00% (percentile 99.90%)",
+	}
+	for _, expected := range expectedSubstrings {
+		if !strings.Contains(string(markdown), expected) {
+			t.Errorf("Expected markdown to contain %s, got %s", expected, markdown)
+		}
+	}
+}
+
+func TestFormatEnrichedTable(t *testing.T) {
+	result := testEnrichedResult(t)
+
+	lines := strings.Split(strings.TrimSpace(formatEnrichedTable(result, SortByEPSS)), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected header and %d rows, got %v", 4, lines)
+	}
+
+	expectedFields := []string{"CVE-2024-0002", "libssl3", "HIGH", "95.00%", "99.90%", "-"}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != strings.Join(expectedFields, " ") {
+		t.Errorf("Expected %v, got %v", expectedFields, fields)
+	}
+}
+
+func TestEvaluatePolicyWithEPSS(t *testing.T) {
+	policy := Policy{
+		DefaultAction: PolicyActionIgnore,
+		Rules: []PolicyRule{
+			{Name: "likely-exploited", Action: PolicyActionFail, MinEPSS: 0.5},
+			{Name: "known-exploited", Action: PolicyActionFail, KnownExploited: boolPointer(true)},
+		},
+	}
+
+	policyResult := evaluatePolicy(policy, testEnrichedResult(t), testPolicyNow)
+
+	expectedActions := map[string]string{
+		"CVE-2024-0001": PolicyActionFail,
+		"CVE-2024-0002": PolicyActionFail,
+		"CVE-2024-0003": PolicyActionIgnore,
+		"CVE-2024-0004": PolicyActionIgnore,
+	}
+	for _, finding := range policyResult.Findings {
+		if finding.Action != expectedActions[finding.VulnerabilityID] {
+			t.Errorf("Expected %s for %s, got %s", expectedActions[finding.VulnerabilityID], finding.VulnerabilityID, finding)
+		}
+	}
+}
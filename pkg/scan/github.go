@@ -0,0 +1,281 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+const gitHubRequestTimeout = 30 * time.Second
+
+// Hidden marker used to find the comment previously posted for the same image,
+// so that we update a single sticky comment instead of posting a new one on every run.
+func commentMarker(imageName string) string {
+	return fmt.Sprintf("<!-- 3lv-scan: %s -->", imageName)
+}
+
+func noVulnerabilitiesMarkdown(imageName string) []byte {
+	return []byte(fmt.Sprintf("# :white_check_mark: No vulnerabilities detected in %s\n", imageName))
+}
+
+type GitHubEnvironment struct {
+	APIURL            string
+	Token             string
+	Repository        string
+	PullRequestNumber int
+	StepSummaryFile   string
+}
+
+func isRunningInGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+func getGitHubEnvironment() GitHubEnvironment {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	return GitHubEnvironment{
+		APIURL:            strings.TrimSuffix(apiURL, "/"),
+		Token:             token,
+		Repository:        os.Getenv("GITHUB_REPOSITORY"),
+		PullRequestNumber: findPullRequestNumber(os.Getenv("GITHUB_EVENT_PATH"), os.Getenv("GITHUB_REF")),
+		StepSummaryFile:   os.Getenv("GITHUB_STEP_SUMMARY"),
+	}
+}
+
+// findPullRequestNumber returns the pull request number from the GitHub event payload,
+// falling back to parsing refs/pull/<number>/merge. Returns 0 if not running for a pull request.
+func findPullRequestNumber(eventPath string, ref string) int {
+	if eventPath != "" {
+		eventFile, err := os.ReadFile(eventPath)
+		if err == nil {
+			var event struct {
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+
+			if err := json.Unmarshal(eventFile, &event); err == nil && event.PullRequest.Number != 0 {
+				return event.PullRequest.Number
+			}
+		}
+	}
+
+	if strings.HasPrefix(ref, "refs/pull/") {
+		number, err := strconv.Atoi(strings.Split(strings.TrimPrefix(ref, "refs/pull/"), "/")[0])
+		if err == nil {
+			return number
+		}
+	}
+
+	return 0
+}
+
+// reportToGitHub writes the scan results to the job summary and comments them on the pull request.
+// Only failing to write the job summary is an error.
+func reportToGitHub(
+	imageName string,
+	markdown []byte,
+	environment GitHubEnvironment,
+) error {
+	if environment.StepSummaryFile != "" {
+		log.Println("Writing scan results to GitHub job summary")
+
+		if err := writeStepSummary(environment.StepSummaryFile, imageName, markdown); err != nil {
+			return err
+		}
+	}
+
+	if environment.PullRequestNumber == 0 {
+		log.Println("Not running for a pull request, will not comment scan results")
+		return nil
+	}
+
+	// Commenting is best effort: e.g. pull requests from forks only get a read-only token.
+	if environment.Token == "" || environment.Repository == "" {
+		log.Println("WARNING: GITHUB_TOKEN (or GH_TOKEN) and GITHUB_REPOSITORY must be set to comment scan results on pull requests, will not comment")
+		return nil
+	}
+
+	log.Printf("Commenting scan results on pull request #%d\n", environment.PullRequestNumber)
+
+	if err := upsertPullRequestComment(imageName, markdown, environment); err != nil {
+		log.Printf("WARNING: Failed to comment scan results on pull request #%d: %s\n", environment.PullRequestNumber, err)
+	}
+
+	return nil
+}
+
+func writeStepSummary(stepSummaryFile string, imageName string, markdown []byte) error {
+	file, err := os.OpenFile(stepSummaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open GitHub job summary file: %w", err)
+	}
+
+	defer file.Close()
+
+	content := markdown
+	if len(content) == 0 {
+		content = noVulnerabilitiesMarkdown(imageName)
+	}
+
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("Failed to write GitHub job summary: %w", err)
+	}
+
+	return nil
+}
+
+type GitHubComment struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+func upsertPullRequestComment(
+	imageName string,
+	markdown []byte,
+	environment GitHubEnvironment,
+) error {
+	marker := commentMarker(imageName)
+
+	existingComment, err := findPullRequestComment(marker, environment)
+	if err != nil {
+		return err
+	}
+
+	if len(markdown) == 0 {
+		if existingComment == nil {
+			log.Println("No vulnerabilities found, will not comment on pull request")
+			return nil
+		}
+
+		markdown = noVulnerabilitiesMarkdown(imageName)
+	}
+
+	body, err := json.Marshal(GitHubComment{Body: marker + "\n" + string(markdown)})
+	if err != nil {
+		return err
+	}
+
+	if existingComment != nil {
+		_, err := sendGitHubRequest(
+			http.MethodPatch,
+			fmt.Sprintf("%s/repos/%s/issues/comments/%d", environment.APIURL, environment.Repository, existingComment.ID),
+			environment.Token,
+			body,
+		)
+		if err != nil {
+			return fmt.Errorf("Failed to update pull request comment: %w", err)
+		}
+
+		return nil
+	}
+
+	_, err = sendGitHubRequest(
+		http.MethodPost,
+		fmt.Sprintf(
+			"%s/repos/%s/issues/%d/comments",
+			environment.APIURL,
+			environment.Repository,
+			environment.PullRequestNumber,
+		),
+		environment.Token,
+		body,
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to create pull request comment: %w", err)
+	}
+
+	return nil
+}
+
+func findPullRequestComment(marker string, environment GitHubEnvironment) (*GitHubComment, error) {
+	const perPage = 100
+
+	for page := 1; ; page++ {
+		response, err := sendGitHubRequest(
+			http.MethodGet,
+			fmt.Sprintf(
+				"%s/repos/%s/issues/%d/comments?per_page=%d&page=%d",
+				environment.APIURL,
+				environment.Repository,
+				environment.PullRequestNumber,
+				perPage,
+				page,
+			),
+			environment.Token,
+			nil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list pull request comments: %w", err)
+		}
+
+		var comments []GitHubComment
+		if err := json.Unmarshal(response, &comments); err != nil {
+			return nil, fmt.Errorf("Failed to parse pull request comments: %w", err)
+		}
+
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, marker) {
+				return &comment, nil
+			}
+		}
+
+		if len(comments) < perPage {
+			return nil, nil
+		}
+	}
+}
+
+func sendGitHubRequest(
+	method string,
+	url string,
+	token string,
+	body []byte,
+) ([]byte, error) {
+	client := &http.Client{Timeout: gitHubRequestTimeout}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("GitHub returned status code %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	return responseBody, nil
+}
@@ -0,0 +1,275 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeGitHubAPI struct {
+	mu       sync.Mutex
+	comments []GitHubComment
+	nextID   int64
+	requests []string
+}
+
+func newFakeGitHubAPI(t *testing.T, comments []GitHubComment) (*fakeGitHubAPI, *httptest.Server) {
+	api := &fakeGitHubAPI{comments: comments, nextID: 1000}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/3lvia/test-repo/issues/42/comments", func(w http.ResponseWriter, r *http.Request) {
+		api.record(r)
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(api.comments)
+	})
+	mux.HandleFunc("POST /repos/3lvia/test-repo/issues/42/comments", func(w http.ResponseWriter, r *http.Request) {
+		api.record(r)
+
+		var comment GitHubComment
+		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		comment.ID = api.nextID
+		api.nextID++
+		api.comments = append(api.comments, comment)
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(comment)
+	})
+	mux.HandleFunc("PATCH /repos/3lvia/test-repo/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		api.record(r)
+
+		var comment GitHubComment
+		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		for i := range api.comments {
+			if fmt.Sprint(api.comments[i].ID) == r.PathValue("id") {
+				api.comments[i].Body = comment.Body
+				_ = json.NewEncoder(w).Encode(api.comments[i])
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return api, server
+}
+
+func (api *fakeGitHubAPI) record(r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.requests = append(api.requests, r.Method+" "+r.URL.Path)
+}
+
+func testGitHubEnvironment(server *httptest.Server) GitHubEnvironment {
+	return GitHubEnvironment{
+		APIURL:            server.URL,
+		Token:             "test-token",
+		Repository:        "3lvia/test-repo",
+		PullRequestNumber: 42,
+	}
+}
+
+func TestUpsertPullRequestComment1(t *testing.T) {
+	api, server := newFakeGitHubAPI(t, []GitHubComment{{ID: 1, Body: "Unrelated comment"}})
+
+	err := upsertPullRequestComment(
+		"test-image:latest",
+		[]byte("# Vulnerabilities"),
+		testGitHubEnvironment(server),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(api.comments) != 2 {
+		t.Fatalf("Expected %d comments, got %d", 2, len(api.comments))
+	}
+
+	expectedBody := commentMarker("test-image:latest") + "\n# Vulnerabilities"
+	if api.comments[1].Body != expectedBody {
+		t.Errorf("Expected %s, got %s", expectedBody, api.comments[1].Body)
+	}
+}
+
+func TestUpsertPullRequestComment2(t *testing.T) {
+	api, server := newFakeGitHubAPI(
+		t,
+		[]GitHubComment{
+			{ID: 1, Body: "Unrelated comment"},
+			{ID: 2, Body: commentMarker("test-image:latest") + "\n# Old vulnerabilities"},
+		},
+	)
+
+	err := upsertPullRequestComment(
+		"test-image:latest",
+		[]byte("# New vulnerabilities"),
+		testGitHubEnvironment(server),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(api.comments) != 2 {
+		t.Fatalf("Expected %d comments, got %d", 2, len(api.comments))
+	}
+
+	expectedBody := commentMarker("test-image:latest") + "\n# New vulnerabilities"
+	if api.comments[1].Body != expectedBody {
+		t.Errorf("Expected %s, got %s", expectedBody, api.comments[1].Body)
+	}
+
+	if api.requests[len(api.requests)-1] != "PATCH /repos/3lvia/test-repo/issues/comments/2" {
+		t.Errorf("Expected existing comment to be updated, got requests %v", api.requests)
+	}
+}
+
+func TestUpsertPullRequestComment3(t *testing.T) {
+	api, server := newFakeGitHubAPI(t, nil)
+
+	err := upsertPullRequestComment(
+		"test-image:latest",
+		[]byte{},
+		testGitHubEnvironment(server),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(api.comments) != 0 {
+		t.Errorf("Expected no comment when there are no vulnerabilities, got %v", api.comments)
+	}
+}
+
+func TestUpsertPullRequestComment4(t *testing.T) {
+	_, server := newFakeGitHubAPI(t, nil)
+
+	environment := testGitHubEnvironment(server)
+	environment.Token = "wrong-token"
+
+	err := upsertPullRequestComment(
+		"test-image:latest",
+		[]byte("# Vulnerabilities"),
+		environment,
+	)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected unauthorized error, got %v", err)
+	}
+}
+
+func TestReportToGitHub1(t *testing.T) {
+	_, server := newFakeGitHubAPI(t, nil)
+
+	environment := testGitHubEnvironment(server)
+	environment.StepSummaryFile = filepath.Join(t.TempDir(), "summary.md")
+
+	if err := reportToGitHub("test-image:latest", []byte("# Vulnerabilities"), environment); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	summary, err := os.ReadFile(environment.StepSummaryFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if string(summary) != "# Vulnerabilities\n" {
+		t.Errorf("Expected %s, got %s", "# Vulnerabilities\n", string(summary))
+	}
+}
+
+func TestReportToGitHub2(t *testing.T) {
+	api, server := newFakeGitHubAPI(t, nil)
+
+	// Pull requests from forks get a read-only token, which the API rejects.
+	environment := testGitHubEnvironment(server)
+	environment.Token = "read-only-token"
+	environment.StepSummaryFile = filepath.Join(t.TempDir(), "summary.md")
+
+	if err := reportToGitHub("test-image:latest", []byte("# Vulnerabilities"), environment); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(api.comments) != 0 {
+		t.Errorf("Expected no comment, got %v", api.comments)
+	}
+
+	if _, err := os.Stat(environment.StepSummaryFile); err != nil {
+		t.Errorf("Expected job summary to be written, got %s", err)
+	}
+}
+
+func TestReportToGitHub3(t *testing.T) {
+	_, server := newFakeGitHubAPI(t, nil)
+
+	environment := testGitHubEnvironment(server)
+	environment.Token = ""
+
+	if err := reportToGitHub("test-image:latest", []byte("# Vulnerabilities"), environment); err != nil {
+		t.Errorf("Expected no error without token, got %s", err)
+	}
+}
+
+func TestReportToGitHub4(t *testing.T) {
+	environment := GitHubEnvironment{StepSummaryFile: t.TempDir()}
+
+	if err := reportToGitHub("test-image:latest", []byte("# Vulnerabilities"), environment); err == nil {
+		t.Errorf("Expected error writing job summary to a directory")
+	}
+}
+
+func TestFindPullRequestNumber1(t *testing.T) {
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(eventPath, []byte(`{"pull_request": {"number": 1337}}`), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	const expected = 1337
+	actual := findPullRequestNumber(eventPath, "refs/heads/trunk")
+
+	if actual != expected {
+		t.Errorf("Expected %d, got %d", expected, actual)
+	}
+}
+
+func TestFindPullRequestNumber2(t *testing.T) {
+	const expected = 42
+	actual := findPullRequestNumber("", "refs/pull/42/merge")
+
+	if actual != expected {
+		t.Errorf("Expected %d, got %d", expected, actual)
+	}
+}
+
+func TestFindPullRequestNumber3(t *testing.T) {
+	const expected = 0
+	actual := findPullRequestNumber("", "refs/heads/trunk")
+
+	if actual != expected {
+		t.Errorf("Expected %d, got %d", expected, actual)
+	}
+}
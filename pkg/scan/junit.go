@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/utils"
+)
+
+type JUnitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func toJUnit(result TrivyResult) ([]byte, error) {
+	testSuites := JUnitTestSuites{
+		Name: result.ArtifactName,
+	}
+
+	for _, target := range result.Results {
+		// License results are not vulnerabilities, and would show up as passing test suites.
+		if len(target.Licenses) > 0 && len(target.Vulnerabilities) == 0 {
+			continue
+		}
+
+		testSuite := JUnitTestSuite{
+			Name: target.Target,
+		}
+
+		// Targets without vulnerabilities are reported as a single passing test case,
+		// so that the target still shows up in the test dashboard.
+		if len(target.Vulnerabilities) == 0 {
+			testSuite.TestCases = append(testSuite.TestCases, JUnitTestCase{
+				Name:      "No vulnerabilities found",
+				ClassName: target.Target,
+			})
+		}
+
+		for _, vulnerability := range target.Vulnerabilities {
+			fixedVersion := utils.StringWithDefault(vulnerability.FixedVersion, "none")
+
+			testSuite.TestCases = append(testSuite.TestCases, JUnitTestCase{
+				Name: fmt.Sprintf(
+					"[%s] %s: %s",
+					vulnerability.Severity,
+					vulnerability.PkgName,
+					vulnerability.VulnerabilityID,
+				),
+				ClassName: target.Target,
+				Failure: &JUnitFailure{
+					Message: vulnerability.Title,
+					Type:    vulnerability.Severity,
+					Text: strings.Join(
+						[]string{
+							"Vulnerability ID: " + vulnerability.VulnerabilityID,
+							"Severity: " + vulnerability.Severity,
+							"Package Name: " + vulnerability.PkgName,
+							"Installed Version: " + vulnerability.InstalledVersion,
+							"Fixed Version: " + fixedVersion,
+							"Primary URL: " + vulnerability.PrimaryURL,
+							"",
+							vulnerability.Description,
+						},
+						"\n",
+					),
+				},
+			})
+			testSuite.Failures++
+		}
+
+		testSuite.Tests = len(testSuite.TestCases)
+
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+		testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+	}
+
+	junit, err := xml.MarshalIndent(testSuites, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal JUnit XML: %v", err)
+	}
+
+	return append([]byte(xml.Header), junit...), nil
+}
@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+const testTrivyJSON = `{
+  "ArtifactName": "test-image:latest",
+  "Results": [
+    {
+      "Target": "test-image:latest (alpine 3.20.0)",
+      "Class": "os-pkgs",
+      "Type": "alpine",
+      "Vulnerabilities": [
+        {
+          "VulnerabilityID": "CVE-2024-0001",
+          "PkgName": "libcrypto3",
+          "InstalledVersion": "3.3.0-r0",
+          "FixedVersion": "3.3.0-r1",
+          "Title": "openssl: something bad",
+          "Description": "A very bad vulnerability.",
+          "Severity": "CRITICAL"
+        },
+        {
+          "VulnerabilityID": "CVE-2024-0002",
+          "PkgName": "libssl3",
+          "InstalledVersion": "3.3.0-r0",
+          "Title": "openssl: something less bad",
+          "Description": "A less bad vulnerability.",
+          "Severity": "HIGH"
+        }
+      ]
+    },
+    {
+      "Target": "app/executable",
+      "Class": "lang-pkgs",
+      "Type": "gobinary"
+    }
+  ]
+}`
+
+func TestToJUnit1(t *testing.T) {
+	var result TrivyResult
+	if err := json.Unmarshal([]byte(testTrivyJSON), &result); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	junit, err := toJUnit(result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	var testSuites JUnitTestSuites
+	if err := xml.Unmarshal(junit, &testSuites); err != nil {
+		t.Fatalf("Expected valid JUnit XML, got %s", err)
+	}
+
+	if testSuites.Name != "test-image:latest" {
+		t.Errorf("Expected name %s, got %s", "test-image:latest", testSuites.Name)
+	}
+
+	if testSuites.Tests != 3 {
+		t.Errorf("Expected %d tests, got %d", 3, testSuites.Tests)
+	}
+
+	if testSuites.Failures != 2 {
+		t.Errorf("Expected %d failures, got %d", 2, testSuites.Failures)
+	}
+
+	if len(testSuites.TestSuites) != 2 {
+		t.Fatalf("Expected %d test suites, got %d", 2, len(testSuites.TestSuites))
+	}
+
+	passingSuite := testSuites.TestSuites[1]
+	if passingSuite.Failures != 0 || passingSuite.TestCases[0].Failure != nil {
+		t.Errorf("Expected target without vulnerabilities to pass, got %+v", passingSuite)
+	}
+}
+
+func TestToJUnit2(t *testing.T) {
+	var result TrivyResult
+	if err := json.Unmarshal([]byte(testTrivyJSON), &result); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	junit, err := toJUnit(result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	var testSuites JUnitTestSuites
+	if err := xml.Unmarshal(junit, &testSuites); err != nil {
+		t.Fatalf("Expected valid JUnit XML, got %s", err)
+	}
+
+	testCases := testSuites.TestSuites[0].TestCases
+
+	const expectedName = "[CRITICAL] libcrypto3: CVE-2024-0001"
+	if testCases[0].Name != expectedName {
+		t.Errorf("Expected %s, got %s", expectedName, testCases[0].Name)
+	}
+
+	if testCases[0].Failure.Type != "CRITICAL" {
+		t.Errorf("Expected failure type %s, got %s", "CRITICAL", testCases[0].Failure.Type)
+	}
+
+	const expectedText = "Vulnerability ID: CVE-2024-0002\n" +
+		"Severity: HIGH\n" +
+		"Package Name: libssl3\n" +
+		"Installed Version: 3.3.0-r0\n" +
+		"Fixed Version: none\n" +
+		"Primary URL: \n" +
+		"\n" +
+		"A less bad vulnerability."
+	if testCases[1].Failure.Text != expectedText {
+		t.Errorf("Expected %s, got %s", expectedText, testCases[1].Failure.Text)
+	}
+}
@@ -0,0 +1,221 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+const (
+	LicenseActionAllow = "allow"
+	LicenseActionWarn  = "warn"
+	LicenseActionDeny  = "deny"
+)
+
+// LicenseConfig lists SPDX license identifiers to allow, warn about or deny.
+// Identifiers are matched ignoring case, and a trailing * matches any suffix, e.g. GPL-* matches GPL-3.0-only.
+// Licenses not in any list are allowed, unless an allow list is given, in which case they are warned about.
+type LicenseConfig struct {
+	Allow []string
+	Warn  []string
+	Deny  []string
+}
+
+type LicenseFinding struct {
+	Target   string
+	PkgName  string
+	FilePath string
+	License  string
+	Action   string
+}
+
+func licenseScanCommand(
+	imageName string,
+	outputFile string,
+	options *ScanImageCommandOptions,
+) command.Output {
+	if options == nil {
+		options = &ScanImageCommandOptions{}
+	}
+
+	cmd := exec.Command(
+		"trivy",
+		"image",
+		"--scanners",
+		"license",
+		// Which licenses fail the scan is decided by the license lists, not Trivy's license categories.
+		"--severity",
+		"UNKNOWN,LOW,MEDIUM,HIGH,CRITICAL",
+		"--exit-code",
+		"0",
+		"--timeout",
+		"15m0s",
+		"--format",
+		"json",
+		"--output",
+		outputFile,
+	)
+
+	if options.InMemoryCache {
+		cmd.Args = append(cmd.Args, "--cache-backend", "memory")
+	}
+
+	if options.DB.CacheDir != "" {
+		cmd.Args = append(cmd.Args, "--cache-dir", options.DB.CacheDir)
+	}
+
+	cmd.Args = append(cmd.Args, imageArguments(imageName, options.Input)...)
+
+	return command.Run(*cmd, options.RunOptions)
+}
+
+// mergeLicenseResults appends the results of the license scan to the results of the vulnerability scan,
+// so that all outputs converted from the JSON file include licenses.
+// Raw JSON is used to avoid dropping any fields not modelled by TrivyResult.
+func mergeLicenseResults(jsonFile string, licensesFile string) error {
+	readReport := func(fileName string) (map[string]json.RawMessage, []json.RawMessage, error) {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var report map[string]json.RawMessage
+		if err := json.Unmarshal(content, &report); err != nil {
+			return nil, nil, fmt.Errorf("Failed to parse %s: %w", fileName, err)
+		}
+
+		var results []json.RawMessage
+		if rawResults, ok := report["Results"]; ok {
+			if err := json.Unmarshal(rawResults, &results); err != nil {
+				return nil, nil, fmt.Errorf("Failed to parse results in %s: %w", fileName, err)
+			}
+		}
+
+		return report, results, nil
+	}
+
+	report, results, err := readReport(jsonFile)
+	if err != nil {
+		return err
+	}
+
+	_, licenseResults, err := readReport(licensesFile)
+	if err != nil {
+		return err
+	}
+
+	mergedResults, err := json.Marshal(append(results, licenseResults...))
+	if err != nil {
+		return err
+	}
+	report["Results"] = mergedResults
+
+	mergedReport, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(jsonFile, mergedReport, 0644)
+}
+
+func matchesLicense(patterns []string, license string) bool {
+	for _, pattern := range patterns {
+		if prefix, isWildcard := strings.CutSuffix(pattern, "*"); isWildcard {
+			if strings.HasPrefix(strings.ToLower(license), strings.ToLower(prefix)) {
+				return true
+			}
+		} else if strings.EqualFold(pattern, license) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func licenseAction(config LicenseConfig, license string) string {
+	switch {
+	case matchesLicense(config.Deny, license):
+		return LicenseActionDeny
+	case matchesLicense(config.Warn, license):
+		return LicenseActionWarn
+	case matchesLicense(config.Allow, license):
+		return LicenseActionAllow
+	case len(config.Allow) > 0:
+		return LicenseActionWarn
+	default:
+		return LicenseActionAllow
+	}
+}
+
+func evaluateLicenses(config LicenseConfig, result TrivyResult) []LicenseFinding {
+	var findings []LicenseFinding
+	for _, target := range result.Results {
+		for _, license := range target.Licenses {
+			findings = append(findings, LicenseFinding{
+				Target:   target.Target,
+				PkgName:  license.PkgName,
+				FilePath: license.FilePath,
+				License:  license.Name,
+				Action:   licenseAction(config, license.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+func countLicenseAction(findings []LicenseFinding, action string) int {
+	count := 0
+	for _, finding := range findings {
+		if finding.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+// reportedLicenses returns the license findings which are warned about or denied.
+func reportedLicenses(findings []LicenseFinding) []LicenseFinding {
+	var reported []LicenseFinding
+	for _, finding := range findings {
+		if finding.Action != LicenseActionAllow {
+			reported = append(reported, finding)
+		}
+	}
+
+	return reported
+}
+
+func (finding LicenseFinding) String() string {
+	source := finding.PkgName
+	if source == "" {
+		source = finding.FilePath
+	}
+
+	return fmt.Sprintf(
+		"%s license %s in %s (%s)",
+		strings.ToUpper(finding.Action),
+		finding.License,
+		source,
+		finding.Target,
+	)
+}
+
+func logLicenseFindings(findings []LicenseFinding) {
+	for _, finding := range reportedLicenses(findings) {
+		log.Println(finding)
+	}
+
+	log.Printf(
+		"License result: %d denied, %d warnings, %d allowed\n",
+		countLicenseAction(findings, LicenseActionDeny),
+		countLicenseAction(findings, LicenseActionWarn),
+		countLicenseAction(findings, LicenseActionAllow),
+	)
+}
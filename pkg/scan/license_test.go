@@ -0,0 +1,181 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/3lvia/cli/pkg/command"
+)
+
+func TestLicenseAction1(t *testing.T) {
+	config := LicenseConfig{
+		Deny: []string{"GPL-*", "AGPL-*"},
+		Warn: []string{"LGPL-2.1-only"},
+	}
+
+	expected := map[string]string{
+		"GPL-3.0-only":  LicenseActionDeny,
+		"agpl-3.0":      LicenseActionDeny,
+		"LGPL-2.1-only": LicenseActionWarn,
+		"MIT":           LicenseActionAllow,
+	}
+
+	for license, expectedAction := range expected {
+		actualAction := licenseAction(config, license)
+		if actualAction != expectedAction {
+			t.Errorf("Expected %s for %s, got %s", expectedAction, license, actualAction)
+		}
+	}
+}
+
+func TestLicenseAction2(t *testing.T) {
+	config := LicenseConfig{
+		Allow: []string{"MIT", "Apache-2.0", "BSD-*"},
+		Deny:  []string{"GPL-*"},
+	}
+
+	expected := map[string]string{
+		"MIT":          LicenseActionAllow,
+		"BSD-3-Clause": LicenseActionAllow,
+		"GPL-2.0":      LicenseActionDeny,
+		// Not in any list, but an allow list is given.
+		"MPL-2.0": LicenseActionWarn,
+	}
+
+	for license, expectedAction := range expected {
+		actualAction := licenseAction(config, license)
+		if actualAction != expectedAction {
+			t.Errorf("Expected %s for %s, got %s", expectedAction, license, actualAction)
+		}
+	}
+}
+
+func TestEvaluateLicenses(t *testing.T) {
+	const trivyJSON = `{
+  "ArtifactName": "test-image:latest",
+  "Results": [
+    {
+      "Target": "OS Packages",
+      "Class": "license",
+      "Licenses": [
+        { "PkgName": "bash", "Name": "GPL-3.0-or-later" },
+        { "PkgName": "musl", "Name": "MIT" }
+      ]
+    }
+  ]
+}`
+
+	var result TrivyResult
+	if err := json.Unmarshal([]byte(trivyJSON), &result); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	findings := evaluateLicenses(LicenseConfig{Deny: []string{"GPL-*"}}, result)
+
+	if countLicenseAction(findings, LicenseActionDeny) != 1 {
+		t.Errorf("Expected %d denied license, got %d", 1, countLicenseAction(findings, LicenseActionDeny))
+	}
+
+	reported := reportedLicenses(findings)
+	if len(reported) != 1 || reported[0].PkgName != "bash" {
+		t.Errorf("Expected only bash to be reported, got %v", reported)
+	}
+
+	const expected = "DENY license GPL-3.0-or-later in bash (OS Packages)"
+	if reported[0].String() != expected {
+		t.Errorf("Expected %s, got %s", expected, reported[0].String())
+	}
+}
+
+func TestMergeLicenseResults(t *testing.T) {
+	directory := t.TempDir()
+	jsonFile := filepath.Join(directory, "trivy.json")
+	licensesFile := filepath.Join(directory, "trivy.licenses.json")
+
+	const vulnerabilityJSON = `{"SchemaVersion": 2, "ArtifactName": "test-image:latest", "Results": [{"Target": "alpine", "Class": "os-pkgs"}]}`
+	const licenseJSON = `{"SchemaVersion": 2, "ArtifactName": "test-image:latest", "Results": [{"Target": "OS Packages", "Class": "license", "Licenses": [{"PkgName": "bash", "Name": "GPL-3.0"}]}]}`
+
+	if err := os.WriteFile(jsonFile, []byte(vulnerabilityJSON), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if err := os.WriteFile(licensesFile, []byte(licenseJSON), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if err := mergeLicenseResults(jsonFile, licensesFile); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	result, err := readJSONOutput(jsonFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if result.SchemaVersion != 2 || len(result.Results) != 2 {
+		t.Fatalf("Expected merged report with 2 results, got %+v", result)
+	}
+
+	if result.Results[1].Licenses[0].Name != "GPL-3.0" {
+		t.Errorf("Expected license GPL-3.0, got %s", result.Results[1].Licenses[0].Name)
+	}
+}
+
+func TestToMarkdownWithLicenses(t *testing.T) {
+	licenses := []LicenseFinding{
+		{Target: "OS Packages", PkgName: "bash", License: "GPL-3.0-or-later", Action: LicenseActionDeny},
+	}
+
+	markdown, err := toMarkdown(TrivyVulnerabilityResultsWithArtifactName{ArtifactName: "test-image:latest"}, licenses, SortBySeverity)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if strings.Contains(string(markdown), "Vulnerabilities detected") {
+		t.Errorf("Expected no vulnerability section, got %s", markdown)
+	}
+
+	const expected = "| DENY | GPL-3.0-or-later | `bash` | `OS Packages` |"
+	if !strings.Contains(string(markdown), expected) {
+		t.Errorf("Expected markdown to contain %s, got %s", expected, markdown)
+	}
+}
+
+func TestLicenseScanCommand(t *testing.T) {
+	const imageName = "test-image:latest"
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"trivy",
+			"image",
+			"--scanners",
+			"license",
+			"--severity",
+			"UNKNOWN,LOW,MEDIUM,HIGH,CRITICAL",
+			"--exit-code",
+			"0",
+			"--timeout",
+			"15m0s",
+			"--format",
+			"json",
+			"--output",
+			"trivy.licenses.json",
+			imageName,
+		},
+		" ",
+	)
+
+	actualCommand := licenseScanCommand(
+		imageName,
+		"trivy.licenses.json",
+		&ScanImageCommandOptions{RunOptions: &command.RunOptions{DryRun: true}},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
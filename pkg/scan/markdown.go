@@ -1,12 +1,12 @@
 package scan
 
 import (
-	"bytes"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"text/template"
 )
 
@@ -56,58 +56,75 @@ type TrivyResult struct {
 }
 
 type TrivyVulnerabilityResult struct {
-	Target          string `json:"Target"`
-	Class           string `json:"Class"`
-	Type            string `json:"Type"`
-	Vulnerabilities []struct {
-		VulnerabilityID string `json:"VulnerabilityID"`
-		PkgID           string `json:"PkgID"`
-		PkgName         string `json:"PkgName"`
-		PkgIdentifier   struct {
-			PURL string `json:"PURL"`
-			UID  string `json:"UID"`
-		} `json:"PkgIdentifier"`
-		InstalledVersion string `json:"InstalledVersion"`
-		Status           string `json:"Status"`
-		Layer            struct {
-			Digest string `json:"Digest"`
-			DiffID string `json:"DiffID"`
-		} `json:"Layer"`
-		SeveritySource string `json:"SeveritySource"`
-		PrimaryURL     string `json:"PrimaryURL"`
-		DataSource     struct {
-			ID   string `json:"ID"`
-			Name string `json:"Name"`
-			URL  string `json:"URL"`
-		} `json:"DataSource"`
-		Title          string   `json:"Title"`
-		Description    string   `json:"Description"`
-		Severity       string   `json:"Severity"`
-		CweIDs         []string `json:"CweIDs"`
-		VendorSeverity struct {
-			Azure      int `json:"azure"`
-			Nvd        int `json:"nvd"`
-			OracleOval int `json:"oracle-oval"`
-			Photon     int `json:"photon"`
-			Redhat     int `json:"redhat"`
-			Ubuntu     int `json:"ubuntu"`
-		} `json:"VendorSeverity"`
-		CVSS struct {
-			Nvd struct {
-				V2Vector string  `json:"V2Vector"`
-				V3Vector string  `json:"V3Vector"`
-				V2Score  float64 `json:"V2Score"`
-				V3Score  float64 `json:"V3Score"`
-			} `json:"nvd"`
-			Redhat struct {
-				V3Vector string  `json:"V3Vector"`
-				V3Score  float64 `json:"V3Score"`
-			} `json:"redhat"`
-		} `json:"CVSS"`
-		References       []string `json:"References"`
-		PublishedDate    string   `json:"PublishedDate"`
-		LastModifiedDate string   `json:"LastModifiedDate"`
-	} `json:"Vulnerabilities"`
+	Target          string               `json:"Target"`
+	Class           string               `json:"Class"`
+	Type            string               `json:"Type"`
+	Vulnerabilities []TrivyVulnerability `json:"Vulnerabilities"`
+	Licenses        []TrivyLicense       `json:"Licenses"`
+}
+
+type TrivyLicense struct {
+	Severity   string  `json:"Severity"`
+	Category   string  `json:"Category"`
+	PkgName    string  `json:"PkgName"`
+	FilePath   string  `json:"FilePath"`
+	Name       string  `json:"Name"`
+	Confidence float64 `json:"Confidence"`
+	Link       string  `json:"Link"`
+}
+
+type TrivyVulnerability struct {
+	VulnerabilityID string `json:"VulnerabilityID"`
+	PkgID           string `json:"PkgID"`
+	PkgName         string `json:"PkgName"`
+	PkgIdentifier   struct {
+		PURL string `json:"PURL"`
+		UID  string `json:"UID"`
+	} `json:"PkgIdentifier"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	Status           string `json:"Status"`
+	Layer            struct {
+		Digest string `json:"Digest"`
+		DiffID string `json:"DiffID"`
+	} `json:"Layer"`
+	SeveritySource string `json:"SeveritySource"`
+	PrimaryURL     string `json:"PrimaryURL"`
+	DataSource     struct {
+		ID   string `json:"ID"`
+		Name string `json:"Name"`
+		URL  string `json:"URL"`
+	} `json:"DataSource"`
+	Title          string   `json:"Title"`
+	Description    string   `json:"Description"`
+	Severity       string   `json:"Severity"`
+	CweIDs         []string `json:"CweIDs"`
+	VendorSeverity struct {
+		Azure      int `json:"azure"`
+		Nvd        int `json:"nvd"`
+		OracleOval int `json:"oracle-oval"`
+		Photon     int `json:"photon"`
+		Redhat     int `json:"redhat"`
+		Ubuntu     int `json:"ubuntu"`
+	} `json:"VendorSeverity"`
+	CVSS struct {
+		Nvd struct {
+			V2Vector string  `json:"V2Vector"`
+			V3Vector string  `json:"V3Vector"`
+			V2Score  float64 `json:"V2Score"`
+			V3Score  float64 `json:"V3Score"`
+		} `json:"nvd"`
+		Redhat struct {
+			V3Vector string  `json:"V3Vector"`
+			V3Score  float64 `json:"V3Score"`
+		} `json:"redhat"`
+	} `json:"CVSS"`
+	References       []string `json:"References"`
+	PublishedDate    string   `json:"PublishedDate"`
+	LastModifiedDate string   `json:"LastModifiedDate"`
+	// Not reported by Trivy, set when enriching the results with EPSS and KEV data.
+	EPSS           *EPSSScore `json:"EPSS,omitempty"`
+	KnownExploited bool       `json:"KnownExploited,omitempty"`
 }
 
 type TrivyVulnerabilityResultsWithArtifactName struct {
@@ -125,53 +142,177 @@ func toTrivyVulnerabilityResultsWithArtifactName(
 		}
 	}
 
-	if len(trivyVulnerabilityResults) > 0 {
-		return TrivyVulnerabilityResultsWithArtifactName{
-			ArtifactName: result.ArtifactName,
-			Results:      trivyVulnerabilityResults,
-		}
+	return TrivyVulnerabilityResultsWithArtifactName{
+		ArtifactName: result.ArtifactName,
+		Results:      trivyVulnerabilityResults,
 	}
-
-	return TrivyVulnerabilityResultsWithArtifactName{}
 }
 
-func parseJSONOutput() (TrivyVulnerabilityResultsWithArtifactName, error) {
-	jsonFile, err := os.Open("trivy.json")
+func readJSONOutput(jsonFileName string) (TrivyResult, error) {
+	jsonFile, err := os.Open(jsonFileName)
 	if err != nil {
-		return TrivyVulnerabilityResultsWithArtifactName{}, err
+		return TrivyResult{}, err
 	}
 
 	defer jsonFile.Close()
 
 	byteValue, err := io.ReadAll(jsonFile)
 	if err != nil {
-		return TrivyVulnerabilityResultsWithArtifactName{}, err
+		return TrivyResult{}, err
 	}
 
 	var trivyResult TrivyResult
 	err = json.Unmarshal(byteValue, &trivyResult)
 	if err != nil {
-		return TrivyVulnerabilityResultsWithArtifactName{}, err
+		return TrivyResult{}, err
+	}
+
+	return trivyResult, nil
+}
+
+// GitHub rejects comments larger than 65536 characters, so we leave some headroom.
+const maxMarkdownSize = 60000
+
+type MarkdownSeverityCount struct {
+	Severity string
+	Count    int
+}
+
+type MarkdownTarget struct {
+	Target          string
+	Counts          []MarkdownSeverityCount
+	Vulnerabilities []TrivyVulnerability
+}
+
+type MarkdownReport struct {
+	ArtifactName    string
+	Totals          []MarkdownSeverityCount
+	Targets         []MarkdownTarget
+	OmittedFindings int
+	Licenses        []LicenseFinding
+	KnownExploited  int
+}
+
+func toSeverityCounts(vulnerabilities []TrivyVulnerability) []MarkdownSeverityCount {
+	counts := severityCounts(vulnerabilities)
+
+	var severityCountList []MarkdownSeverityCount
+	for _, severity := range severities {
+		severityCountList = append(severityCountList, MarkdownSeverityCount{
+			Severity: severity,
+			Count:    counts[severity],
+		})
 	}
 
-	return toTrivyVulnerabilityResultsWithArtifactName(trivyResult), nil
+	return severityCountList
 }
 
-func toMarkdown(results TrivyVulnerabilityResultsWithArtifactName) ([]byte, error) {
-	if len(results.Results) == 0 || results.ArtifactName == "" {
+// toMarkdownReport builds the data passed to the markdown template. At most maxFindings
+// vulnerabilities are included in the report, picking the first ones in the order given by sortBy,
+// i.e. the most severe ones first by default. A negative maxFindings includes all vulnerabilities.
+func toMarkdownReport(
+	results TrivyVulnerabilityResultsWithArtifactName,
+	licenses []LicenseFinding,
+	maxFindings int,
+	sortBy string,
+) MarkdownReport {
+	allResultVulnerabilities := allVulnerabilities(results.Results)
+
+	report := MarkdownReport{
+		ArtifactName: results.ArtifactName,
+		Totals:       toSeverityCounts(allResultVulnerabilities),
+		Licenses:     licenses,
+	}
+
+	for _, vulnerability := range allResultVulnerabilities {
+		if vulnerability.KnownExploited {
+			report.KnownExploited++
+		}
+	}
+
+	type rankedVulnerability struct {
+		target        int
+		vulnerability TrivyVulnerability
+	}
+
+	var ranked []rankedVulnerability
+	for i, result := range results.Results {
+		for _, vulnerability := range result.Vulnerabilities {
+			ranked = append(ranked, rankedVulnerability{target: i, vulnerability: vulnerability})
+		}
+	}
+
+	compare := vulnerabilityComparison(sortBy)
+	slices.SortStableFunc(ranked, func(a, b rankedVulnerability) int {
+		return compare(a.vulnerability, b.vulnerability)
+	})
+
+	if maxFindings >= 0 && maxFindings < len(ranked) {
+		report.OmittedFindings = len(ranked) - maxFindings
+		ranked = ranked[:maxFindings]
+	}
+
+	shownPerTarget := make([][]TrivyVulnerability, len(results.Results))
+	for _, shown := range ranked {
+		shownPerTarget[shown.target] = append(shownPerTarget[shown.target], shown.vulnerability)
+	}
+
+	for i, result := range results.Results {
+		report.Targets = append(report.Targets, MarkdownTarget{
+			Target:          result.Target,
+			Counts:          toSeverityCounts(result.Vulnerabilities),
+			Vulnerabilities: shownPerTarget[i],
+		})
+	}
+
+	return report
+}
+
+// toMarkdown renders the markdown report for the vulnerabilities, and licenses which are warned about or denied.
+// Returns an empty report if there is nothing to report.
+func toMarkdown(
+	results TrivyVulnerabilityResultsWithArtifactName,
+	licenses []LicenseFinding,
+	sortBy string,
+) ([]byte, error) {
+	if len(results.Results) == 0 && len(licenses) == 0 {
 		return []byte{}, nil
 	}
 
-	markdownTemplate, err := template.New(templateFile).ParseFS(trivyMarkdownTemplate, templateFile)
+	markdownTemplate, err := template.New(templateFile).
+		Funcs(templateFuncs).
+		ParseFS(trivyMarkdownTemplate, templateFile)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse markdown template: %v", err)
 	}
 
-	var markdownBuffer bytes.Buffer
-	err = markdownTemplate.Execute(&markdownBuffer, results)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to execute markdown template: %v", err)
-	}
+	return renderMarkdownWithinSize(markdownTemplate, results, licenses, sortBy, maxMarkdownSize)
+}
+
+func renderMarkdownWithinSize(
+	markdownTemplate *template.Template,
+	results TrivyVulnerabilityResultsWithArtifactName,
+	licenses []LicenseFinding,
+	sortBy string,
+	maxSize int,
+) ([]byte, error) {
+	maxFindings := len(allVulnerabilities(results.Results))
 
-	return markdownBuffer.Bytes(), nil
+	for {
+		markdown, err := renderTemplate(
+			markdownTemplate,
+			toMarkdownReport(results, licenses, maxFindings, sortBy),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(markdown) <= maxSize || maxFindings == 0 {
+			return markdown, nil
+		}
+
+		// Estimate how many findings fit based on the current size, and always make progress.
+		estimate := maxFindings * maxSize / len(markdown) * 9 / 10
+		maxFindings = max(0, min(estimate, maxFindings-1))
+	}
 }
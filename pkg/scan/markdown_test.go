@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testVulnerabilityResults(t *testing.T) TrivyVulnerabilityResultsWithArtifactName {
+	var result TrivyResult
+	if err := json.Unmarshal([]byte(testTrivyJSON), &result); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	return toTrivyVulnerabilityResultsWithArtifactName(result)
+}
+
+func TestToMarkdownReport1(t *testing.T) {
+	results := testVulnerabilityResults(t)
+
+	report := toMarkdownReport(results, nil, -1, SortBySeverity)
+
+	if report.OmittedFindings != 0 {
+		t.Errorf("Expected %d omitted findings, got %d", 0, report.OmittedFindings)
+	}
+
+	if len(report.Targets) != 1 {
+		t.Fatalf("Expected %d targets, got %d", 1, len(report.Targets))
+	}
+
+	expectedTotals := []MarkdownSeverityCount{
+		{Severity: "CRITICAL", Count: 1},
+		{Severity: "HIGH", Count: 1},
+		{Severity: "MEDIUM", Count: 0},
+		{Severity: "LOW", Count: 0},
+		{Severity: "UNKNOWN", Count: 0},
+	}
+	for i, expected := range expectedTotals {
+		if report.Totals[i] != expected {
+			t.Errorf("Expected %v, got %v", expected, report.Totals[i])
+		}
+	}
+}
+
+func TestToMarkdownReport2(t *testing.T) {
+	results := testVulnerabilityResults(t)
+
+	report := toMarkdownReport(results, nil, 1, SortBySeverity)
+
+	if report.OmittedFindings != 1 {
+		t.Errorf("Expected %d omitted findings, got %d", 1, report.OmittedFindings)
+	}
+
+	vulnerabilities := report.Targets[0].Vulnerabilities
+	if len(vulnerabilities) != 1 || vulnerabilities[0].Severity != "CRITICAL" {
+		t.Errorf("Expected only the CRITICAL vulnerability to be shown, got %v", vulnerabilities)
+	}
+}
+
+func TestToMarkdown1(t *testing.T) {
+	results := testVulnerabilityResults(t)
+
+	markdown, err := toMarkdown(results, nil, SortBySeverity)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expectedSubstrings := []string{
+		"| **Total** | **1** | **1** | **0** | **0** | **0** |",
+		"| `test-image:latest (alpine 3.20.0)` | 1 | 1 | 0 | 0 | 0 |",
+		"<summary><b>CRITICAL</b> CVE-2024-0001 in <code>libcrypto3</code> – openssl: something bad</summary>",
+		"**Fixed Version**: 3.3.0-r1",
+		"**Fixed Version**: Not fixed",
+	}
+	for _, expected := range expectedSubstrings {
+		if !strings.Contains(string(markdown), expected) {
+			t.Errorf("Expected markdown to contain %s, got %s", expected, markdown)
+		}
+	}
+
+	if strings.Contains(string(markdown), "more findings not shown") {
+		t.Errorf("Expected no omitted findings note, got %s", markdown)
+	}
+}
+
+func TestToMarkdown2(t *testing.T) {
+	var vulnerabilities []TrivyVulnerability
+	for i := range 500 {
+		vulnerabilities = append(vulnerabilities, TrivyVulnerability{
+			VulnerabilityID: fmt.Sprintf("CVE-2024-%04d", i),
+			PkgName:         "libcrypto3",
+			Severity:        "HIGH",
+			Description:     strings.Repeat("Very long description. ", 100),
+		})
+	}
+
+	results := TrivyVulnerabilityResultsWithArtifactName{
+		ArtifactName: "test-image:latest",
+		Results: []TrivyVulnerabilityResult{
+			{
+				Target:          "test-image:latest (alpine 3.20.0)",
+				Vulnerabilities: vulnerabilities,
+			},
+		},
+	}
+
+	markdown, err := toMarkdown(results, nil, SortBySeverity)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(markdown) > maxMarkdownSize {
+		t.Errorf("Expected markdown to be at most %d bytes, got %d", maxMarkdownSize, len(markdown))
+	}
+
+	if !strings.Contains(string(markdown), "more findings not shown") {
+		t.Errorf("Expected omitted findings note, got %s", markdown)
+	}
+}
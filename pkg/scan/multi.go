@@ -0,0 +1,241 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/3lvia/cli/pkg/utils"
+)
+
+type ImageScanResult struct {
+	ImageName      string
+	SeverityCounts []MarkdownSeverityCount
+	Error          error
+}
+
+// readImagesFile reads image references from a file, one per line.
+// Empty lines and lines starting with # are ignored.
+func readImagesFile(imagesFile string) ([]string, error) {
+	file, err := os.Open(imagesFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open images file %s: %w", imagesFile, err)
+	}
+
+	defer file.Close()
+
+	var images []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		images = append(images, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read images file %s: %w", imagesFile, err)
+	}
+
+	return images, nil
+}
+
+// ScanImages scans several images concurrently, running at most parallel scans at the same time.
+// The Trivy databases are downloaded once up front and shared between all scans.
+// Results for each image are written to separate files, and a combined summary is printed at the end.
+func ScanImages(
+	imageNames []string,
+	severity string,
+	formats []string,
+	disableError bool,
+	skipDBUpdate bool,
+	parallel int,
+	options *ScanImageOptions,
+) error {
+	if options == nil {
+		options = &ScanImageOptions{}
+	}
+
+	outputDirectory := utils.StringWithDefault(options.OutputDirectory, ".")
+	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
+		return fmt.Errorf("Failed to create output directory %s: %w", outputDirectory, err)
+	}
+
+	if !skipDBUpdate {
+		log.Println("Downloading Trivy databases before scanning images")
+
+		if err := downloadDBs(options.DB); err != nil {
+			return err
+		}
+	} else {
+		warnIfDBOutdated(options.DB, options.DBMaxAge, time.Now())
+	}
+
+	imageOptions := *options
+
+	if options.Enrichment.enabled() {
+		enrichment, err := loadEnrichmentFeeds(options)
+		if err != nil {
+			return err
+		}
+
+		imageOptions.enrichment = enrichment
+	}
+	imageOptions.OutputPerImage = true
+	imageOptions.SkipJavaDBUpdate = true
+	imageOptions.InMemoryCache = true
+	// The database age has already been checked above.
+	imageOptions.DBMaxAge = 0
+
+	if options.TemplateFile != "" && !slices.Contains(formats, "markdown") {
+		log.Println("Custom template provided, will also output results in markdown format")
+		formats = append(slices.Clone(formats), "markdown")
+	}
+
+	results := make([]ImageScanResult, len(imageNames))
+	semaphore := make(chan struct{}, max(1, parallel))
+
+	// Tables are printed whole after each scan, so that the tables of images scanned at the same time do not interleave.
+	var stdoutMutex sync.Mutex
+
+	var wg sync.WaitGroup
+	for i, imageName := range imageNames {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			log.Printf("Scanning image %s (%d/%d)\n", imageName, i+1, len(imageNames))
+
+			var tableOutput bytes.Buffer
+			scanOptions := imageOptions
+			scanOptions.tableOutput = &tableOutput
+
+			severityCounts, err := scanImage(
+				imageName,
+				severity,
+				slices.Clone(formats),
+				disableError,
+				true,
+				&scanOptions,
+			)
+
+			if tableOutput.Len() > 0 {
+				stdoutMutex.Lock()
+				fmt.Print(tableOutput.String())
+				stdoutMutex.Unlock()
+			}
+
+			results[i] = ImageScanResult{
+				ImageName:      imageName,
+				SeverityCounts: severityCounts,
+				Error:          err,
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	log.Print("\n" + formatScanSummary(results))
+
+	if slices.Contains(formats, "markdown") {
+		if err := os.WriteFile(
+			filepath.Join(outputDirectory, "trivy-summary.md"),
+			formatScanSummaryMarkdown(results),
+			0644,
+		); err != nil {
+			return fmt.Errorf("Failed to write scan summary: %w", err)
+		}
+	}
+
+	var failedImages []string
+	for _, result := range results {
+		if result.Error != nil {
+			failedImages = append(failedImages, result.ImageName)
+		}
+	}
+
+	if len(failedImages) > 0 {
+		return fmt.Errorf(
+			"Scan failed for %d of %d images: %s",
+			len(failedImages),
+			len(imageNames),
+			strings.Join(failedImages, ", "),
+		)
+	}
+
+	return nil
+}
+
+func scanResultStatus(result ImageScanResult) string {
+	if result.Error != nil {
+		return "FAILED"
+	}
+
+	return "OK"
+}
+
+func formatScanSummary(results []ImageScanResult) string {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "IMAGE\tSTATUS\t%s\n", strings.Join(severities, "\t"))
+	for _, result := range results {
+		fmt.Fprintf(writer, "%s\t%s", result.ImageName, scanResultStatus(result))
+		for _, count := range toSeverityCountsOrEmpty(result.SeverityCounts) {
+			fmt.Fprintf(writer, "\t%d", count.Count)
+		}
+		fmt.Fprintln(writer)
+	}
+
+	writer.Flush()
+
+	return buffer.String()
+}
+
+func formatScanSummaryMarkdown(results []ImageScanResult) []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("# Scan summary\n\n")
+	buffer.WriteString("| Image | Status |")
+	for _, severity := range severities {
+		buffer.WriteString(" " + severity + " |")
+	}
+	buffer.WriteString("\n| --- | --- |" + strings.Repeat(" ---: |", len(severities)) + "\n")
+
+	for _, result := range results {
+		status := ":white_check_mark:"
+		if result.Error != nil {
+			status = ":x:"
+		}
+
+		fmt.Fprintf(&buffer, "| `%s` | %s |", result.ImageName, status)
+		for _, count := range toSeverityCountsOrEmpty(result.SeverityCounts) {
+			fmt.Fprintf(&buffer, " %d |", count.Count)
+		}
+		buffer.WriteString("\n")
+	}
+
+	return buffer.Bytes()
+}
+
+func toSeverityCountsOrEmpty(severityCounts []MarkdownSeverityCount) []MarkdownSeverityCount {
+	if len(severityCounts) == 0 {
+		return toSeverityCounts(nil)
+	}
+
+	return severityCounts
+}
@@ -0,0 +1,175 @@
+package scan
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReadImagesFile(t *testing.T) {
+	imagesFile := filepath.Join(t.TempDir(), "images.txt")
+	const content = `# images built in this release
+ghcr.io/3lvia/core/cli:latest
+
+containerregistryelvia.azurecr.io/core-demo-api:v1
+  debian:10  
+`
+	if err := os.WriteFile(imagesFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := []string{
+		"ghcr.io/3lvia/core/cli:latest",
+		"containerregistryelvia.azurecr.io/core-demo-api:v1",
+		"debian:10",
+	}
+	actual, err := readImagesFile(imagesFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFormatScanSummaryMarkdown(t *testing.T) {
+	results := []ImageScanResult{
+		{
+			ImageName: "debian:10",
+			SeverityCounts: []MarkdownSeverityCount{
+				{Severity: "CRITICAL", Count: 3},
+				{Severity: "HIGH", Count: 7},
+				{Severity: "MEDIUM", Count: 0},
+				{Severity: "LOW", Count: 0},
+				{Severity: "UNKNOWN", Count: 0},
+			},
+			Error: errors.New("exit status 1"),
+		},
+		{
+			ImageName: "alpine:3.20",
+		},
+	}
+
+	expected := "# Scan summary\n\n" +
+		"| Image | Status | CRITICAL | HIGH | MEDIUM | LOW | UNKNOWN |\n" +
+		"| --- | --- | ---: | ---: | ---: | ---: | ---: |\n" +
+		"| `debian:10` | :x: | 3 | 7 | 0 | 0 | 0 |\n" +
+		"| `alpine:3.20` | :white_check_mark: | 0 | 0 | 0 | 0 | 0 |\n"
+	actual := string(formatScanSummaryMarkdown(results))
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+// fakeTrivy is a trivy stand-in writing an empty scan result, and printing a table slowly enough for the tables of
+// concurrent scans to interleave when printed directly.
+const fakeTrivy = `#!/bin/sh
+if [ "$1" = "convert" ]; then
+  for file in "$@"; do :; done
+  echo "table start $file"
+  sleep 0.1
+  echo "table end $file"
+  exit 0
+fi
+
+while [ "$#" -gt 0 ]; do
+  if [ "$1" = "--output" ]; then
+    echo '{"ArtifactName": "image", "Results": []}' > "$2"
+  fi
+  shift
+done
+`
+
+func TestScanImages(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The trivy stand-in is a shell script")
+	}
+
+	binDirectory := t.TempDir()
+	writeExecutable(t, filepath.Join(binDirectory, "trivy"), fakeTrivy)
+	t.Setenv("PATH", binDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	outputDirectory := t.TempDir()
+	templateFile := filepath.Join(t.TempDir(), "report.tmpl")
+	if err := os.WriteFile(templateFile, []byte("{{ .ArtifactName }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spare capacity, so that appending to the formats in each scan would write to the same array.
+	formats := make([]string, 0, 8)
+	formats = append(formats, "table")
+
+	images := []string{"debian:10", "debian:11", "alpine:3.19", "alpine:3.20"}
+
+	stdout := captureStdout(t, func() {
+		err := ScanImages(images, "CRITICAL", formats, true, true, len(images), &ScanImageOptions{
+			TemplateFile:    templateFile,
+			OutputDirectory: outputDirectory,
+			DB:              DBOptions{CacheDir: t.TempDir()},
+		})
+		if err != nil {
+			t.Errorf("Expected no error, got %s", err)
+		}
+	})
+
+	if len(formats) != 1 {
+		t.Errorf("Expected the formats of the caller to be unchanged, got %v", formats)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2*len(images) {
+		t.Fatalf("Expected %d table lines, got %s", 2*len(images), stdout)
+	}
+
+	for i := 0; i < len(lines); i += 2 {
+		file := strings.TrimPrefix(lines[i], "table start ")
+		if lines[i+1] != "table end "+file {
+			t.Errorf("Expected the table of each image to be printed whole, got %s", stdout)
+		}
+	}
+
+	for _, image := range images {
+		markdownFile := filepath.Join(outputDirectory, "trivy-"+imageFileName(image)+".md")
+		if _, err := os.Stat(markdownFile); err != nil {
+			t.Errorf("Expected %s to be written, got %s", markdownFile, err)
+		}
+	}
+}
+
+func writeExecutable(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(reader)
+		output <- string(content)
+	}()
+
+	f()
+
+	writer.Close()
+	return <-output
+}
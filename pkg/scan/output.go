@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type OutputFiles struct {
+	JSON     string
+	SARIF    string
+	Markdown string
+	JUnit    string
+	// Intermediate output of the license scan, merged into the JSON output.
+	Licenses string
+}
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// imageFileName converts an image reference to a string safe to use in a file name,
+// e.g. ghcr.io/3lvia/core/cli:v1 becomes ghcr.io-3lvia-core-cli-v1.
+func imageFileName(imageName string) string {
+	return strings.Trim(unsafeFileNameCharacters.ReplaceAllString(imageName, "-"), "-")
+}
+
+// getOutputFiles returns the paths of the files written when scanning an image.
+// When perImage is true or an output directory other than the working directory is given, the file names include
+// the image name, so that scanning several images in the same directory does not overwrite previous results.
+func getOutputFiles(outputDirectory string, imageName string, perImage bool) OutputFiles {
+	baseName := "trivy"
+	if perImage || filepath.Clean(outputDirectory) != "." {
+		baseName = "trivy-" + imageFileName(imageName)
+	}
+
+	basePath := filepath.Join(outputDirectory, baseName)
+
+	return OutputFiles{
+		JSON:     basePath + ".json",
+		SARIF:    basePath + ".sarif",
+		Markdown: basePath + ".md",
+		JUnit:    basePath + ".junit.xml",
+		Licenses: basePath + ".licenses.json",
+	}
+}
@@ -0,0 +1,63 @@
+package scan
+
+import (
+	"testing"
+)
+
+func TestImageFileName1(t *testing.T) {
+	const expected = "ghcr.io-3lvia-core-cli-latest-cache"
+	actual := imageFileName("ghcr.io/3lvia/core/cli:latest-cache")
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestImageFileName2(t *testing.T) {
+	const expected = "containerregistryelvia.azurecr.io-core-demo-api-sha256-abc123"
+	actual := imageFileName("containerregistryelvia.azurecr.io/core-demo-api@sha256:abc123")
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestGetOutputFiles1(t *testing.T) {
+	expected := OutputFiles{
+		JSON:     "trivy.json",
+		SARIF:    "trivy.sarif",
+		Markdown: "trivy.md",
+		JUnit:    "trivy.junit.xml",
+		Licenses: "trivy.licenses.json",
+	}
+	actual := getOutputFiles(".", "debian:10", false)
+
+	if actual != expected {
+		t.Errorf("Expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestGetOutputFiles2(t *testing.T) {
+	expected := OutputFiles{
+		JSON:     "scan-results/trivy-debian-10.json",
+		SARIF:    "scan-results/trivy-debian-10.sarif",
+		Markdown: "scan-results/trivy-debian-10.md",
+		JUnit:    "scan-results/trivy-debian-10.junit.xml",
+		Licenses: "scan-results/trivy-debian-10.licenses.json",
+	}
+	actual := getOutputFiles("scan-results", "debian:10", true)
+
+	if actual != expected {
+		t.Errorf("Expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestGetOutputFiles3(t *testing.T) {
+	// Results written to an output directory are always named by image, so that repeated scans do not overwrite each other.
+	const expected = "scan-results/trivy-debian-10.json"
+	actual := getOutputFiles("scan-results", "debian:10", false)
+
+	if actual.JSON != expected {
+		t.Errorf("Expected %s, got %s", expected, actual.JSON)
+	}
+}
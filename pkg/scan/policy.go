@@ -0,0 +1,320 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	PolicyActionFail   = "fail"
+	PolicyActionWarn   = "warn"
+	PolicyActionIgnore = "ignore"
+)
+
+// Policy decides what to do with each vulnerability found by Trivy.
+// Rules are evaluated in order and the first matching rule decides the action,
+// vulnerabilities not matching any rule get the default action.
+type Policy struct {
+	// Overrides --ignore-unfixed when set.
+	IgnoreUnfixed *bool `json:"ignoreUnfixed"`
+	// Action for vulnerabilities not matching any rule, defaults to fail.
+	DefaultAction string       `json:"defaultAction"`
+	Rules         []PolicyRule `json:"rules"`
+}
+
+// PolicyRule matches vulnerabilities where all of the given conditions hold.
+// Conditions that are not set match any vulnerability.
+type PolicyRule struct {
+	Name             string   `json:"name"`
+	Action           string   `json:"action"`
+	Severities       []string `json:"severities"`
+	Classes          []string `json:"classes"`
+	Types            []string `json:"types"`
+	Packages         []string `json:"packages"`
+	VulnerabilityIDs []string `json:"vulnerabilityIDs"`
+	FixAvailable     *bool    `json:"fixAvailable"`
+	// Minimum number of days since the vulnerability was published. Trivy does not report when a fix was released.
+	MinPublishedAgeDays int     `json:"minPublishedAgeDays"`
+	MinCVSSV3Score      float64 `json:"minCVSSV3Score"`
+	// Matches vulnerabilities listed in the --kev-feed.
+	KnownExploited *bool `json:"knownExploited"`
+	// Minimum EPSS probability between 0 and 1. Vulnerabilities without an EPSS score do not match.
+	MinEPSS float64 `json:"minEPSS"`
+}
+
+type PolicyFinding struct {
+	Target          string
+	VulnerabilityID string
+	PkgName         string
+	Severity        string
+	Action          string
+	Rule            string
+	Reasons         []string
+}
+
+type PolicyResult struct {
+	Findings []PolicyFinding
+}
+
+func (result PolicyResult) countAction(action string) int {
+	count := 0
+	for _, finding := range result.Findings {
+		if finding.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (result PolicyResult) Failed() bool {
+	return result.countAction(PolicyActionFail) > 0
+}
+
+func readPolicyFile(policyFile string) (Policy, error) {
+	content, err := os.ReadFile(policyFile)
+	if err != nil {
+		return Policy{}, fmt.Errorf("Failed to read policy file %s: %w", policyFile, err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return Policy{}, fmt.Errorf("Failed to parse policy file %s: %w", policyFile, err)
+	}
+
+	if err := validatePolicy(policy); err != nil {
+		return Policy{}, fmt.Errorf("Invalid policy file %s: %w", policyFile, err)
+	}
+
+	return policy, nil
+}
+
+func isValidPolicyAction(action string) bool {
+	return action == PolicyActionFail || action == PolicyActionWarn || action == PolicyActionIgnore
+}
+
+func validatePolicy(policy Policy) error {
+	if policy.DefaultAction != "" && !isValidPolicyAction(policy.DefaultAction) {
+		return fmt.Errorf("Invalid default action %s: must be one of fail, warn or ignore", policy.DefaultAction)
+	}
+
+	for i, rule := range policy.Rules {
+		if !isValidPolicyAction(rule.Action) {
+			return fmt.Errorf("Invalid action %s in rule %d: must be one of fail, warn or ignore", rule.Action, i+1)
+		}
+
+		if rule.MinEPSS < 0 || rule.MinEPSS > 1 {
+			return fmt.Errorf("Invalid minEPSS %g in rule %d: must be between 0 and 1", rule.MinEPSS, i+1)
+		}
+	}
+
+	return nil
+}
+
+// validatePolicyData checks that the feeds needed by the policy rules are given on the command line.
+func validatePolicyData(policy Policy, enrichmentOptions EnrichmentOptions) error {
+	usesKnownExploited := slices.ContainsFunc(policy.Rules, func(rule PolicyRule) bool {
+		return rule.KnownExploited != nil
+	})
+	if usesKnownExploited && enrichmentOptions.KEVFeed == "" {
+		return fmt.Errorf("--kev-feed must be set when a rule uses knownExploited")
+	}
+
+	usesEPSS := slices.ContainsFunc(policy.Rules, func(rule PolicyRule) bool {
+		return rule.MinEPSS > 0
+	})
+	if usesEPSS && enrichmentOptions.EPSSFeed == "" {
+		return fmt.Errorf("--epss-feed must be set when a rule uses minEPSS")
+	}
+
+	return nil
+}
+
+func cvssV3Score(vulnerability TrivyVulnerability) float64 {
+	return max(vulnerability.CVSS.Nvd.V3Score, vulnerability.CVSS.Redhat.V3Score)
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
+
+// matchRule returns whether the rule matches the vulnerability, and the reasons it matched.
+func matchRule(
+	rule PolicyRule,
+	result TrivyVulnerabilityResult,
+	vulnerability TrivyVulnerability,
+	now time.Time,
+) (bool, []string) {
+	var reasons []string
+
+	if len(rule.Severities) > 0 {
+		if !containsFold(rule.Severities, vulnerability.Severity) {
+			return false, nil
+		}
+		reasons = append(reasons, "severity is "+vulnerability.Severity)
+	}
+
+	if len(rule.Classes) > 0 {
+		if !containsFold(rule.Classes, result.Class) {
+			return false, nil
+		}
+		reasons = append(reasons, "class is "+result.Class)
+	}
+
+	if len(rule.Types) > 0 {
+		if !containsFold(rule.Types, result.Type) {
+			return false, nil
+		}
+		reasons = append(reasons, "type is "+result.Type)
+	}
+
+	if len(rule.Packages) > 0 {
+		if !slices.Contains(rule.Packages, vulnerability.PkgName) {
+			return false, nil
+		}
+		reasons = append(reasons, "package is "+vulnerability.PkgName)
+	}
+
+	if len(rule.VulnerabilityIDs) > 0 {
+		if !containsFold(rule.VulnerabilityIDs, vulnerability.VulnerabilityID) {
+			return false, nil
+		}
+		reasons = append(reasons, "vulnerability ID is listed")
+	}
+
+	if rule.FixAvailable != nil {
+		fixAvailable := vulnerability.FixedVersion != ""
+		if fixAvailable != *rule.FixAvailable {
+			return false, nil
+		}
+		if fixAvailable {
+			reasons = append(reasons, "fixed in "+vulnerability.FixedVersion)
+		} else {
+			reasons = append(reasons, "no fix available")
+		}
+	}
+
+	if rule.MinPublishedAgeDays > 0 {
+		publishedDate, err := time.Parse(time.RFC3339, vulnerability.PublishedDate)
+		if err != nil {
+			return false, nil
+		}
+
+		ageDays := int(now.Sub(publishedDate).Hours() / 24)
+		if ageDays < rule.MinPublishedAgeDays {
+			return false, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("published %d days ago", ageDays))
+	}
+
+	if rule.MinCVSSV3Score > 0 {
+		score := cvssV3Score(vulnerability)
+		if score < rule.MinCVSSV3Score {
+			return false, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("CVSS v3 score is %.1f", score))
+	}
+
+	if rule.MinEPSS > 0 {
+		if vulnerability.EPSS == nil || vulnerability.EPSS.Probability < rule.MinEPSS {
+			return false, nil
+		}
+		reasons = append(reasons, "EPSS is "+formatPercent(vulnerability.EPSS.Probability))
+	}
+
+	if rule.KnownExploited != nil {
+		if vulnerability.KnownExploited != *rule.KnownExploited {
+			return false, nil
+		}
+		if vulnerability.KnownExploited {
+			reasons = append(reasons, "known to be exploited")
+		} else {
+			reasons = append(reasons, "not known to be exploited")
+		}
+	}
+
+	return true, reasons
+}
+
+func evaluatePolicy(
+	policy Policy,
+	result TrivyResult,
+	now time.Time,
+) PolicyResult {
+	defaultAction := policy.DefaultAction
+	if defaultAction == "" {
+		defaultAction = PolicyActionFail
+	}
+
+	var policyResult PolicyResult
+	for _, target := range result.Results {
+		for _, vulnerability := range target.Vulnerabilities {
+			finding := PolicyFinding{
+				Target:          target.Target,
+				VulnerabilityID: vulnerability.VulnerabilityID,
+				PkgName:         vulnerability.PkgName,
+				Severity:        vulnerability.Severity,
+				Action:          defaultAction,
+				Rule:            "default",
+			}
+
+			for i, rule := range policy.Rules {
+				matches, reasons := matchRule(rule, target, vulnerability, now)
+				if !matches {
+					continue
+				}
+
+				finding.Action = rule.Action
+				finding.Rule = rule.Name
+				if finding.Rule == "" {
+					finding.Rule = fmt.Sprintf("rule %d", i+1)
+				}
+				finding.Reasons = reasons
+				break
+			}
+
+			policyResult.Findings = append(policyResult.Findings, finding)
+		}
+	}
+
+	return policyResult
+}
+
+func (finding PolicyFinding) String() string {
+	explanation := fmt.Sprintf(
+		"%s %s in %s (%s): matched %s",
+		strings.ToUpper(finding.Action),
+		finding.VulnerabilityID,
+		finding.PkgName,
+		finding.Target,
+		finding.Rule,
+	)
+
+	if len(finding.Reasons) > 0 {
+		explanation += " because " + strings.Join(finding.Reasons, ", ")
+	}
+
+	return explanation
+}
+
+func logPolicyResult(policyResult PolicyResult) {
+	for _, finding := range policyResult.Findings {
+		if finding.Action != PolicyActionIgnore {
+			log.Println(finding)
+		}
+	}
+
+	log.Printf(
+		"Policy result: %d failed, %d warnings, %d ignored\n",
+		policyResult.countAction(PolicyActionFail),
+		policyResult.countAction(PolicyActionWarn),
+		policyResult.countAction(PolicyActionIgnore),
+	)
+}
@@ -0,0 +1,222 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testPolicyTrivyJSON = `{
+  "ArtifactName": "test-image:latest",
+  "Results": [
+    {
+      "Target": "test-image:latest (alpine 3.20.0)",
+      "Class": "os-pkgs",
+      "Type": "alpine",
+      "Vulnerabilities": [
+        {
+          "VulnerabilityID": "CVE-2024-0001",
+          "PkgName": "libcrypto3",
+          "FixedVersion": "3.3.0-r1",
+          "Severity": "CRITICAL",
+          "PublishedDate": "2024-01-01T00:00:00Z"
+        },
+        {
+          "VulnerabilityID": "CVE-2024-0002",
+          "PkgName": "libssl3",
+          "Severity": "HIGH",
+          "PublishedDate": "2024-01-01T00:00:00Z"
+        }
+      ]
+    },
+    {
+      "Target": "app/executable",
+      "Class": "lang-pkgs",
+      "Type": "gobinary",
+      "Vulnerabilities": [
+        {
+          "VulnerabilityID": "CVE-2024-0003",
+          "PkgName": "golang.org/x/net",
+          "FixedVersion": "0.23.0",
+          "Severity": "CRITICAL",
+          "PublishedDate": "2024-06-28T00:00:00Z"
+        },
+        {
+          "VulnerabilityID": "CVE-2024-0004",
+          "PkgName": "golang.org/x/crypto",
+          "Severity": "HIGH",
+          "PublishedDate": "2024-01-01T00:00:00Z",
+          "CVSS": {
+            "nvd": {
+              "V3Score": 9.1
+            }
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+var testPolicyNow = time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func boolPointer(value bool) *bool {
+	return &value
+}
+
+func testPolicyResult(t *testing.T) TrivyResult {
+	var result TrivyResult
+	if err := json.Unmarshal([]byte(testPolicyTrivyJSON), &result); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	return result
+}
+
+func findingActions(policyResult PolicyResult) map[string]string {
+	actions := make(map[string]string)
+	for _, finding := range policyResult.Findings {
+		actions[finding.VulnerabilityID] = finding.Action
+	}
+
+	return actions
+}
+
+func TestEvaluatePolicy1(t *testing.T) {
+	policy := Policy{
+		DefaultAction: PolicyActionIgnore,
+		Rules: []PolicyRule{
+			{
+				Name:                "critical-with-fix-published-long-ago",
+				Action:              PolicyActionFail,
+				Severities:          []string{"CRITICAL"},
+				FixAvailable:        boolPointer(true),
+				MinPublishedAgeDays: 7,
+			},
+		},
+	}
+
+	expected := map[string]string{
+		"CVE-2024-0001": PolicyActionFail,
+		// Published 3 days ago, so not old enough to fail.
+		"CVE-2024-0003": PolicyActionIgnore,
+		"CVE-2024-0002": PolicyActionIgnore,
+		"CVE-2024-0004": PolicyActionIgnore,
+	}
+	actual := findingActions(evaluatePolicy(policy, testPolicyResult(t), testPolicyNow))
+
+	for vulnerabilityID, expectedAction := range expected {
+		if actual[vulnerabilityID] != expectedAction {
+			t.Errorf("Expected %s for %s, got %s", expectedAction, vulnerabilityID, actual[vulnerabilityID])
+		}
+	}
+}
+
+func TestEvaluatePolicy2(t *testing.T) {
+	policy := Policy{
+		DefaultAction: PolicyActionIgnore,
+		Rules: []PolicyRule{
+			{Name: "os-packages", Action: PolicyActionWarn, Classes: []string{"os-pkgs"}},
+			{Name: "known-exploited", Action: PolicyActionFail, KnownExploited: boolPointer(true)},
+			{Name: "cvss", Action: PolicyActionFail, MinCVSSV3Score: 9.0},
+		},
+	}
+	result := testPolicyResult(t)
+	enrichResult(&result, &Enrichment{
+		KnownExploited: map[string]bool{
+			"CVE-2024-0001": true,
+			"CVE-2024-0003": true,
+		},
+	})
+
+	expected := map[string]string{
+		// The first matching rule wins, so OS packages only warn even if known to be exploited.
+		"CVE-2024-0001": PolicyActionWarn,
+		"CVE-2024-0002": PolicyActionWarn,
+		"CVE-2024-0003": PolicyActionFail,
+		"CVE-2024-0004": PolicyActionFail,
+	}
+	policyResult := evaluatePolicy(policy, result, testPolicyNow)
+	actual := findingActions(policyResult)
+
+	for vulnerabilityID, expectedAction := range expected {
+		if actual[vulnerabilityID] != expectedAction {
+			t.Errorf("Expected %s for %s, got %s", expectedAction, vulnerabilityID, actual[vulnerabilityID])
+		}
+	}
+
+	if !policyResult.Failed() {
+		t.Errorf("Expected policy to fail")
+	}
+}
+
+func TestEvaluatePolicy3(t *testing.T) {
+	policy := Policy{
+		Rules: []PolicyRule{
+			{Action: PolicyActionIgnore, Types: []string{"gobinary"}},
+		},
+	}
+
+	policyResult := evaluatePolicy(policy, testPolicyResult(t), testPolicyNow)
+
+	// Vulnerabilities not matching any rule fail by default.
+	if policyResult.countAction(PolicyActionFail) != 2 {
+		t.Errorf("Expected %d failures, got %d", 2, policyResult.countAction(PolicyActionFail))
+	}
+
+	if policyResult.Findings[2].Rule != "rule 1" {
+		t.Errorf("Expected unnamed rule to be named by position, got %s", policyResult.Findings[2].Rule)
+	}
+}
+
+func TestPolicyFindingString(t *testing.T) {
+	finding := PolicyFinding{
+		Target:          "app/executable",
+		VulnerabilityID: "CVE-2024-0004",
+		PkgName:         "golang.org/x/crypto",
+		Action:          PolicyActionFail,
+		Rule:            "cvss",
+		Reasons:         []string{"CVSS v3 score is 9.1"},
+	}
+
+	const expected = "FAIL CVE-2024-0004 in golang.org/x/crypto (app/executable): matched cvss because CVSS v3 score is 9.1"
+	actual := finding.String()
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestReadPolicyFile1(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	const content = `{"rules": [{"action": "block"}]}`
+	if err := os.WriteFile(policyFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := readPolicyFile(policyFile); err == nil {
+		t.Errorf("Expected error for invalid action, got nil")
+	}
+}
+
+func TestValidatePolicyData(t *testing.T) {
+	policy := Policy{
+		Rules: []PolicyRule{
+			{Action: PolicyActionFail, KnownExploited: boolPointer(true)},
+			{Action: PolicyActionFail, MinEPSS: 0.5},
+		},
+	}
+
+	if err := validatePolicyData(policy, EnrichmentOptions{EPSSFeed: "epss.csv"}); err == nil {
+		t.Errorf("Expected error for missing known exploited vulnerabilities, got nil")
+	}
+
+	if err := validatePolicyData(policy, EnrichmentOptions{KEVFeed: "kev.json"}); err == nil {
+		t.Errorf("Expected error for missing EPSS feed, got nil")
+	}
+
+	if err := validatePolicyData(policy, EnrichmentOptions{EPSSFeed: "epss.csv", KEVFeed: "kev.json"}); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
@@ -3,10 +3,12 @@ package scan
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"slices"
+	"time"
 
 	"github.com/3lvia/cli/pkg/command"
 	"github.com/3lvia/cli/pkg/utils"
@@ -16,10 +18,14 @@ import (
 const commandName = "scan"
 
 var Command *cli.Command = &cli.Command{
-	Name:    "scan",
-	Aliases: []string{"s"},
-	Usage:   "Scan image using Trivy",
-	Flags: []cli.Flag{
+	Name:      "scan",
+	Aliases:   []string{"s"},
+	Usage:     "Scan one or more images using Trivy",
+	ArgsUsage: "[image...]",
+	Subcommands: []*cli.Command{
+		dbCommand,
+	},
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "severity",
 			Aliases: []string{"S"},
@@ -30,11 +36,11 @@ var Command *cli.Command = &cli.Command{
 		&cli.StringSliceFlag{
 			Name:    "formats",
 			Aliases: []string{"F"},
-			Usage:   "The formats to use when outputting the scan results: can be table, json, sarif or markdown.",
+			Usage:   "The formats to use when outputting the scan results: can be table, json, sarif, markdown or junit.",
 			Value:   cli.NewStringSlice("table"),
 			Action: func(c *cli.Context, formats []string) error {
 				for _, format := range formats {
-					if format != "table" && format != "json" && format != "sarif" && format != "markdown" {
+					if format != "table" && format != "json" && format != "sarif" && format != "markdown" && format != "junit" {
 						return cli.Exit("Invalid format provided", 1)
 					}
 				}
@@ -43,6 +49,104 @@ var Command *cli.Command = &cli.Command{
 			},
 			EnvVars: []string{"3LV_FORMATS"},
 		},
+		&cli.StringFlag{
+			Name:    "template",
+			Aliases: []string{"T"},
+			Usage:   "Path to a custom Go template used instead of the built-in template when outputting the scan results as markdown.",
+			EnvVars: []string{"3LV_TEMPLATE"},
+		},
+		&cli.BoolFlag{
+			Name:    "github-report",
+			Usage:   "When running in GitHub Actions, write the markdown report to the job summary and comment it on the pull request. Requires GITHUB_TOKEN or GH_TOKEN to be set.",
+			Value:   false,
+			EnvVars: []string{"3LV_GITHUB_REPORT"},
+		},
+		&cli.StringFlag{
+			Name:    "policy",
+			Usage:   "Path to a JSON policy file deciding which vulnerabilities fail the scan. See the README for the policy format.",
+			EnvVars: []string{"3LV_POLICY"},
+		},
+		&cli.BoolFlag{
+			Name:    "ignore-unfixed",
+			Usage:   "Ignore vulnerabilities without a fix available. Use --ignore-unfixed=false to include them.",
+			Value:   true,
+			EnvVars: []string{"3LV_IGNORE_UNFIXED"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "vex",
+			Usage:   "Path to an OpenVEX document. Vulnerabilities declared not_affected or fixed for the image are removed from the results. Can be given multiple times.",
+			EnvVars: []string{"3LV_VEX"},
+		},
+		&cli.BoolFlag{
+			Name:    "licenses",
+			Aliases: []string{"L"},
+			Usage:   "Also scan the image for licenses, checked against --license-allow, --license-warn and --license-deny.",
+			Value:   false,
+			EnvVars: []string{"3LV_LICENSES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "license-allow",
+			Usage:   "SPDX license identifiers to allow, e.g. MIT,Apache-2.0. A trailing * matches any suffix. When set, licenses not in any list are warned about.",
+			EnvVars: []string{"3LV_LICENSE_ALLOW"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "license-warn",
+			Usage:   "SPDX license identifiers to warn about, e.g. LGPL-*. A trailing * matches any suffix.",
+			EnvVars: []string{"3LV_LICENSE_WARN"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "license-deny",
+			Usage:   "SPDX license identifiers failing the scan, e.g. GPL-*,AGPL-*. A trailing * matches any suffix.",
+			EnvVars: []string{"3LV_LICENSE_DENY"},
+		},
+		&cli.StringFlag{
+			Name:    "input",
+			Aliases: []string{"i"},
+			Usage:   "Path to an image tarball, e.g. from docker save, or an OCI layout directory to scan instead of an image. Does not require Docker.",
+			EnvVars: []string{"3LV_INPUT"},
+		},
+		&cli.StringFlag{
+			Name:    "epss-feed",
+			Usage:   "File path or URL of EPSS scores, e.g. https://epss.cyentia.com/epss_scores-current.csv.gz, used to enrich, sort and gate on vulnerabilities. Downloads are cached for 24 hours.",
+			EnvVars: []string{"3LV_EPSS_FEED"},
+		},
+		&cli.StringFlag{
+			Name:    "kev-feed",
+			Usage:   "File path or URL of the CISA known exploited vulnerabilities catalog in JSON format, used to enrich, sort and gate on vulnerabilities. Downloads are cached for 24 hours.",
+			EnvVars: []string{"3LV_KEV_FEED"},
+		},
+		&cli.StringFlag{
+			Name:    "sort-by",
+			Usage:   "How to sort vulnerabilities in the Markdown and enriched table output: severity, or epss to sort known exploited vulnerabilities first, then by EPSS probability.",
+			Value:   SortBySeverity,
+			Action:  ValidateSortBy,
+			EnvVars: []string{"3LV_SORT_BY"},
+		},
+		&cli.StringFlag{
+			Name:    "images-file",
+			Usage:   "Path to a file listing images to scan, one per line. Can be combined with images given as arguments.",
+			EnvVars: []string{"3LV_IMAGES_FILE"},
+		},
+		&cli.IntFlag{
+			Name:    "parallel",
+			Aliases: []string{"P"},
+			Usage:   "The maximum number of images to scan at the same time when scanning multiple images",
+			Value:   4,
+			EnvVars: []string{"3LV_PARALLEL"},
+		},
+		&cli.StringFlag{
+			Name:    "output-dir",
+			Aliases: []string{"o"},
+			Usage:   "The directory to write the scan result files to. Will be created if it does not exist.",
+			Value:   ".",
+			EnvVars: []string{"3LV_OUTPUT_DIR"},
+		},
+		&cli.BoolFlag{
+			Name:    "output-per-image",
+			Usage:   "Include the image name in the scan result file names, e.g. trivy-ghcr.io-3lvia-core-cli-latest.json instead of trivy.json. Always done when an output directory is given.",
+			Value:   false,
+			EnvVars: []string{"3LV_OUTPUT_PER_IMAGE"},
+		},
 		&cli.BoolFlag{
 			Name:    "disable-error",
 			Aliases: []string{"D"},
@@ -56,18 +160,29 @@ var Command *cli.Command = &cli.Command{
 			Value:   false,
 			EnvVars: []string{"3LV_SKIP_DB_UPDATE"},
 		},
-	},
+	}, DBFlags("", "")...),
 	Action: Scan,
 }
 
 func Scan(c *cli.Context) error {
-	if c.NArg() <= 0 {
-		return cli.ShowCommandHelp(c, commandName)
+	imageNames := utils.RemoveZeroValues(c.Args().Slice())
+
+	input := c.String("input")
+	if input != "" && (len(imageNames) > 0 || c.String("images-file") != "") {
+		return cli.Exit("--input can not be combined with image names or --images-file", 1)
+	}
+
+	if imagesFile := c.String("images-file"); imagesFile != "" {
+		imagesFromFile, err := readImagesFile(imagesFile)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		imageNames = append(imageNames, imagesFromFile...)
 	}
 
 	// Required args
-	imageName := c.Args().First()
-	if imageName == "" {
+	if len(imageNames) == 0 && input == "" {
 		log.Println("Image name not provided")
 		return cli.ShowCommandHelp(c, commandName)
 	}
@@ -77,8 +192,46 @@ func Scan(c *cli.Context) error {
 	formats := utils.RemoveZeroValues(c.StringSlice("formats"))
 	disableError := c.Bool("disable-error")
 	skipDBUpdate := c.Bool("skip-db-update")
+	options := &ScanImageOptions{
+		TemplateFile:    c.String("template"),
+		GitHubReport:    c.Bool("github-report"),
+		OutputDirectory: c.String("output-dir"),
+		OutputPerImage:  c.Bool("output-per-image"),
+		PolicyFile:      c.String("policy"),
+		IncludeUnfixed:  !c.Bool("ignore-unfixed"),
+		DB:              DBOptionsFromContext(c, ""),
+		DBMaxAge:        c.Duration("db-max-age"),
+		Enrichment: EnrichmentOptions{
+			EPSSFeed: c.String("epss-feed"),
+			KEVFeed:  c.String("kev-feed"),
+		},
+		SortBy:   c.String("sort-by"),
+		VEXFiles: utils.RemoveZeroValues(c.StringSlice("vex")),
+	}
+
+	if c.Bool("licenses") {
+		options.Licenses = &LicenseConfig{
+			Allow: utils.RemoveZeroValues(c.StringSlice("license-allow")),
+			Warn:  utils.RemoveZeroValues(c.StringSlice("license-warn")),
+			Deny:  utils.RemoveZeroValues(c.StringSlice("license-deny")),
+		}
+	}
+
+	if input != "" {
+		options.Input = true
+		imageNames = []string{input}
+	}
 
-	err := ScanImage(imageName, severity, formats, disableError, skipDBUpdate)
+	if len(imageNames) > 1 {
+		err := ScanImages(imageNames, severity, formats, disableError, skipDBUpdate, c.Int("parallel"), options)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		return nil
+	}
+
+	err := ScanImage(imageNames[0], severity, formats, disableError, skipDBUpdate, options)
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
@@ -86,13 +239,30 @@ func Scan(c *cli.Context) error {
 	return nil
 }
 
+type ScanImageCommandOptions struct {
+	IncludeUnfixed   bool
+	SkipJavaDBUpdate bool
+	// Use an in-memory cache for image layers instead of the shared cache directory,
+	// allowing several Trivy processes to run at the same time.
+	InMemoryCache bool
+	// Treat the image name as a path to an image tarball or OCI layout directory.
+	Input      bool
+	DB         DBOptions
+	RunOptions *command.RunOptions
+}
+
 func scanImageCommand(
 	imageName string,
 	severity string,
 	disableError bool,
 	skipDBUpdate bool,
-	runOptions *command.RunOptions,
+	outputFile string,
+	options *ScanImageCommandOptions,
 ) command.Output {
+	if options == nil {
+		options = &ScanImageCommandOptions{}
+	}
+
 	exitCode := func() string {
 		if disableError {
 			return "0"
@@ -112,25 +282,51 @@ func scanImageCommand(
 		"--format",
 		"json",
 		"--output",
-		"trivy.json",
+		outputFile,
 		"--db-repository",
-		"ghcr.io/3lvia/trivy-db",
+		options.DB.dbRepository(),
 		"--java-db-repository",
-		"ghcr.io/3lvia/trivy-java-db",
-		"--ignore-unfixed",
+		options.DB.javaDBRepository(),
 	)
 
+	if !options.IncludeUnfixed {
+		cmd.Args = append(cmd.Args, "--ignore-unfixed")
+	}
+
 	if skipDBUpdate {
 		cmd.Args = append(cmd.Args, "--skip-db-update")
 	}
 
-	cmd.Args = append(cmd.Args, imageName)
+	if options.SkipJavaDBUpdate {
+		cmd.Args = append(cmd.Args, "--skip-java-db-update")
+	}
+
+	if options.InMemoryCache {
+		cmd.Args = append(cmd.Args, "--cache-backend", "memory")
+	}
+
+	if options.DB.CacheDir != "" {
+		cmd.Args = append(cmd.Args, "--cache-dir", options.DB.CacheDir)
+	}
 
-	return command.Run(*cmd, runOptions)
+	cmd.Args = append(cmd.Args, imageArguments(imageName, options.Input)...)
+
+	return command.Run(*cmd, options.RunOptions)
+}
+
+// imageArguments returns the Trivy arguments selecting the image to scan.
+func imageArguments(imageName string, input bool) []string {
+	if input {
+		return []string{"--input", imageName}
+	}
+
+	return []string{imageName}
 }
 
 func convertCommand(
 	format string,
+	jsonFile string,
+	outputFile string,
 	runOptions *command.RunOptions,
 ) command.Output {
 	if format == "table" {
@@ -140,7 +336,7 @@ func convertCommand(
 				"convert",
 				"--format",
 				"table",
-				"trivy.json",
+				jsonFile,
 			),
 			runOptions,
 		)
@@ -154,8 +350,8 @@ func convertCommand(
 				"--format",
 				"sarif",
 				"--output",
-				"trivy.sarif",
-				"trivy.json",
+				outputFile,
+				jsonFile,
 			),
 			runOptions,
 		)
@@ -164,39 +360,255 @@ func convertCommand(
 	return command.Error(fmt.Errorf("Invalid format %s", format))
 }
 
+type ScanImageOptions struct {
+	TemplateFile    string
+	GitHubReport    bool
+	OutputDirectory string
+	OutputPerImage  bool
+	PolicyFile      string
+	IncludeUnfixed  bool
+	// License scanning is disabled when nil.
+	Licenses *LicenseConfig
+	// Set when scanning several images at once, after the databases have been downloaded.
+	SkipJavaDBUpdate bool
+	InMemoryCache    bool
+	// Scan an image tarball or OCI layout directory given as the image name, instead of an image.
+	Input bool
+	DB    DBOptions
+	// Warn when the local vulnerability database is older than this. Disabled when zero.
+	DBMaxAge   time.Duration
+	Enrichment EnrichmentOptions
+	// One of severity or epss, defaults to severity.
+	SortBy string
+	// OpenVEX documents declaring vulnerabilities which do not affect the image.
+	VEXFiles []string
+	// Set when scanning several images at once, after the enrichment feeds have been read.
+	enrichment *Enrichment
+	// Set when scanning several images at once, to print the table of each image after its scan instead of interleaved.
+	tableOutput io.Writer
+}
+
 func ScanImage(
 	imageName string,
 	severity string,
 	formats []string,
 	disableError bool,
 	skipDBUpdate bool,
+	options *ScanImageOptions,
 ) error {
-	scanImageOutput := scanImageCommand(
+	_, err := scanImage(
 		imageName,
 		severity,
+		formats,
 		disableError,
 		skipDBUpdate,
-		nil,
+		options,
+	)
+
+	return err
+}
+
+func scanImage(
+	imageName string,
+	severity string,
+	formats []string,
+	disableError bool,
+	skipDBUpdate bool,
+	options *ScanImageOptions,
+) ([]MarkdownSeverityCount, error) {
+	if options == nil {
+		options = &ScanImageOptions{}
+	}
+
+	if options.TemplateFile != "" && !slices.Contains(formats, "markdown") {
+		log.Println("Custom template provided, will also output results in markdown format")
+		// The formats of the caller must not be modified, they are shared when scanning several images at once.
+		formats = append(slices.Clone(formats), "markdown")
+	}
+
+	outputDirectory := utils.StringWithDefault(options.OutputDirectory, ".")
+	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create output directory %s: %w", outputDirectory, err)
+	}
+
+	if options.Input {
+		if _, err := os.Stat(imageName); err != nil {
+			return nil, fmt.Errorf("Failed to read image input %s: %w", imageName, err)
+		}
+	}
+
+	outputFiles := getOutputFiles(outputDirectory, imageName, options.OutputPerImage)
+
+	if skipDBUpdate {
+		warnIfDBOutdated(options.DB, options.DBMaxAge, time.Now())
+	}
+
+	includeUnfixed := options.IncludeUnfixed
+	var policy *Policy
+	if options.PolicyFile != "" {
+		policyFromFile, err := readPolicyFile(options.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		policy = &policyFromFile
+
+		if policy.IgnoreUnfixed != nil {
+			includeUnfixed = !*policy.IgnoreUnfixed
+		}
+
+		if err := validatePolicyData(*policy, options.Enrichment); err != nil {
+			return nil, fmt.Errorf("Invalid policy file %s: %w", options.PolicyFile, err)
+		}
+	}
+
+	vexDocuments, err := ReadVEXFiles(options.VEXFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	enrichment := options.enrichment
+	if enrichment == nil && options.Enrichment.enabled() {
+		var err error
+		enrichment, err = loadEnrichmentFeeds(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scanImageOutput := scanImageCommand(
+		imageName,
+		severity,
+		// When using a policy or VEX documents, the scan fails based on the filtered results instead of Trivy's exit code.
+		disableError || policy != nil || len(vexDocuments) > 0,
+		skipDBUpdate,
+		outputFiles.JSON,
+		&ScanImageCommandOptions{
+			IncludeUnfixed:   includeUnfixed,
+			SkipJavaDBUpdate: options.SkipJavaDBUpdate,
+			InMemoryCache:    options.InMemoryCache,
+			Input:            options.Input,
+			DB:               options.DB,
+		},
 	)
 
-	if _, err := os.Stat("trivy.json"); errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(outputFiles.JSON); errors.Is(err, os.ErrNotExist) {
 		if disableError {
 			log.Println("Trivy did not produce any output")
-			return nil
+			return nil, nil
 		}
 
-		return fmt.Errorf("Trivy did not produce any output")
+		return nil, fmt.Errorf("Trivy did not produce any output")
+	}
+
+	if len(vexDocuments) > 0 {
+		log.Println("Applying VEX statements")
+
+		imageNames, err := scannedImageNames(imageName, outputFiles.JSON)
+		if err != nil {
+			return nil, err
+		}
+
+		suppressions, err := applyVEX(outputFiles.JSON, vexDocuments, imageNames)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to apply VEX statements: %w", err)
+		}
+
+		logVEXSuppressions(suppressions)
+	}
+
+	if options.Licenses != nil {
+		log.Println("Scanning image for licenses")
+
+		licenseScanOutput := licenseScanCommand(
+			imageName,
+			outputFiles.Licenses,
+			&ScanImageCommandOptions{
+				InMemoryCache: options.InMemoryCache,
+				Input:         options.Input,
+				DB:            options.DB,
+			},
+		)
+		if command.IsError(licenseScanOutput) {
+			return nil, fmt.Errorf("Failed to scan image for licenses: %w", licenseScanOutput.Error)
+		}
+
+		if err := mergeLicenseResults(outputFiles.JSON, outputFiles.Licenses); err != nil {
+			return nil, fmt.Errorf("Failed to merge license scan results: %w", err)
+		}
+
+		if err := os.Remove(outputFiles.Licenses); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := readJSONOutput(outputFiles.JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	enrichResult(&result, enrichment)
+
+	var licenseFindings []LicenseFinding
+	var licenseErr error
+	if options.Licenses != nil {
+		licenseFindings = evaluateLicenses(*options.Licenses, result)
+		logLicenseFindings(licenseFindings)
+
+		deniedLicenses := countLicenseAction(licenseFindings, LicenseActionDeny)
+		if deniedLicenses > 0 && !disableError {
+			licenseErr = fmt.Errorf("%d denied licenses found in %s", deniedLicenses, imageName)
+		}
+	}
+
+	vulnerabilityCounts := toSeverityCounts(allVulnerabilities(result.Results))
+
+	var vexErr error
+	if len(vexDocuments) > 0 && policy == nil && !disableError {
+		if remaining := len(allVulnerabilities(result.Results)); remaining > 0 {
+			vexErr = fmt.Errorf("%d vulnerabilities found in %s after applying VEX statements", remaining, imageName)
+		}
+	}
+
+	var policyErr error
+	if policy != nil {
+		log.Printf("Evaluating vulnerability policy %s\n", options.PolicyFile)
+
+		policyResult := evaluatePolicy(*policy, result, time.Now())
+		logPolicyResult(policyResult)
+
+		if policyResult.Failed() && !disableError {
+			policyErr = fmt.Errorf(
+				"Vulnerability policy failed for %s: %d vulnerabilities not allowed by policy",
+				imageName,
+				policyResult.countAction(PolicyActionFail),
+			)
+		}
 	}
 
 	if slices.Contains(formats, "table") {
 		log.Println("Converting results to table format")
 
+		tableOutput := options.tableOutput
+		if tableOutput == nil {
+			tableOutput = os.Stdout
+		}
+
 		convertOutput := convertCommand(
 			"table",
-			nil,
+			outputFiles.JSON,
+			"",
+			&command.RunOptions{Quiet: options.tableOutput != nil},
 		)
 		if command.IsError(convertOutput) {
-			return convertOutput.Error
+			return vulnerabilityCounts, convertOutput.Error
+		}
+
+		if options.tableOutput != nil {
+			fmt.Fprint(tableOutput, convertOutput.Output)
+		}
+
+		if enrichment != nil && len(allVulnerabilities(result.Results)) > 0 {
+			fmt.Fprintln(tableOutput, formatEnrichedTable(result, options.SortBy))
 		}
 	}
 
@@ -205,46 +617,95 @@ func ScanImage(
 
 		convertOutput := convertCommand(
 			"sarif",
+			outputFiles.JSON,
+			outputFiles.SARIF,
 			nil,
 		)
 		if command.IsError(convertOutput) {
-			return convertOutput.Error
+			return vulnerabilityCounts, convertOutput.Error
 		}
 	}
 
 	if slices.Contains(formats, "markdown") {
 		log.Println("Converting results to Markdown format")
 
-		result, err := parseJSONOutput()
+		markdown, err := generateMarkdown(result, licenseFindings, options.TemplateFile, options.SortBy)
 		if err != nil {
-			return err
+			return vulnerabilityCounts, err
+		}
+		if len(markdown) == 0 {
+			log.Println("Markdown output is empty, will write to empty file")
 		}
 
-		markdown, err := toMarkdown(result)
+		err = os.WriteFile(outputFiles.Markdown, markdown, 0644)
 		if err != nil {
-			return err
+			return vulnerabilityCounts, err
 		}
-		if len(markdown) == 0 {
-			log.Println("Markdown output is empty, will write to empty file")
+	}
+
+	if options.GitHubReport {
+		if isRunningInGitHubActions() {
+			markdown, err := generateMarkdown(result, licenseFindings, options.TemplateFile, options.SortBy)
+			if err != nil {
+				return vulnerabilityCounts, err
+			}
+
+			// Reporting must not fail the scan, nor skip writing the other outputs.
+			if err := reportToGitHub(imageName, markdown, getGitHubEnvironment()); err != nil {
+				log.Printf("WARNING: Failed to report scan results to GitHub: %s\n", err)
+			}
+		} else {
+			log.Println("Not running in GitHub Actions, will not report scan results to GitHub")
+		}
+	}
+
+	if slices.Contains(formats, "junit") {
+		log.Println("Converting results to JUnit format")
+
+		junit, err := toJUnit(result)
+		if err != nil {
+			return vulnerabilityCounts, err
 		}
 
-		err = os.WriteFile("trivy.md", markdown, 0644)
+		err = os.WriteFile(outputFiles.JUnit, junit, 0644)
 		if err != nil {
-			return err
+			return vulnerabilityCounts, err
 		}
 	}
 
 	if !slices.Contains(formats, "json") {
-		err := os.Remove("trivy.json")
+		err := os.Remove(outputFiles.JSON)
 		if err != nil {
-			return err
+			return vulnerabilityCounts, err
 		}
 	} else {
 		log.Println("Keeping pre-existing JSON output")
 	}
 
 	if command.IsError(scanImageOutput) {
-		return scanImageOutput.Error
+		return vulnerabilityCounts, scanImageOutput.Error
+	}
+
+	return vulnerabilityCounts, errors.Join(vexErr, policyErr, licenseErr)
+}
+
+func generateMarkdown(
+	result TrivyResult,
+	licenses []LicenseFinding,
+	templateFile string,
+	sortBy string,
+) ([]byte, error) {
+	if templateFile != "" {
+		return toCustomTemplate(templateFile, result)
+	}
+
+	return toMarkdown(toTrivyVulnerabilityResultsWithArtifactName(result), reportedLicenses(licenses), sortBy)
+}
+
+// ValidateSortBy checks the value of a sort order flag.
+func ValidateSortBy(c *cli.Context, sortBy string) error {
+	if sortBy != SortBySeverity && sortBy != SortByEPSS {
+		return cli.Exit("Invalid sort order provided: must be severity or epss", 1)
 	}
 
 	return nil
@@ -42,7 +42,10 @@ func TestScanImageCommand1(t *testing.T) {
 		severity,
 		disableError,
 		skipUpdate,
-		&command.RunOptions{DryRun: true},
+		"trivy.json",
+		&ScanImageCommandOptions{
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -86,7 +89,10 @@ func TestScanImageCommand2(t *testing.T) {
 		severity,
 		disableError,
 		skipUpdate,
-		&command.RunOptions{DryRun: true},
+		"trivy.json",
+		&ScanImageCommandOptions{
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -131,7 +137,10 @@ func TestScanImageCommand3(t *testing.T) {
 		severity,
 		disableError,
 		skipUpdate,
-		&command.RunOptions{DryRun: true},
+		"trivy.json",
+		&ScanImageCommandOptions{
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -175,7 +184,10 @@ func TestScanImageCommand4(t *testing.T) {
 		severity,
 		disableError,
 		skipUpdate,
-		&command.RunOptions{DryRun: true},
+		"trivy.json",
+		&ScanImageCommandOptions{
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
 	)
 
 	command.ExpectedCommandStringEqualsActualCommand(
@@ -220,6 +232,63 @@ func TestScanImageCommand5(t *testing.T) {
 		severity,
 		disableError,
 		skipUpdate,
+		"trivy.json",
+		&ScanImageCommandOptions{
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestConvertCommand1(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"trivy",
+			"convert",
+			"--format",
+			"table",
+			"scan-results/trivy-debian-10.json",
+		},
+		" ",
+	)
+
+	actualCommand := convertCommand(
+		"table",
+		"scan-results/trivy-debian-10.json",
+		"",
+		&command.RunOptions{DryRun: true},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestConvertCommand2(t *testing.T) {
+	expectedCommandString := strings.Join(
+		[]string{
+			"trivy",
+			"convert",
+			"--format",
+			"sarif",
+			"--output",
+			"scan-results/trivy-debian-10.sarif",
+			"scan-results/trivy-debian-10.json",
+		},
+		" ",
+	)
+
+	actualCommand := convertCommand(
+		"sarif",
+		"scan-results/trivy-debian-10.json",
+		"scan-results/trivy-debian-10.sarif",
 		&command.RunOptions{DryRun: true},
 	)
 
@@ -229,3 +298,159 @@ func TestScanImageCommand5(t *testing.T) {
 		actualCommand,
 	)
 }
+
+func TestScanImageCommand6(t *testing.T) {
+	const imageName = "test-image:v42"
+	const severity = "CRITICAL,HIGH"
+	const disableError = false
+	const skipUpdate = true
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"image",
+			"--severity",
+			severity,
+			"--exit-code",
+			"1",
+			"--timeout",
+			"15m0s",
+			"--format",
+			"json",
+			"--output",
+			"trivy-test-image-v42.json",
+			"--db-repository",
+			"ghcr.io/3lvia/trivy-db",
+			"--java-db-repository",
+			"ghcr.io/3lvia/trivy-java-db",
+			"--ignore-unfixed",
+			"--skip-db-update",
+			"--skip-java-db-update",
+			"--cache-backend",
+			"memory",
+			imageName,
+		},
+		" ",
+	)
+
+	actualCommand := scanImageCommand(
+		imageName,
+		severity,
+		disableError,
+		skipUpdate,
+		"trivy-test-image-v42.json",
+		&ScanImageCommandOptions{
+			SkipJavaDBUpdate: true,
+			InMemoryCache:    true,
+			RunOptions:       &command.RunOptions{DryRun: true},
+		},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestScanImageCommand7(t *testing.T) {
+	const imageName = "test-image:latest"
+	const severity = "CRITICAL,HIGH"
+	const disableError = false
+	const skipUpdate = false
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"image",
+			"--severity",
+			severity,
+			"--exit-code",
+			"1",
+			"--timeout",
+			"15m0s",
+			"--format",
+			"json",
+			"--output",
+			"trivy.json",
+			"--db-repository",
+			"ghcr.io/3lvia/trivy-db",
+			"--java-db-repository",
+			"ghcr.io/3lvia/trivy-java-db",
+			imageName,
+		},
+		" ",
+	)
+
+	actualCommand := scanImageCommand(
+		imageName,
+		severity,
+		disableError,
+		skipUpdate,
+		"trivy.json",
+		&ScanImageCommandOptions{
+			IncludeUnfixed: true,
+			RunOptions:     &command.RunOptions{DryRun: true},
+		},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
+
+func TestScanImageCommand8(t *testing.T) {
+	const imageName = "image.tar"
+	const severity = "CRITICAL,HIGH"
+	const disableError = false
+	const skipUpdate = true
+
+	expectedCommandString := strings.Join(
+		[]string{
+			"image",
+			"--severity",
+			severity,
+			"--exit-code",
+			"1",
+			"--timeout",
+			"15m0s",
+			"--format",
+			"json",
+			"--output",
+			"trivy.json",
+			"--db-repository",
+			"registry.example.com/trivy-db",
+			"--java-db-repository",
+			"ghcr.io/3lvia/trivy-java-db",
+			"--ignore-unfixed",
+			"--skip-db-update",
+			"--cache-dir",
+			"/opt/trivy",
+			"--input",
+			imageName,
+		},
+		" ",
+	)
+
+	actualCommand := scanImageCommand(
+		imageName,
+		severity,
+		disableError,
+		skipUpdate,
+		"trivy.json",
+		&ScanImageCommandOptions{
+			Input: true,
+			DB: DBOptions{
+				DBRepository: "registry.example.com/trivy-db",
+				CacheDir:     "/opt/trivy",
+			},
+			RunOptions: &command.RunOptions{DryRun: true},
+		},
+	)
+
+	command.ExpectedCommandStringEqualsActualCommand(
+		t,
+		expectedCommandString,
+		actualCommand,
+	)
+}
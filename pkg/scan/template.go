@@ -0,0 +1,116 @@
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Severities in order from most to least severe, as reported by Trivy.
+var severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+var templateFuncs = template.FuncMap{
+	"severities":      func() []string { return severities },
+	"vulnerabilities": allVulnerabilities,
+	"countSeverity":   countSeverity,
+	"severityCounts":  severityCounts,
+	"sortBySeverity":  sortBySeverity,
+	"sortByEPSS":      sortByEPSS,
+	"percent":         formatPercent,
+	"truncate":        truncate,
+	"upper":           strings.ToUpper,
+	"lower":           strings.ToLower,
+	"join":            strings.Join,
+}
+
+func allVulnerabilities(results []TrivyVulnerabilityResult) []TrivyVulnerability {
+	var vulnerabilities []TrivyVulnerability
+	for _, result := range results {
+		vulnerabilities = append(vulnerabilities, result.Vulnerabilities...)
+	}
+
+	return vulnerabilities
+}
+
+func countSeverity(severity string, vulnerabilities []TrivyVulnerability) int {
+	count := 0
+	for _, vulnerability := range vulnerabilities {
+		if strings.EqualFold(vulnerability.Severity, severity) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func severityCounts(vulnerabilities []TrivyVulnerability) map[string]int {
+	counts := make(map[string]int, len(severities))
+	for _, severity := range severities {
+		counts[severity] = 0
+	}
+
+	for _, vulnerability := range vulnerabilities {
+		counts[strings.ToUpper(vulnerability.Severity)]++
+	}
+
+	return counts
+}
+
+func severityRank(severity string) int {
+	rank := slices.Index(severities, strings.ToUpper(severity))
+	if rank == -1 {
+		return len(severities)
+	}
+
+	return rank
+}
+
+func sortBySeverity(vulnerabilities []TrivyVulnerability) []TrivyVulnerability {
+	sorted := slices.Clone(vulnerabilities)
+	slices.SortStableFunc(sorted, compareBySeverity)
+
+	return sorted
+}
+
+func truncate(length int, str string) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+
+	if length <= 3 {
+		return string(runes[:length])
+	}
+
+	return string(runes[:length-3]) + "..."
+}
+
+func renderTemplate(markdownTemplate *template.Template, data any) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := markdownTemplate.Execute(&buffer, data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute template: %v", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func toCustomTemplate(templatePath string, result TrivyResult) ([]byte, error) {
+	templateContent, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read template file %s: %v", templatePath, err)
+	}
+
+	customTemplate, err := template.New(filepath.Base(templatePath)).
+		Funcs(templateFuncs).
+		Parse(string(templateContent))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse template file %s: %v", templatePath, err)
+	}
+
+	return renderTemplate(customTemplate, result)
+}
@@ -0,0 +1,101 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTruncate1(t *testing.T) {
+	const expected = "short"
+	actual := truncate(10, "short")
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestTruncate2(t *testing.T) {
+	const expected = "a very..."
+	actual := truncate(9, "a very long description")
+
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestSortBySeverity(t *testing.T) {
+	vulnerabilities := []TrivyVulnerability{
+		{VulnerabilityID: "CVE-1", Severity: "LOW"},
+		{VulnerabilityID: "CVE-2", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-3", Severity: "UNKNOWN"},
+		{VulnerabilityID: "CVE-4", Severity: "HIGH"},
+		{VulnerabilityID: "CVE-5", Severity: "CRITICAL"},
+	}
+
+	expected := []string{"CVE-2", "CVE-5", "CVE-4", "CVE-1", "CVE-3"}
+	actual := sortBySeverity(vulnerabilities)
+
+	for i, vulnerability := range actual {
+		if vulnerability.VulnerabilityID != expected[i] {
+			t.Errorf("Expected %s at index %d, got %s", expected[i], i, vulnerability.VulnerabilityID)
+		}
+	}
+
+	if vulnerabilities[0].VulnerabilityID != "CVE-1" {
+		t.Errorf("Expected input slice to be left unchanged")
+	}
+}
+
+func TestSeverityCounts(t *testing.T) {
+	vulnerabilities := []TrivyVulnerability{
+		{Severity: "CRITICAL"},
+		{Severity: "HIGH"},
+		{Severity: "CRITICAL"},
+	}
+
+	counts := severityCounts(vulnerabilities)
+
+	if counts["CRITICAL"] != 2 {
+		t.Errorf("Expected %d CRITICAL, got %d", 2, counts["CRITICAL"])
+	}
+
+	if counts["HIGH"] != 1 {
+		t.Errorf("Expected %d HIGH, got %d", 1, counts["HIGH"])
+	}
+
+	if counts["LOW"] != 0 {
+		t.Errorf("Expected %d LOW, got %d", 0, counts["LOW"])
+	}
+}
+
+func TestToCustomTemplate(t *testing.T) {
+	var result TrivyResult
+	if err := json.Unmarshal([]byte(testTrivyJSON), &result); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	templatePath := filepath.Join(t.TempDir(), "custom.tmpl")
+	const customTemplate = `{{ .ArtifactName }}: ` +
+		`{{ countSeverity "CRITICAL" (vulnerabilities .Results) }} critical, ` +
+		`{{ countSeverity "HIGH" (vulnerabilities .Results) }} high` +
+		`{{ range sortBySeverity (vulnerabilities .Results) }}
+- {{ .VulnerabilityID }} {{ truncate 10 .Title }}{{ end }}`
+	if err := os.WriteFile(templatePath, []byte(customTemplate), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	const expected = "test-image:latest: 1 critical, 1 high\n" +
+		"- CVE-2024-0001 openssl...\n" +
+		"- CVE-2024-0002 openssl..."
+
+	actual, err := toCustomTemplate(templatePath, result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if string(actual) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(actual))
+	}
+}
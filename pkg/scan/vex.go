@@ -0,0 +1,405 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	VEXStatusNotAffected        = "not_affected"
+	VEXStatusAffected           = "affected"
+	VEXStatusFixed              = "fixed"
+	VEXStatusUnderInvestigation = "under_investigation"
+)
+
+// VEXDocument is an OpenVEX document, see https://github.com/openvex/spec.
+type VEXDocument struct {
+	Context    string         `json:"@context"`
+	ID         string         `json:"@id"`
+	Author     string         `json:"author"`
+	Timestamp  string         `json:"timestamp"`
+	Statements []VEXStatement `json:"statements"`
+}
+
+type VEXStatement struct {
+	Vulnerability   VEXVulnerability `json:"vulnerability"`
+	Products        []VEXProduct     `json:"products"`
+	Status          string           `json:"status"`
+	Justification   string           `json:"justification"`
+	ImpactStatement string           `json:"impact_statement"`
+}
+
+type VEXVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// UnmarshalJSON also accepts the vulnerability as a plain string, as in OpenVEX v0.0.1.
+func (vulnerability *VEXVulnerability) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		vulnerability.Name = name
+		return nil
+	}
+
+	type plainVEXVulnerability VEXVulnerability
+	return json.Unmarshal(data, (*plainVEXVulnerability)(vulnerability))
+}
+
+type VEXProduct struct {
+	ID            string         `json:"@id"`
+	Subcomponents []VEXComponent `json:"subcomponents"`
+}
+
+type VEXComponent struct {
+	ID string `json:"@id"`
+}
+
+// UnmarshalJSON also accepts the product as a plain string, as in OpenVEX v0.0.1.
+func (product *VEXProduct) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		product.ID = id
+		return nil
+	}
+
+	type plainVEXProduct VEXProduct
+	return json.Unmarshal(data, (*plainVEXProduct)(product))
+}
+
+func readVEXFile(vexFile string) (VEXDocument, error) {
+	content, err := os.ReadFile(vexFile)
+	if err != nil {
+		return VEXDocument{}, fmt.Errorf("Failed to read VEX file %s: %w", vexFile, err)
+	}
+
+	var document VEXDocument
+	if err := json.Unmarshal(content, &document); err != nil {
+		return VEXDocument{}, fmt.Errorf("Failed to parse VEX file %s: %w", vexFile, err)
+	}
+
+	if !strings.HasPrefix(document.Context, "https://openvex.dev/ns") {
+		return VEXDocument{}, fmt.Errorf("VEX file %s is not an OpenVEX document", vexFile)
+	}
+
+	for i, statement := range document.Statements {
+		if statement.Vulnerability.Name == "" {
+			return VEXDocument{}, fmt.Errorf("Statement %d in VEX file %s has no vulnerability", i+1, vexFile)
+		}
+
+		switch statement.Status {
+		case VEXStatusNotAffected, VEXStatusAffected, VEXStatusFixed, VEXStatusUnderInvestigation:
+		default:
+			return VEXDocument{}, fmt.Errorf("Invalid status %s in statement %d in VEX file %s", statement.Status, i+1, vexFile)
+		}
+	}
+
+	return document, nil
+}
+
+// ReadVEXFiles reads and validates OpenVEX documents.
+func ReadVEXFiles(vexFiles []string) ([]VEXDocument, error) {
+	var documents []VEXDocument
+	for _, vexFile := range vexFiles {
+		document, err := readVEXFile(vexFile)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+// parsePURL splits a package URL into the package without version, qualifiers and subpath, and its version and qualifiers.
+func parsePURL(purl string) (string, string, url.Values) {
+	purl, _, _ = strings.Cut(purl, "#")
+	purl, rawQualifiers, _ := strings.Cut(purl, "?")
+	qualifiers, _ := url.ParseQuery(rawQualifiers)
+
+	// The version comes after the last @ in the name, namespaces may contain an encoded @.
+	name, version := purl, ""
+	if at := strings.LastIndex(purl, "@"); at > strings.LastIndex(purl, "/") {
+		name, version = purl[:at], purl[at+1:]
+	}
+
+	return strings.ToLower(name), version, qualifiers
+}
+
+// imageRepository returns the repository of an image reference, without tag and digest.
+func imageRepository(imageName string) string {
+	repository, _, _ := strings.Cut(imageName, "@")
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository = repository[:colon]
+	}
+
+	return strings.ToLower(repository)
+}
+
+// matchesVEXProduct returns whether the product identifies one of the scanned images.
+// Products are either OCI package URLs or image references. Tags, digests and versions are ignored,
+// so that statements apply to all versions of an application.
+func matchesVEXProduct(productID string, imageNames []string) bool {
+	if productID == "" {
+		return false
+	}
+
+	if strings.HasPrefix(productID, "pkg:oci/") {
+		name, _, qualifiers := parsePURL(productID)
+		name = strings.TrimPrefix(name, "pkg:oci/")
+
+		for _, imageName := range imageNames {
+			repository := imageRepository(imageName)
+
+			if repositoryURL := qualifiers.Get("repository_url"); repositoryURL != "" {
+				if imageRepository(repositoryURL) == repository {
+					return true
+				}
+				continue
+			}
+
+			if repository == name || strings.HasSuffix(repository, "/"+name) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, imageName := range imageNames {
+		if imageRepository(productID) == imageRepository(imageName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesVEXComponent returns whether the subcomponent identifies the vulnerable package.
+// Subcomponents are package URLs, where the version must match if given, or package names.
+func matchesVEXComponent(componentID string, vulnerability vexVulnerabilityFinding) bool {
+	if !strings.HasPrefix(componentID, "pkg:") {
+		return componentID == vulnerability.PkgName
+	}
+
+	if vulnerability.PkgIdentifier.PURL == "" {
+		return false
+	}
+
+	name, version, _ := parsePURL(componentID)
+	packageName, packageVersion, _ := parsePURL(vulnerability.PkgIdentifier.PURL)
+
+	return name == packageName && (version == "" || version == packageVersion)
+}
+
+// vexVulnerabilityFinding holds the fields of a Trivy vulnerability needed to apply VEX statements.
+type vexVulnerabilityFinding struct {
+	VulnerabilityID string `json:"VulnerabilityID"`
+	PkgName         string `json:"PkgName"`
+	PkgIdentifier   struct {
+		PURL string `json:"PURL"`
+	} `json:"PkgIdentifier"`
+}
+
+func matchesVEXStatement(
+	statement VEXStatement,
+	vulnerability vexVulnerabilityFinding,
+	imageNames []string,
+) bool {
+	if !strings.EqualFold(statement.Vulnerability.Name, vulnerability.VulnerabilityID) &&
+		!containsFold(statement.Vulnerability.Aliases, vulnerability.VulnerabilityID) {
+		return false
+	}
+
+	for _, product := range statement.Products {
+		if !matchesVEXProduct(product.ID, imageNames) {
+			continue
+		}
+
+		if len(product.Subcomponents) == 0 {
+			return true
+		}
+
+		for _, component := range product.Subcomponents {
+			if matchesVEXComponent(component.ID, vulnerability) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// vexStatus returns the statement applying to the vulnerability, if any.
+// Later statements override earlier ones, also across documents.
+func vexStatus(
+	documents []VEXDocument,
+	vulnerability vexVulnerabilityFinding,
+	imageNames []string,
+) (VEXStatement, bool) {
+	var matched VEXStatement
+	found := false
+
+	for _, document := range documents {
+		for _, statement := range document.Statements {
+			if matchesVEXStatement(statement, vulnerability, imageNames) {
+				matched = statement
+				found = true
+			}
+		}
+	}
+
+	return matched, found
+}
+
+type VEXSuppression struct {
+	Target          string
+	VulnerabilityID string
+	PkgName         string
+	Statement       VEXStatement
+}
+
+func (suppression VEXSuppression) String() string {
+	explanation := fmt.Sprintf(
+		"Suppressed %s in %s (%s) by VEX: %s",
+		suppression.VulnerabilityID,
+		suppression.PkgName,
+		suppression.Target,
+		suppression.Statement.Status,
+	)
+
+	if suppression.Statement.Justification != "" {
+		explanation += ", " + suppression.Statement.Justification
+	}
+
+	if suppression.Statement.ImpactStatement != "" {
+		explanation += ": " + suppression.Statement.ImpactStatement
+	}
+
+	return explanation
+}
+
+// applyVEX removes vulnerabilities declared not_affected or fixed by the VEX documents from the Trivy JSON output,
+// so that all outputs converted from the JSON file exclude them. Raw JSON is used to avoid dropping any fields
+// not modelled by TrivyResult. imageNames are the names the scanned image is known by, used to match VEX products.
+func applyVEX(jsonFile string, documents []VEXDocument, imageNames []string) ([]VEXSuppression, error) {
+	content, err := os.ReadFile(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var report map[string]json.RawMessage
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %w", jsonFile, err)
+	}
+
+	rawResults, ok := report["Results"]
+	if !ok {
+		return nil, nil
+	}
+
+	var results []map[string]json.RawMessage
+	if err := json.Unmarshal(rawResults, &results); err != nil {
+		return nil, fmt.Errorf("Failed to parse results in %s: %w", jsonFile, err)
+	}
+
+	var suppressions []VEXSuppression
+	for _, result := range results {
+		rawVulnerabilities, ok := result["Vulnerabilities"]
+		if !ok {
+			continue
+		}
+
+		var target string
+		if err := json.Unmarshal(result["Target"], &target); err != nil {
+			return nil, fmt.Errorf("Failed to parse target in %s: %w", jsonFile, err)
+		}
+
+		var vulnerabilities []json.RawMessage
+		if err := json.Unmarshal(rawVulnerabilities, &vulnerabilities); err != nil {
+			return nil, fmt.Errorf("Failed to parse vulnerabilities in %s: %w", jsonFile, err)
+		}
+
+		var remaining []json.RawMessage
+		for _, rawVulnerability := range vulnerabilities {
+			var vulnerability vexVulnerabilityFinding
+			if err := json.Unmarshal(rawVulnerability, &vulnerability); err != nil {
+				return nil, fmt.Errorf("Failed to parse vulnerability in %s: %w", jsonFile, err)
+			}
+
+			statement, found := vexStatus(documents, vulnerability, imageNames)
+			if found && (statement.Status == VEXStatusNotAffected || statement.Status == VEXStatusFixed) {
+				suppressions = append(suppressions, VEXSuppression{
+					Target:          target,
+					VulnerabilityID: vulnerability.VulnerabilityID,
+					PkgName:         vulnerability.PkgName,
+					Statement:       statement,
+				})
+				continue
+			}
+
+			remaining = append(remaining, rawVulnerability)
+		}
+
+		if len(remaining) == 0 {
+			delete(result, "Vulnerabilities")
+			continue
+		}
+
+		result["Vulnerabilities"], err = json.Marshal(remaining)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report["Results"], err = json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+
+	filteredReport, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return suppressions, os.WriteFile(jsonFile, filteredReport, 0644)
+}
+
+// scannedImageNames returns the names the scanned image is known by, from the Trivy JSON output,
+// which includes the tags of image tarballs.
+func scannedImageNames(imageName string, jsonFile string) ([]string, error) {
+	content, err := os.ReadFile(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var report struct {
+		ArtifactName string `json:"ArtifactName"`
+		Metadata     struct {
+			RepoTags    []string `json:"RepoTags"`
+			RepoDigests []string `json:"RepoDigests"`
+		} `json:"Metadata"`
+	}
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %w", jsonFile, err)
+	}
+
+	imageNames := []string{imageName, report.ArtifactName}
+	imageNames = append(imageNames, report.Metadata.RepoTags...)
+	imageNames = append(imageNames, report.Metadata.RepoDigests...)
+
+	return imageNames, nil
+}
+
+func logVEXSuppressions(suppressions []VEXSuppression) {
+	for _, suppression := range suppressions {
+		log.Println(suppression)
+	}
+
+	log.Printf("VEX result: %d vulnerabilities suppressed\n", len(suppressions))
+}
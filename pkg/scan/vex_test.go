@@ -0,0 +1,185 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testVEXJSON = `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "https://github.com/3lvia/core/vex/test-image",
+  "author": "Elvia",
+  "timestamp": "2024-07-01T00:00:00Z",
+  "version": 1,
+  "statements": [
+    {
+      "vulnerability": { "name": "CVE-2024-0001" },
+      "products": [
+        {
+          "@id": "pkg:oci/test-image?repository_url=ghcr.io/3lvia/test-image",
+          "subcomponents": [{ "@id": "pkg:apk/alpine/libcrypto3" }]
+        }
+      ],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": { "name": "GHSA-xxxx-yyyy-zzzz", "aliases": ["CVE-2024-0002"] },
+      "products": [{ "@id": "ghcr.io/3lvia/test-image:v1" }],
+      "status": "under_investigation"
+    },
+    {
+      "vulnerability": { "name": "CVE-2024-0003" },
+      "products": [{ "@id": "ghcr.io/3lvia/other-image" }],
+      "status": "not_affected",
+      "justification": "component_not_present"
+    }
+  ]
+}`
+
+const testVEXTrivyJSON = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "ghcr.io/3lvia/test-image:latest",
+  "Results": [
+    {
+      "Target": "ghcr.io/3lvia/test-image:latest (alpine 3.20.0)",
+      "Class": "os-pkgs",
+      "Vulnerabilities": [
+        {
+          "VulnerabilityID": "CVE-2024-0001",
+          "PkgName": "libcrypto3",
+          "PkgIdentifier": { "PURL": "pkg:apk/alpine/libcrypto3@3.3.0-r0?distro=3.20.0" },
+          "Severity": "CRITICAL"
+        },
+        {
+          "VulnerabilityID": "CVE-2024-0002",
+          "PkgName": "libssl3",
+          "Severity": "HIGH"
+        },
+        {
+          "VulnerabilityID": "CVE-2024-0003",
+          "PkgName": "busybox",
+          "Severity": "HIGH"
+        }
+      ]
+    }
+  ]
+}`
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	fileName := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	return fileName
+}
+
+func TestReadVEXFile1(t *testing.T) {
+	document, err := readVEXFile(writeTestFile(t, "vex.json", testVEXJSON))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(document.Statements) != 3 {
+		t.Fatalf("Expected %d statements, got %d", 3, len(document.Statements))
+	}
+
+	if document.Statements[1].Vulnerability.Aliases[0] != "CVE-2024-0002" {
+		t.Errorf("Expected alias CVE-2024-0002, got %v", document.Statements[1].Vulnerability)
+	}
+}
+
+func TestReadVEXFile2(t *testing.T) {
+	const content = `{
+  "@context": "https://openvex.dev/ns",
+  "statements": [
+    { "vulnerability": "CVE-2024-0001", "products": ["ghcr.io/3lvia/test-image"], "status": "fixed" }
+  ]
+}`
+
+	document, err := readVEXFile(writeTestFile(t, "vex.json", content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	statement := document.Statements[0]
+	if statement.Vulnerability.Name != "CVE-2024-0001" || statement.Products[0].ID != "ghcr.io/3lvia/test-image" {
+		t.Errorf("Expected OpenVEX v0.0.1 statement to be parsed, got %+v", statement)
+	}
+}
+
+func TestReadVEXFile3(t *testing.T) {
+	const content = `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "statements": [{ "vulnerability": { "name": "CVE-2024-0001" }, "status": "ignored" }]
+}`
+
+	if _, err := readVEXFile(writeTestFile(t, "vex.json", content)); err == nil {
+		t.Errorf("Expected error for invalid status, got nil")
+	}
+}
+
+func TestMatchesVEXProduct(t *testing.T) {
+	imageNames := []string{"ghcr.io/3lvia/test-image:latest"}
+
+	expected := map[string]bool{
+		"pkg:oci/test-image?repository_url=ghcr.io/3lvia/test-image":            true,
+		"pkg:oci/test-image@sha256:abc?repository_url=ghcr.io/3lvia/test-image": true,
+		"pkg:oci/test-image?repository_url=ghcr.io/other/test-image":            false,
+		"pkg:oci/test-image":              true,
+		"ghcr.io/3lvia/test-image:v1.0.0": true,
+		"ghcr.io/3lvia/test-image":        true,
+		"ghcr.io/3lvia/other-image":       false,
+		"":                                false,
+	}
+
+	for productID, expectedMatch := range expected {
+		if matchesVEXProduct(productID, imageNames) != expectedMatch {
+			t.Errorf("Expected match to be %t for %s", expectedMatch, productID)
+		}
+	}
+}
+
+func TestApplyVEX(t *testing.T) {
+	jsonFile := writeTestFile(t, "trivy.json", testVEXTrivyJSON)
+
+	document, err := readVEXFile(writeTestFile(t, "vex.json", testVEXJSON))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	imageNames, err := scannedImageNames("ghcr.io/3lvia/test-image:latest", jsonFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	suppressions, err := applyVEX(jsonFile, []VEXDocument{document}, imageNames)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(suppressions) != 1 || suppressions[0].VulnerabilityID != "CVE-2024-0001" {
+		t.Fatalf("Expected only CVE-2024-0001 to be suppressed, got %v", suppressions)
+	}
+
+	const expectedSuppression = "Suppressed CVE-2024-0001 in libcrypto3 (ghcr.io/3lvia/test-image:latest (alpine 3.20.0)) by VEX: not_affected, vulnerable_code_not_in_execute_path"
+	if suppressions[0].String() != expectedSuppression {
+		t.Errorf("Expected %s, got %s", expectedSuppression, suppressions[0])
+	}
+
+	result, err := readJSONOutput(jsonFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	vulnerabilities := allVulnerabilities(result.Results)
+	if len(vulnerabilities) != 2 || vulnerabilities[0].VulnerabilityID != "CVE-2024-0002" {
+		t.Errorf("Expected CVE-2024-0002 and CVE-2024-0003 to remain, got %v", vulnerabilities)
+	}
+
+	if result.SchemaVersion != 2 {
+		t.Errorf("Expected other fields to be kept, got schema version %d", result.SchemaVersion)
+	}
+}
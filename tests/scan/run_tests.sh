@@ -84,6 +84,26 @@ test_markdown_scan() {
     fi
 }
 
+test_junit_scan() {
+    if 3lv scan \
+        --severity CRITICAL,HIGH \
+        --formats junit \
+        debian:10; then
+        echo "Scan should have failed"
+        exit 1
+    fi
+
+    if [[ ! -f trivy.junit.xml ]]; then
+        echo "ERROR: File 'trivy.junit.xml' not found"
+        exit 1
+    fi
+
+    if [[ -f trivy.json ]]; then
+        echo "ERROR: File 'trivy.json' not cleaned up"
+        exit 1
+    fi
+}
+
 test_json_sarif_scan() {
     if 3lv scan \
         --severity CRITICAL,HIGH \
@@ -254,6 +274,47 @@ test_all_outputs_scan() {
     fi
 }
 
+test_output_dir_per_image_scan() {
+    if 3lv scan \
+        --severity CRITICAL,HIGH \
+        --formats json,sarif \
+        --output-dir scan-results \
+        --output-per-image \
+        debian:10; then
+        echo "Scan should have failed"
+        exit 1
+    fi
+
+    if [[ ! -f scan-results/trivy-debian-10.json ]]; then
+        echo "ERROR: File 'scan-results/trivy-debian-10.json' not found"
+        exit 1
+    fi
+
+    if [[ ! -f scan-results/trivy-debian-10.sarif ]]; then
+        echo "ERROR: File 'scan-results/trivy-debian-10.sarif' not found"
+        exit 1
+    fi
+}
+
+test_multiple_images_scan() {
+    if 3lv scan \
+        --severity CRITICAL,HIGH \
+        --formats json,markdown \
+        --output-dir scan-results \
+        --parallel 2 \
+        debian:10 alpine:3.12; then
+        echo "Scan should have failed"
+        exit 1
+    fi
+
+    for file in trivy-debian-10.json trivy-alpine-3.12.json trivy-summary.md; do
+        if [[ ! -f "scan-results/$file" ]]; then
+            echo "ERROR: File 'scan-results/$file' not found"
+            exit 1
+        fi
+    done
+}
+
 test_disable_error_scan() {
     if ! 3lv scan \
         --severity CRITICAL,HIGH \
@@ -295,6 +356,16 @@ cleanup_files() {
         echo 'Removing trivy.md'
         rm trivy.md
     fi
+
+    if [[ -f trivy.junit.xml ]]; then
+        echo 'Removing trivy.junit.xml'
+        rm trivy.junit.xml
+    fi
+
+    if [[ -d scan-results ]]; then
+        echo 'Removing scan-results'
+        rm -r scan-results
+    fi
 }
 
 
@@ -304,6 +375,7 @@ main() {
     test_json_scan
     test_sarif_scan
     test_markdown_scan
+    test_junit_scan
     test_json_sarif_scan
     test_json_markdown_scan
     test_json_table_scan
@@ -312,6 +384,8 @@ main() {
     test_json_sarif_table_scan
     test_sarif_table_markdown_scan
     test_all_outputs_scan
+    test_output_dir_per_image_scan
+    test_multiple_images_scan
     test_disable_error_scan
 
     cleanup_files